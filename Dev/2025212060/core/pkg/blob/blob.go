@@ -0,0 +1,57 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+// ErrNotFound 对象不存在
+var ErrNotFound = errors.New("blob not found")
+
+// Object 存储中的对象信息
+type Object struct {
+	Key     string
+	Size    int64
+	ModTime time.Time
+}
+
+// Store 对象存储接口，key 使用 / 分隔，如 screenshots/<monitor>/<id>.png
+// 本地文件系统之外的实现（如 S3 兼容存储）只需实现这几个方法
+type Store interface {
+	Put(ctx context.Context, key string, r io.Reader, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+	// List 返回以 prefix 开头的对象，按 key 排序
+	List(ctx context.Context, prefix string) ([]Object, error)
+}
+
+// CleanKey 规范化 key，拒绝空 key、绝对路径与 .. 之类越界的写法
+func CleanKey(key string) (string, error) {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return "", fmt.Errorf("invalid blob key: %q", key)
+	}
+	cleaned := path.Clean(key)
+	if cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, "../") || cleaned != key {
+		return "", fmt.Errorf("invalid blob key: %q", key)
+	}
+	return cleaned, nil
+}
+
+// MonitorSegment 把监控 ID 转义为 key 中的一段目录名，避免出现 / 与 ..，
+// 没有监控 ID 的临时检测使用 _adhoc
+func MonitorSegment(monitorID string) string {
+	if monitorID == "" {
+		monitorID = "_adhoc"
+	}
+	escaped := url.PathEscape(monitorID)
+	if escaped == "." || escaped == ".." {
+		escaped = "%2E" + escaped[1:]
+	}
+	return escaped
+}
@@ -0,0 +1,42 @@
+package charset
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html/charset"
+	"golang.org/x/text/encoding"
+)
+
+// Decode 把响应体转换为 UTF-8，依次根据 BOM、Content-Type 的 charset 与前 1024 字节中的 <meta charset> 判断编码
+// 支持 x/text 中的编码，如 GBK、GB18030、Big5、Shift_JIS、EUC-KR，非法字节替换为 U+FFFD
+// 返回解码后的文本与使用的编码名称
+func Decode(body []byte, contentType string) (string, string) {
+	enc, name, certain := charset.DetermineEncoding(body, contentType)
+	// 没有任何声明时 DetermineEncoding 只看前 1024 字节，全是 ASCII 就退回 windows-1252
+	// 整体是合法 UTF-8 时按 UTF-8 处理，否则中文出现在 1024 字节之后的页面会乱码
+	if !certain && name == "windows-1252" && utf8.Valid(body) {
+		enc, name = encoding.Nop, "utf-8"
+	}
+	decoded, err := enc.NewDecoder().Bytes(body)
+	if err != nil {
+		decoded, name = body, "utf-8"
+	}
+	// UTF-8 的 BOM 解码后仍然保留，去掉以免影响匹配
+	return strings.TrimPrefix(strings.ToValidUTF8(string(decoded), "\uFFFD"), "\uFEFF"), name
+}
+
+// Truncate 按字符截断到最多 n 个字符并加上省略号，不会截断多字节字符
+func Truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	i := 0
+	for pos := range s {
+		if i == n {
+			return s[:pos] + "..."
+		}
+		i++
+	}
+	return s
+}
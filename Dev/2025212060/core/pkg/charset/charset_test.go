@@ -0,0 +1,110 @@
+package charset
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/traditionalchinese"
+	"golang.org/x/text/encoding/unicode"
+)
+
+func encode(t *testing.T, enc encoding.Encoding, s string) []byte {
+	t.Helper()
+	b, err := enc.NewEncoder().Bytes([]byte(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestDecode(t *testing.T) {
+	const zh = "<p>红岩网校工作站</p>"
+	const zhHant = "<p>繁體中文測試</p>"
+	const ja = "<p>日本語のテスト</p>"
+	gbkMeta := append([]byte(`<html><head><meta charset="gbk"></head>`), encode(t, simplifiedchinese.GBK, zh)...)
+	longUTF8 := strings.Repeat("a", 2048) + zh
+
+	tests := []struct {
+		name        string
+		body        []byte
+		contentType string
+		want        string
+		wantName    string
+	}{
+		{"gbk from content-type", encode(t, simplifiedchinese.GBK, zh), "text/html; charset=GBK", zh, "gbk"},
+		{"gb2312 label", encode(t, simplifiedchinese.GBK, zh), "text/html; charset=gb2312", zh, "gbk"},
+		{"gb18030", encode(t, simplifiedchinese.GB18030, zh), "text/plain; charset=gb18030", zh, "gb18030"},
+		{"gbk from meta", gbkMeta, "text/html", `<html><head><meta charset="gbk"></head>` + zh, "gbk"},
+		{"big5", encode(t, traditionalchinese.Big5, zhHant), "text/html; charset=big5", zhHant, "big5"},
+		{"shift_jis", encode(t, japanese.ShiftJIS, ja), "text/html; charset=Shift_JIS", ja, "shift_jis"},
+		{"utf-16le bom", encode(t, unicode.UTF16(unicode.LittleEndian, unicode.UseBOM), zh), "text/html", zh, "utf-16le"},
+		{"utf-16be bom", encode(t, unicode.UTF16(unicode.BigEndian, unicode.UseBOM), zh), "text/html", zh, "utf-16be"},
+		// BOM 优先于 Content-Type 的声明
+		{"utf-8 bom overrides header", append([]byte("\xef\xbb\xbf"), zh...), "text/html; charset=gbk", zh, "utf-8"},
+		{"utf-8 declared", []byte(zh), "application/json; charset=utf-8", zh, "utf-8"},
+		{"undeclared utf-8 after 1024 bytes", []byte(longUTF8), "text/html", longUTF8, "utf-8"},
+		{"undeclared latin-1", []byte("caf\xe9"), "text/plain", "café", "windows-1252"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, name := Decode(tt.body, tt.contentType)
+			if got != tt.want || name != tt.wantName {
+				t.Fatalf("Decode = %q, %q; want %q, %q", got, name, tt.want, tt.wantName)
+			}
+		})
+	}
+}
+
+func TestDecodeInvalidBytes(t *testing.T) {
+	// 截断的 GBK 双字节字符
+	body := append(encode(t, simplifiedchinese.GBK, "中文"), 0xd6)
+	got, name := Decode(body, "text/html; charset=gbk")
+	if name != "gbk" || !utf8.ValidString(got) || !strings.HasPrefix(got, "中文") {
+		t.Fatalf("Decode = %q, %q", got, name)
+	}
+	got, _ = Decode([]byte("ok\xff\xfe"+strings.Repeat("x", 2000)), "text/html; charset=utf-8")
+	if !utf8.ValidString(got) || !strings.Contains(got, "�") {
+		t.Fatalf("invalid utf-8 not replaced: %q", got[:10])
+	}
+}
+
+func FuzzDecode(f *testing.F) {
+	f.Add([]byte("<meta charset=gbk>\xd6\xd0"), "text/html")
+	f.Add([]byte("\xff\xfeh\x00i\x00"), "")
+	f.Add([]byte("plain"), "text/plain; charset=big5")
+	f.Fuzz(func(t *testing.T, body []byte, contentType string) {
+		got, name := Decode(body, contentType)
+		if !utf8.ValidString(got) {
+			t.Fatalf("Decode returned invalid utf-8 for %q", body)
+		}
+		if name == "" {
+			t.Fatal("empty encoding name")
+		}
+	})
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		s    string
+		n    int
+		want string
+	}{
+		{"hello", 10, "hello"},
+		{"hello", 5, "hello"},
+		{"hello world", 5, "hello..."},
+		{"红岩网校工作站", 2, "红岩..."},
+		{"红岩网校", 4, "红岩网校"},
+		{"a😀b", 2, "a😀..."},
+		{"abc", 0, "..."},
+		{"", 0, ""},
+	}
+	for _, tt := range tests {
+		if got := Truncate(tt.s, tt.n); got != tt.want {
+			t.Errorf("Truncate(%q, %d) = %q, want %q", tt.s, tt.n, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package har
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// HAR 1.2 格式，只包含 Go HTTP 客户端能填写的字段，浏览器生成的 HAR 直接按原始数据保存
+// 规范：http://www.softwareishard.com/blog/har-12-spec/
+
+type HAR struct {
+	Log Log `json:"log"`
+}
+
+type Log struct {
+	Version string  `json:"version"`
+	Creator Creator `json:"creator"`
+	Entries []Entry `json:"entries"`
+}
+
+type Creator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type Entry struct {
+	StartedDateTime time.Time `json:"startedDateTime"`
+	Time            float64   `json:"time"` // 毫秒，各阶段之和
+	Request         Request   `json:"request"`
+	Response        Response  `json:"response"`
+	Cache           struct{}  `json:"cache"`
+	Timings         Timings   `json:"timings"`
+	ServerIPAddress string    `json:"serverIPAddress,omitempty"`
+	Error           string    `json:"_error,omitempty"` // 请求失败原因，与 Chrome 导出的字段名一致
+}
+
+type Request struct {
+	Method      string      `json:"method"`
+	URL         string      `json:"url"`
+	HTTPVersion string      `json:"httpVersion"`
+	Cookies     []NameValue `json:"cookies"`
+	Headers     []NameValue `json:"headers"`
+	QueryString []NameValue `json:"queryString"`
+	PostData    *PostData   `json:"postData,omitempty"`
+	HeadersSize int64       `json:"headersSize"`
+	BodySize    int64       `json:"bodySize"`
+}
+
+type Response struct {
+	Status      int         `json:"status"`
+	StatusText  string      `json:"statusText"`
+	HTTPVersion string      `json:"httpVersion"`
+	Cookies     []NameValue `json:"cookies"`
+	Headers     []NameValue `json:"headers"`
+	Content     Content     `json:"content"`
+	RedirectURL string      `json:"redirectURL"`
+	HeadersSize int64       `json:"headersSize"`
+	BodySize    int64       `json:"bodySize"`
+}
+
+type NameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type PostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type Content struct {
+	Size     int64  `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"` // 非文本内容为 base64
+}
+
+// Timings 各阶段耗时（毫秒），不适用的阶段为 -1，connect 包含 ssl
+type Timings struct {
+	Blocked float64 `json:"blocked"`
+	DNS     float64 `json:"dns"`
+	Connect float64 `json:"connect"`
+	SSL     float64 `json:"ssl"`
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// Redacted 替换敏感内容后的占位符
+const Redacted = "[REDACTED]"
+
+// sensitiveHeaders 值总是被替换的请求头与响应头
+var sensitiveHeaders = map[string]bool{
+	"authorization":       true,
+	"proxy-authorization": true,
+	"cookie":              true,
+	"set-cookie":          true,
+	"x-api-key":           true,
+	"x-auth-token":        true,
+}
+
+// Redact 替换 HAR 中的认证头、cookie 值，以及任意位置出现的 secrets（含 URL 编码形式）
+// 输入可以是浏览器导出的 HAR，未知字段原样保留
+func Redact(data []byte, secrets ...string) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var doc interface{}
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("decode har failed: %w", err)
+	}
+
+	var replacements []string
+	for _, s := range secrets {
+		if s == "" {
+			continue
+		}
+		replacements = append(replacements, s, Redacted)
+		for _, escaped := range []string{url.QueryEscape(s), url.PathEscape(s)} {
+			if escaped != s {
+				replacements = append(replacements, escaped, Redacted)
+			}
+		}
+	}
+	r := &redactor{replacer: strings.NewReplacer(replacements...)}
+	doc = r.walk(doc, "")
+
+	var out bytes.Buffer
+	encoder := json.NewEncoder(&out)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(doc); err != nil {
+		return nil, fmt.Errorf("encode har failed: %w", err)
+	}
+	return out.Bytes(), nil
+}
+
+type redactor struct {
+	replacer *strings.Replacer
+}
+
+// walk 递归处理 JSON，key 为当前值所在的字段名
+func (r *redactor) walk(v interface{}, key string) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		switch key {
+		case "headers":
+			if name, ok := v["name"].(string); ok && sensitiveHeaders[strings.ToLower(name)] {
+				v["value"] = Redacted
+			}
+		case "cookies":
+			if _, ok := v["value"]; ok {
+				v["value"] = Redacted
+			}
+		}
+		for k, child := range v {
+			v[k] = r.walk(child, k)
+		}
+		return v
+	case []interface{}:
+		// 数组元素继承数组的字段名，如 headers 里的每一项
+		for i, child := range v {
+			v[i] = r.walk(child, key)
+		}
+		return v
+	case string:
+		return r.replacer.Replace(v)
+	}
+	return v
+}
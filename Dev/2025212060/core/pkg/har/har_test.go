@@ -0,0 +1,100 @@
+package har
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// browserHAR 浏览器导出的 HAR 片段，带有 Go 结构体中没有的字段
+const browserHAR = `{"log": {"version": "1.2", "_custom": {"keep": 1.50},
+	"entries": [{
+		"request": {
+			"url": "https://api.example.com/v1?token=s3cr3t%2F%2B&q=1",
+			"headers": [
+				{"name": "Authorization", "value": "Bearer abc"},
+				{"name": "cookie", "value": "sid=1"},
+				{"name": "X-Api-Key", "value": "k"},
+				{"name": "Accept", "value": "application/json"}
+			],
+			"cookies": [{"name": "sid", "value": "1", "httpOnly": true}],
+			"postData": {"mimeType": "application/json", "text": "{\"password\":\"s3cr3t/+\"}"}
+		},
+		"response": {
+			"headers": [{"name": "Set-Cookie", "value": "sid=2; Path=/"}],
+			"cookies": [{"name": "sid", "value": "2"}],
+			"content": {"text": "path /s3cr3t%2F+/x", "size": 18}
+		}
+	}]}}`
+
+func TestRedact(t *testing.T) {
+	out, err := Redact([]byte(browserHAR), "s3cr3t/+", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := string(out)
+	for _, leaked := range []string{"s3cr3t", "Bearer abc", "sid=1", "sid=2", `"value":"1"`, `"value":"2"`, `"value":"k"`} {
+		if strings.Contains(text, leaked) {
+			t.Errorf("redacted HAR still contains %q:\n%s", leaked, text)
+		}
+	}
+
+	var doc struct {
+		Log struct {
+			Custom  map[string]json.Number `json:"_custom"`
+			Entries []struct {
+				Request struct {
+					URL     string
+					Headers []NameValue
+					Cookies []map[string]interface{}
+				}
+			}
+		}
+	}
+	if err := json.Unmarshal(out, &doc); err != nil {
+		t.Fatal(err)
+	}
+	// 未知字段与数字的原始写法保留
+	if doc.Log.Custom["keep"] != "1.50" {
+		t.Errorf("_custom.keep = %q", doc.Log.Custom["keep"])
+	}
+	req := doc.Log.Entries[0].Request
+	if req.URL != "https://api.example.com/v1?token="+Redacted+"&q=1" {
+		t.Errorf("url = %s", req.URL)
+	}
+	if h := req.Headers[3]; h.Name != "Accept" || h.Value != "application/json" {
+		t.Errorf("non-sensitive header changed: %+v", h)
+	}
+	if c := req.Cookies[0]; c["name"] != "sid" || c["value"] != Redacted || c["httpOnly"] != true {
+		t.Errorf("cookie = %+v", c)
+	}
+	if !strings.Contains(text, `"text":"path /`+Redacted+`/x"`) {
+		t.Errorf("path-escaped secret not redacted:\n%s", text)
+	}
+	// HTML 字符不被转义，HAR 里的响应内容保持原样
+	out, _ = Redact([]byte(`{"text":"<a href='x'>&</a>"}`))
+	if strings.TrimSpace(string(out)) != `{"text":"<a href='x'>&</a>"}` {
+		t.Errorf("html escaped: %s", out)
+	}
+}
+
+func TestRedactInvalid(t *testing.T) {
+	if _, err := Redact([]byte("{not json")); err == nil || !strings.Contains(err.Error(), "decode har failed") {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+func FuzzRedact(f *testing.F) {
+	f.Add([]byte(browserHAR), "s3cr3t/+")
+	f.Add([]byte(`{"headers":[{"name":"Cookie","value":"a"}]}`), "")
+	f.Add([]byte(`[1, "x", null, {"cookies": [1, {"value": 2}]}]`), "x")
+	f.Fuzz(func(t *testing.T, data []byte, secret string) {
+		out, err := Redact(data, secret)
+		if err != nil {
+			return
+		}
+		if !json.Valid(out) {
+			t.Fatalf("Redact produced invalid json: %s", out)
+		}
+	})
+}
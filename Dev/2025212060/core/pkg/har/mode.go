@@ -0,0 +1,29 @@
+package har
+
+import "fmt"
+
+// 记录模式
+const (
+	ModeNever     = "never"      // 不记录
+	ModeOnFailure = "on_failure" // 检测失败时保存
+	ModeAlways    = "always"     // 每次都保存，按需排查时临时开启
+)
+
+// ValidMode 检查记录模式配置，空字符串等同于 never
+func ValidMode(mode string) error {
+	switch mode {
+	case "", ModeNever, ModeOnFailure, ModeAlways:
+		return nil
+	}
+	return fmt.Errorf("unsupported har mode: %s", mode)
+}
+
+// Enabled 是否需要在检测时记录
+func Enabled(mode string) bool {
+	return mode == ModeOnFailure || mode == ModeAlways
+}
+
+// ShouldSave 根据检测结果判断是否保存本次记录
+func ShouldSave(mode string, failed bool) bool {
+	return mode == ModeAlways || (mode == ModeOnFailure && failed)
+}
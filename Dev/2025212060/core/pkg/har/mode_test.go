@@ -0,0 +1,31 @@
+package har
+
+import "testing"
+
+func TestModes(t *testing.T) {
+	tests := []struct {
+		mode                     string
+		valid, enabled           bool
+		saveOnPass, saveOnFailed bool
+	}{
+		{"", true, false, false, false},
+		{ModeNever, true, false, false, false},
+		{ModeOnFailure, true, true, false, true},
+		{ModeAlways, true, true, true, true},
+		{"sometimes", false, false, false, false},
+	}
+	for _, tt := range tests {
+		if err := ValidMode(tt.mode); (err == nil) != tt.valid {
+			t.Errorf("ValidMode(%q) = %v", tt.mode, err)
+		}
+		if got := Enabled(tt.mode); got != tt.enabled {
+			t.Errorf("Enabled(%q) = %v", tt.mode, got)
+		}
+		if got := ShouldSave(tt.mode, false); got != tt.saveOnPass {
+			t.Errorf("ShouldSave(%q, false) = %v", tt.mode, got)
+		}
+		if got := ShouldSave(tt.mode, true); got != tt.saveOnFailed {
+			t.Errorf("ShouldSave(%q, true) = %v", tt.mode, got)
+		}
+	}
+}
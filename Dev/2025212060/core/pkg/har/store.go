@@ -0,0 +1,115 @@
+package har
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"redrock-dashboard/core/pkg/blob"
+	"strings"
+	"time"
+)
+
+const (
+	// harPrefix HAR 在对象存储中的前缀
+	harPrefix = "har/"
+	// timestampLayout 文件名中的时间戳，定长且按字典序即时间顺序
+	timestampLayout = "20060102T150405.000000000Z"
+	// ContentType HAR 文件的类型
+	ContentType = "application/json"
+)
+
+// Store 把 HAR 写入对象存储，并按保留策略清理旧文件
+type Store struct {
+	store  blob.Store
+	keep   int           // 每个监控保留的 HAR 数量，为 0 时不限制
+	maxAge time.Duration // 超过该时长的 HAR 被删除，为 0 时不限制
+}
+
+// StoreOption 配置选项
+type StoreOption func(*Store)
+
+// NewStore 创建 HAR 存储
+func NewStore(store blob.Store, opts ...StoreOption) *Store {
+	s := &Store{store: store, keep: 20}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// WithRetention 设置每个监控保留的 HAR 数量与最长保留时间，为 0 表示不限制
+func WithRetention(keep int, maxAge time.Duration) StoreOption {
+	return func(s *Store) {
+		s.keep = keep
+		s.maxAge = maxAge
+	}
+}
+
+// Save 保存 HAR 并返回 ID，然后清理该监控超出保留策略的旧文件
+func (s *Store) Save(ctx context.Context, monitorID string, data []byte) (string, error) {
+	id := monitorPrefix(monitorID) + time.Now().UTC().Format(timestampLayout) + ".har"
+	if err := s.store.Put(ctx, id, bytes.NewReader(data), ContentType); err != nil {
+		return "", fmt.Errorf("store har failed: %w", err)
+	}
+	if err := s.Prune(ctx, monitorID); err != nil {
+		return id, fmt.Errorf("prune har failed: %w", err)
+	}
+	return id, nil
+}
+
+// Open 按 ID 读取 HAR，供事件页面下载
+func (s *Store) Open(ctx context.Context, id string) (io.ReadCloser, error) {
+	if !strings.HasPrefix(id, harPrefix) {
+		return nil, blob.ErrNotFound
+	}
+	return s.store.Get(ctx, id)
+}
+
+// FileName 下载时使用的文件名，如 monitor-20250101T000000.000000000Z.har
+func FileName(id string) string {
+	rest := strings.TrimPrefix(id, harPrefix)
+	monitor, name, ok := strings.Cut(rest, "/")
+	if !ok {
+		return rest
+	}
+	if unescaped, err := url.PathUnescape(monitor); err == nil {
+		monitor = unescaped
+	}
+	monitor = strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == '"' || r < ' ' {
+			return '_'
+		}
+		return r
+	}, monitor)
+	return monitor + "-" + name
+}
+
+// Prune 按保留数量与时长删除监控的旧 HAR
+func (s *Store) Prune(ctx context.Context, monitorID string) error {
+	if s.keep <= 0 && s.maxAge <= 0 {
+		return nil
+	}
+	// List 按 key 排序，文件名以时间戳开头，倒序即从新到旧
+	objects, err := s.store.List(ctx, monitorPrefix(monitorID))
+	if err != nil {
+		return err
+	}
+	cutoff := time.Now().Add(-s.maxAge)
+	for i := len(objects) - 1; i >= 0; i-- {
+		newer := len(objects) - 1 - i
+		expired := s.maxAge > 0 && objects[i].ModTime.Before(cutoff)
+		if (s.keep > 0 && newer >= s.keep) || expired {
+			if err := s.store.Delete(ctx, objects[i].Key); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// monitorPrefix 监控在存储中的目录
+func monitorPrefix(monitorID string) string {
+	return harPrefix + blob.MonitorSegment(monitorID) + "/"
+}
@@ -0,0 +1,151 @@
+package har
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"redrock-dashboard/core/pkg/blob"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestStore(t *testing.T, opts ...StoreOption) (*Store, *blob.LocalStore, string) {
+	t.Helper()
+	root := t.TempDir()
+	local, err := blob.NewLocalStore(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewStore(local, opts...), local, root
+}
+
+func TestStoreSaveOpen(t *testing.T) {
+	s, _, _ := newTestStore(t)
+	ctx := context.Background()
+
+	id, err := s.Save(ctx, "team/api", []byte(`{"log":{}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(id, "har/team%2Fapi/") || !strings.HasSuffix(id, ".har") {
+		t.Fatalf("id = %s", id)
+	}
+	r, err := s.Open(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(r)
+	r.Close()
+	if string(data) != `{"log":{}}` {
+		t.Fatalf("content = %s", data)
+	}
+
+	// 只允许读取 HAR 前缀下的对象
+	for _, bad := range []string{"screenshots/1/a.png", "content/1/snapshot.txt", ""} {
+		if _, err := s.Open(ctx, bad); !errors.Is(err, blob.ErrNotFound) {
+			t.Errorf("Open(%q) err = %v", bad, err)
+		}
+	}
+	if _, err := s.Open(ctx, "har/none/x.har"); !errors.Is(err, blob.ErrNotFound) {
+		t.Errorf("missing har err = %v", err)
+	}
+}
+
+func TestStorePruneKeep(t *testing.T) {
+	s, local, _ := newTestStore(t, WithRetention(2, 0))
+	ctx := context.Background()
+
+	var ids []string
+	for i := 0; i < 4; i++ {
+		id, err := s.Save(ctx, "m1", []byte("{}"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+	if _, err := s.Save(ctx, "m2", []byte("{}")); err != nil {
+		t.Fatal(err)
+	}
+
+	objects, err := local.List(ctx, monitorPrefix("m1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objects) != 2 || objects[0].Key != ids[2] || objects[1].Key != ids[3] {
+		t.Fatalf("kept %+v, want the newest two of %v", objects, ids)
+	}
+	// 其他监控不受影响
+	if objects, _ := local.List(ctx, monitorPrefix("m2")); len(objects) != 1 {
+		t.Fatalf("m2 objects = %+v", objects)
+	}
+}
+
+func TestStorePruneMaxAge(t *testing.T) {
+	s, local, root := newTestStore(t, WithRetention(0, time.Hour))
+	ctx := context.Background()
+
+	old, err := s.Save(ctx, "m1", []byte("{}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	past := time.Now().Add(-2 * time.Hour)
+	if err := os.Chtimes(filepath.Join(root, filepath.FromSlash(old)), past, past); err != nil {
+		t.Fatal(err)
+	}
+	fresh, err := s.Save(ctx, "m1", []byte("{}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	objects, _ := local.List(ctx, monitorPrefix("m1"))
+	if len(objects) != 1 || objects[0].Key != fresh {
+		t.Fatalf("objects = %+v, want only %s", objects, fresh)
+	}
+}
+
+func TestStoreNoRetention(t *testing.T) {
+	s, local, _ := newTestStore(t, WithRetention(0, 0))
+	ctx := context.Background()
+	for i := 0; i < 25; i++ {
+		if _, err := s.Save(ctx, "m1", []byte("{}")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if objects, _ := local.List(ctx, monitorPrefix("m1")); len(objects) != 25 {
+		t.Fatalf("kept %d objects, want 25", len(objects))
+	}
+}
+
+func TestMonitorPrefix(t *testing.T) {
+	tests := map[string]string{
+		"":      "har/_adhoc/",
+		"42":    "har/42/",
+		"a/b":   "har/a%2Fb/",
+		".":     "har/%2E/",
+		"..":    "har/%2E./",
+		"a b?c": "har/a%20b%3Fc/",
+	}
+	for id, want := range tests {
+		if got := monitorPrefix(id); got != want {
+			t.Errorf("monitorPrefix(%q) = %q, want %q", id, got, want)
+		}
+	}
+}
+
+func TestFileName(t *testing.T) {
+	tests := map[string]string{
+		"har/42/20250101T000000.000000000Z.har":         "42-20250101T000000.000000000Z.har",
+		"har/team%2Fapi/20250101T000000.000000000Z.har": "team_api-20250101T000000.000000000Z.har",
+		`har/a%22b%5Cc/x.har`:                           "a_b_c-x.har",
+		"har/bad%zz/x.har":                              "bad%zz-x.har",
+		"har/x.har":                                     "x.har",
+	}
+	for id, want := range tests {
+		if got := FileName(id); got != want {
+			t.Errorf("FileName(%q) = %q, want %q", id, got, want)
+		}
+	}
+}
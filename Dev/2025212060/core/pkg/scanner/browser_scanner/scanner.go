@@ -0,0 +1,96 @@
+package browser_scanner
+
+import (
+	"context"
+	"encoding/base64"
+	"redrock-dashboard/core/pkg/har"
+	"redrock-dashboard/core/pkg/scanner/web_scanner/playwright_lib"
+	"time"
+)
+
+type BrowserScanResult struct {
+	TimeDelay  time.Duration
+	Passed     bool
+	Steps      []playwright_lib.StepResult
+	FailedStep int // 失败步骤的下标，全部通过时为 -1
+	Variables  map[string]string
+
+	ScreenshotID string // 失败时的截图，配置了截图存储时写入 ID
+	ThumbnailID  string
+	Screenshot   string // 未配置截图存储时的 base64 data URL
+
+	HARID string
+}
+
+type BrowserScanner struct {
+	ID              string
+	Steps           []playwright_lib.Step
+	Timeout         time.Duration // 整个事务的超时，为 0 时使用默认值
+	StepTimeout     time.Duration // 单步默认超时，为 0 时使用默认值
+	ScreenshotStore *playwright_lib.ScreenshotStore
+
+	HARMode  string     // never、on_failure、always，为空时不记录
+	HARStore *har.Store // 为空时不记录 HAR
+}
+
+func (r BrowserScanner) Scan() (*BrowserScanResult, error) {
+	if err := har.ValidMode(r.HARMode); err != nil {
+		return nil, err
+	}
+
+	var opts []playwright_lib.TransactionOption
+	if r.Timeout > 0 {
+		opts = append(opts, playwright_lib.WithTransactionTimeout(r.Timeout))
+	}
+	if r.StepTimeout > 0 {
+		opts = append(opts, playwright_lib.WithStepTimeout(r.StepTimeout))
+	}
+	recordHAR := r.HARStore != nil && har.Enabled(r.HARMode)
+	if recordHAR {
+		opts = append(opts, playwright_lib.WithTransactionHAR())
+	}
+
+	result := playwright_lib.NewTransaction(opts...).Run(r.Steps)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	data := &BrowserScanResult{
+		TimeDelay:  result.TotalTime,
+		Passed:     result.Passed,
+		Steps:      result.Steps,
+		FailedStep: result.FailedStep,
+		Variables:  result.Variables,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	if recordHAR && result.HAR != nil && har.ShouldSave(r.HARMode, !result.Passed) {
+		id, err := r.HARStore.Save(ctx, r.ID, result.HAR)
+		if err != nil {
+			// TODO Log
+		}
+		data.HARID = id
+	}
+
+	if result.Screenshot == nil {
+		return data, nil
+	}
+
+	if r.ScreenshotStore == nil {
+		data.Screenshot = "data:" + result.ContentType + ";base64," + base64.StdEncoding.EncodeToString(result.Screenshot)
+		return data, nil
+	}
+
+	stored, err := r.ScreenshotStore.Save(ctx, r.ID, result.Screenshot, result.ContentType)
+	if err != nil {
+		// TODO Log
+		if stored == nil {
+			return data, nil
+		}
+	}
+	data.ScreenshotID = stored.ID
+	data.ThumbnailID = stored.ThumbnailID
+
+	return data, nil
+}
@@ -0,0 +1,49 @@
+package content_lib
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// DiffResult 两次内容的行级差异
+type DiffResult struct {
+	Unified   string // unified diff，超过 maxLines 时截断
+	Added     int    // 新增行数
+	Removed   int    // 删除行数
+	Truncated bool
+}
+
+// Diff 生成 unified diff，maxLines 为 0 时不截断
+func Diff(previous, current string, maxLines int) (*DiffResult, error) {
+	unified, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(previous),
+		B:        difflib.SplitLines(current),
+		FromFile: "previous",
+		ToFile:   "current",
+		Context:  3,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("diff failed: %w", err)
+	}
+
+	result := &DiffResult{}
+	lines := strings.SplitAfter(unified, "\n")
+	for _, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+		case strings.HasPrefix(line, "+"):
+			result.Added++
+		case strings.HasPrefix(line, "-"):
+			result.Removed++
+		}
+	}
+	if maxLines > 0 && len(lines) > maxLines {
+		rest := len(lines) - maxLines
+		lines = append(lines[:maxLines], fmt.Sprintf("... %d more lines\n", rest))
+		result.Truncated = true
+	}
+	result.Unified = strings.Join(lines, "")
+	return result, nil
+}
@@ -0,0 +1,46 @@
+package content_lib
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	result, err := Diff("a\nb\nc", "a\nB\nc\nd", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Added != 2 || result.Removed != 1 || result.Truncated {
+		t.Fatalf("result = %+v", result)
+	}
+	for _, line := range []string{"--- previous\n", "+++ current\n", "-b\n", "+B\n", "+d\n", " a\n"} {
+		if !strings.Contains(result.Unified, line) {
+			t.Errorf("diff missing %q:\n%s", line, result.Unified)
+		}
+	}
+
+	same, err := Diff("a\nb", "a\nb", 0)
+	if err != nil || same.Unified != "" || same.Added != 0 || same.Removed != 0 {
+		t.Fatalf("identical content: %+v %v", same, err)
+	}
+}
+
+func TestDiffTruncate(t *testing.T) {
+	var prev, cur strings.Builder
+	for i := 0; i < 50; i++ {
+		prev.WriteString("old\n")
+		cur.WriteString("new\n")
+	}
+	result, err := Diff(prev.String(), cur.String(), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 截断只影响展示，增删行数仍是完整统计
+	if !result.Truncated || result.Added != 50 || result.Removed != 50 {
+		t.Fatalf("result = %+v", result)
+	}
+	lines := strings.Split(strings.TrimSuffix(result.Unified, "\n"), "\n")
+	if len(lines) != 11 || !strings.HasPrefix(lines[10], "... ") || !strings.HasSuffix(lines[10], " more lines") {
+		t.Fatalf("truncated diff:\n%s", result.Unified)
+	}
+}
@@ -0,0 +1,103 @@
+package content_lib
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/andybalholm/cascadia"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// 比较的内容
+const (
+	ModeText = "text" // 可见文本，忽略标签与属性的变化
+	ModeHTML = "html" // 元素的 HTML，标签或属性变化也会告警
+)
+
+// skipElements 提取文本时跳过的元素
+var skipElements = map[atom.Atom]bool{
+	atom.Script:   true,
+	atom.Style:    true,
+	atom.Noscript: true,
+	atom.Template: true,
+	atom.Svg:      true,
+}
+
+// blockElements 前后换行的元素，让提取的文本接近浏览器的 innerText 分行
+var blockElements = map[atom.Atom]bool{
+	atom.Address: true, atom.Article: true, atom.Aside: true, atom.Blockquote: true,
+	atom.Br: true, atom.Dd: true, atom.Div: true, atom.Dl: true, atom.Dt: true,
+	atom.Fieldset: true, atom.Figcaption: true, atom.Figure: true, atom.Footer: true,
+	atom.Form: true, atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true,
+	atom.H5: true, atom.H6: true, atom.Header: true, atom.Hr: true, atom.Li: true,
+	atom.Main: true, atom.Nav: true, atom.Ol: true, atom.P: true, atom.Pre: true,
+	atom.Section: true, atom.Table: true, atom.Title: true, atom.Tr: true, atom.Ul: true,
+}
+
+// Extract 解析 HTML，按 CSS 选择器取出内容，selector 为空时取整个文档
+// 匹配多个元素时按文档顺序以换行连接
+func Extract(body []byte, selector, mode string) (string, error) {
+	if mode == "" {
+		mode = ModeText
+	}
+	if mode != ModeText && mode != ModeHTML {
+		return "", fmt.Errorf("unsupported content mode: %s", mode)
+	}
+
+	doc, err := html.Parse(bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("parse html failed: %w", err)
+	}
+
+	nodes := []*html.Node{doc}
+	if selector != "" {
+		group, err := cascadia.ParseGroup(selector)
+		if err != nil {
+			return "", fmt.Errorf("invalid selector %q: %w", selector, err)
+		}
+		nodes = cascadia.QueryAll(doc, group)
+		if len(nodes) == 0 {
+			return "", fmt.Errorf("selector %q matched nothing", selector)
+		}
+	}
+
+	var sb strings.Builder
+	for i, n := range nodes {
+		if i > 0 {
+			sb.WriteByte('\n')
+		}
+		if mode == ModeHTML {
+			if err := html.Render(&sb, n); err != nil {
+				return "", fmt.Errorf("render html failed: %w", err)
+			}
+			continue
+		}
+		writeText(&sb, n)
+	}
+	return sb.String(), nil
+}
+
+// writeText 递归写出节点的文本
+func writeText(sb *strings.Builder, n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		sb.WriteString(n.Data)
+		return
+	case html.ElementNode:
+		if skipElements[n.DataAtom] {
+			return
+		}
+	}
+	block := n.Type == html.ElementNode && blockElements[n.DataAtom]
+	if block {
+		sb.WriteByte('\n')
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		writeText(sb, c)
+	}
+	if block {
+		sb.WriteByte('\n')
+	}
+}
@@ -0,0 +1,77 @@
+package content_lib
+
+import (
+	"strings"
+	"testing"
+)
+
+const page = `<!doctype html>
+<html><head><title>Status</title><style>p { color: red }</style></head>
+<body>
+<nav><a href="/">Home</a></nav>
+<div id="main" class="content">
+  <h1>Service   status</h1>
+  <p>All systems <b>operational</b>.</p>
+  <script>var nonce = "abc";</script>
+  <ul><li class="item">API</li><li class="item">Web</li></ul>
+</div>
+<footer>Updated 2025-06-01 12:30:45</footer>
+</body></html>`
+
+func TestExtract(t *testing.T) {
+	tests := []struct {
+		name     string
+		selector string
+		mode     string
+		want     string
+	}{
+		{"selector text", "#main h1", "", "\nService   status\n"},
+		{"inline elements join", "#main p", ModeText, "\nAll systems operational.\n"},
+		{"multiple matches", "li.item", ModeText, "\nAPI\n\n\nWeb\n"},
+		{"group selector in document order", "footer, h1", ModeText, "\nService   status\n\n\nUpdated 2025-06-01 12:30:45\n"},
+		{"html mode", "li.item", ModeHTML, `<li class="item">API</li>` + "\n" + `<li class="item">Web</li>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Extract([]byte(page), tt.selector, tt.mode)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Fatalf("Extract = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractWholeDocument(t *testing.T) {
+	got, err := Extract([]byte(page), "", ModeText)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, hidden := range []string{"color: red", "nonce"} {
+		if strings.Contains(got, hidden) {
+			t.Errorf("text contains %q from a skipped element", hidden)
+		}
+	}
+	normalized, _ := NewNormalizer(nil, false)
+	want := "Status\nHome\nService status\nAll systems operational.\nAPI\nWeb\nUpdated 2025-06-01 12:30:45"
+	if n := normalized.Normalize(got); n != want {
+		t.Fatalf("normalized text = %q, want %q", n, want)
+	}
+}
+
+func TestExtractErrors(t *testing.T) {
+	tests := []struct {
+		selector, mode, want string
+	}{
+		{"#main", "markdown", "unsupported content mode"},
+		{"div[", ModeText, "invalid selector"},
+		{"#missing", ModeText, "matched nothing"},
+	}
+	for _, tt := range tests {
+		if _, err := Extract([]byte(page), tt.selector, tt.mode); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Extract(%q, %q) err = %v, want %q", tt.selector, tt.mode, err, tt.want)
+		}
+	}
+}
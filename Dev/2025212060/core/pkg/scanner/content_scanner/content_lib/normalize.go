@@ -0,0 +1,66 @@
+package content_lib
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Placeholder 动态内容被替换成的占位符
+const Placeholder = "<dynamic>"
+
+// DefaultPatterns 默认忽略的动态内容：时间、日期、UUID、长十六进制串、Unix 时间戳、nonce 与 CSRF 令牌
+var DefaultPatterns = []string{
+	`\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}(:\d{2}(\.\d+)?)?(Z|[+-]\d{2}:?\d{2})?`,
+	`\d{4}[-/.]\d{1,2}[-/.]\d{1,2}`,
+	`\d{4}年\d{1,2}月\d{1,2}日`,
+	`\b\d{1,2}:\d{2}(:\d{2})?\b`,
+	`(?i)\b[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}\b`,
+	`(?i)\b[0-9a-f]{32,}\b`,
+	`\b\d{10,13}\b`,
+	`(?i)\bnonce\s*[=:]\s*("[^"]*"|'[^']*'|[^\s>"']+)`,
+	`(?i)name="(csrf[-_]?token|authenticity_token|_token|__RequestVerificationToken)"\s+(value|content)="[^"]*"`,
+}
+
+// Normalizer 去掉动态内容并规整空白，使只有实质变化才会改变哈希
+type Normalizer struct {
+	patterns []*regexp.Regexp
+}
+
+// NewNormalizer 创建规整器，patterns 为额外忽略的正则，defaults 为 true 时同时使用 DefaultPatterns
+func NewNormalizer(patterns []string, defaults bool) (*Normalizer, error) {
+	if defaults {
+		patterns = append(append([]string{}, DefaultPatterns...), patterns...)
+	}
+	n := &Normalizer{}
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ignore pattern %q: %w", p, err)
+		}
+		n.patterns = append(n.patterns, re)
+	}
+	return n, nil
+}
+
+// Normalize 替换动态内容，每行合并连续空白并去掉首尾空白，删除空行
+func (n *Normalizer) Normalize(s string) string {
+	for _, re := range n.patterns {
+		s = re.ReplaceAllString(s, Placeholder)
+	}
+	var lines []string
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Hash 内容的 sha256，十六进制
+func Hash(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,76 @@
+package content_lib
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	n, err := NewNormalizer(nil, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name, input, want string
+	}{
+		{"whitespace and blank lines", "  a   b \n\n\t c\n", "a b\nc"},
+		{"iso timestamp", "built 2025-06-01T12:30:45.123+08:00 ok", "built <dynamic> ok"},
+		{"date", "on 2025/6/1", "on <dynamic>"},
+		{"chinese date", "更新于2025年6月1日", "更新于<dynamic>"},
+		{"clock", "at 9:05 and 23:59:59", "at <dynamic> and <dynamic>"},
+		{"uuid", "id 123E4567-e89b-12d3-a456-426614174000", "id <dynamic>"},
+		{"hex digest", "etag " + strings.Repeat("ab", 20), "etag <dynamic>"},
+		{"unix timestamp", "ts=1717243845123", "ts=<dynamic>"},
+		{"nonce", `<script nonce="r4nd0m">`, "<script <dynamic>>"},
+		{"csrf token", `<input name="csrf_token" value="s3cr3t">`, "<input <dynamic>>"},
+		{"short numbers kept", "version 12345 build 7", "version 12345 build 7"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := n.Normalize(tt.input); got != tt.want {
+				t.Fatalf("Normalize(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizerPatterns(t *testing.T) {
+	n, err := NewNormalizer([]string{`visitors: \d+`}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := n.Normalize("visitors: 42 at 12:00"); got != "<dynamic> at 12:00" {
+		t.Fatalf("custom only: %q", got)
+	}
+
+	n, err = NewNormalizer([]string{`visitors: \d+`}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := n.Normalize("visitors: 42 at 12:00"); got != "<dynamic> at <dynamic>" {
+		t.Fatalf("custom with defaults: %q", got)
+	}
+	if len(DefaultPatterns) != 9 {
+		t.Fatalf("DefaultPatterns modified: %d entries", len(DefaultPatterns))
+	}
+
+	if _, err := NewNormalizer([]string{"("}, false); err == nil || !strings.Contains(err.Error(), "invalid ignore pattern") {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+func TestHashIgnoresDynamicContent(t *testing.T) {
+	n, _ := NewNormalizer(nil, true)
+	a := n.Normalize("Price: 100\nUpdated 2025-06-01 12:30")
+	b := n.Normalize("Price:   100\n\nUpdated 2025-06-02 08:00")
+	c := n.Normalize("Price: 120\nUpdated 2025-06-02 08:00")
+	if Hash(a) != Hash(b) {
+		t.Errorf("dynamic content changed the hash: %q vs %q", a, b)
+	}
+	if Hash(a) == Hash(c) {
+		t.Errorf("real change kept the hash: %q vs %q", a, c)
+	}
+	if got := Hash(""); got != "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855" {
+		t.Errorf("Hash(\"\") = %s", got)
+	}
+}
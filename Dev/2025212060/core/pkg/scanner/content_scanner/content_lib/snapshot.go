@@ -0,0 +1,85 @@
+package content_lib
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"redrock-dashboard/core/pkg/blob"
+	"strings"
+	"sync"
+)
+
+// snapshotPrefix 快照在对象存储中的前缀
+const snapshotPrefix = "content/"
+
+// SnapshotStore 保存每个监控上一次规整后的内容，用于下次比较
+// 未配置对象存储时保存在内存中，重启后第一次检测重新建立基线
+type SnapshotStore struct {
+	store  blob.Store
+	mu     sync.Mutex
+	memory map[string]string
+}
+
+// NewSnapshotStore 创建快照存储，store 为 nil 时只保存在内存
+func NewSnapshotStore(store blob.Store) *SnapshotStore {
+	return &SnapshotStore{store: store, memory: make(map[string]string)}
+}
+
+// Load 读取上一次的内容，没有快照时 ok 为 false
+func (s *SnapshotStore) Load(ctx context.Context, monitorID string) (content string, ok bool, err error) {
+	if s.store == nil {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		content, ok = s.memory[monitorID]
+		return content, ok, nil
+	}
+
+	r, err := s.store.Get(ctx, snapshotKey(monitorID))
+	if errors.Is(err, blob.ErrNotFound) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("load snapshot failed: %w", err)
+	}
+	defer r.Close()
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return "", false, fmt.Errorf("load snapshot failed: %w", err)
+	}
+	return string(data), true, nil
+}
+
+// Save 保存本次内容，作为下次比较的基准
+func (s *SnapshotStore) Save(ctx context.Context, monitorID, content string) error {
+	if s.store == nil {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.memory[monitorID] = content
+		return nil
+	}
+	if err := s.store.Put(ctx, snapshotKey(monitorID), strings.NewReader(content), "text/plain; charset=utf-8"); err != nil {
+		return fmt.Errorf("save snapshot failed: %w", err)
+	}
+	return nil
+}
+
+// Delete 删除快照，删除监控或需要重新建立基线时调用
+func (s *SnapshotStore) Delete(ctx context.Context, monitorID string) error {
+	if s.store == nil {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		delete(s.memory, monitorID)
+		return nil
+	}
+	err := s.store.Delete(ctx, snapshotKey(monitorID))
+	if err != nil && !errors.Is(err, blob.ErrNotFound) {
+		return fmt.Errorf("delete snapshot failed: %w", err)
+	}
+	return nil
+}
+
+// snapshotKey 快照的 key
+func snapshotKey(monitorID string) string {
+	return snapshotPrefix + blob.MonitorSegment(monitorID) + "/snapshot.txt"
+}
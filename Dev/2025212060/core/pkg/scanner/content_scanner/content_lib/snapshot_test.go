@@ -0,0 +1,60 @@
+package content_lib
+
+import (
+	"context"
+	"redrock-dashboard/core/pkg/blob"
+	"testing"
+)
+
+func TestSnapshotStore(t *testing.T) {
+	local, err := blob.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, s := range map[string]*SnapshotStore{"memory": NewSnapshotStore(nil), "blob": NewSnapshotStore(local)} {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			if _, ok, err := s.Load(ctx, "m1"); ok || err != nil {
+				t.Fatalf("empty store: ok %v err %v", ok, err)
+			}
+			if err := s.Save(ctx, "m1", "内容 v1"); err != nil {
+				t.Fatal(err)
+			}
+			if err := s.Save(ctx, "../m2", "other"); err != nil {
+				t.Fatal(err)
+			}
+			got, ok, err := s.Load(ctx, "m1")
+			if err != nil || !ok || got != "内容 v1" {
+				t.Fatalf("Load = %q %v %v", got, ok, err)
+			}
+			if err := s.Delete(ctx, "m1"); err != nil {
+				t.Fatal(err)
+			}
+			if err := s.Delete(ctx, "m1"); err != nil {
+				t.Fatalf("deleting a missing snapshot: %v", err)
+			}
+			if _, ok, _ := s.Load(ctx, "m1"); ok {
+				t.Fatal("snapshot still present after Delete")
+			}
+			if got, ok, _ := s.Load(ctx, "../m2"); !ok || got != "other" {
+				t.Fatalf("escaped id: %q %v", got, ok)
+			}
+		})
+	}
+}
+
+func TestSnapshotKey(t *testing.T) {
+	tests := map[string]string{
+		"":      "content/_adhoc/snapshot.txt",
+		"42":    "content/42/snapshot.txt",
+		"a/b":   "content/a%2Fb/snapshot.txt",
+		".":     "content/%2E/snapshot.txt",
+		"..":    "content/%2E./snapshot.txt",
+		"../..": "content/..%2F../snapshot.txt",
+	}
+	for id, want := range tests {
+		if got := snapshotKey(id); got != want {
+			t.Errorf("snapshotKey(%q) = %q, want %q", id, got, want)
+		}
+	}
+}
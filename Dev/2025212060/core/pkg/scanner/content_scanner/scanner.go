@@ -0,0 +1,130 @@
+package content_scanner
+
+import (
+	"context"
+	"fmt"
+	"redrock-dashboard/core/pkg/scanner/content_scanner/content_lib"
+	"redrock-dashboard/core/pkg/scanner/web_scanner/playwright_lib"
+	"redrock-dashboard/core/pkg/scanner/web_scanner/web_lib"
+	"time"
+)
+
+// maxDiffLines 结果中 diff 的最大行数
+const maxDiffLines = 200
+
+// defaultSnapshots 未配置快照存储的监控共用的内存快照
+var defaultSnapshots = content_lib.NewSnapshotStore(nil)
+
+type ContentScanResult struct {
+	TimeDelay    time.Duration
+	Hash         string // 规整后内容的 sha256
+	PreviousHash string
+	Length       int  // 规整后内容的字节数
+	Baseline     bool // 第一次检测，只建立基线不比较
+	Changed      bool
+	Diff         *content_lib.DiffResult // 内容变化时与上一次的差异
+}
+
+type ContentScanner struct {
+	ID       string
+	Dest     string
+	Render   bool   // 使用 Playwright 渲染后再读取，适用于由脚本生成内容的页面
+	Selector string // CSS 选择器，只比较匹配的部分，为空时比较整个页面
+	Mode     string // text 或 html，为空时为 text
+
+	IgnorePatterns    []string // 额外忽略的动态内容正则
+	NoDefaultPatterns bool     // 不使用内置的时间、UUID、令牌等忽略规则
+	Timeout           time.Duration
+
+	Snapshots *content_lib.SnapshotStore // 为空时保存在内存中
+}
+
+// Reset 删除监控的快照，下次检测重新建立基线，删除监控或确认变化后调用
+func (r ContentScanner) Reset() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	return r.snapshots().Delete(ctx, r.ID)
+}
+
+func (r ContentScanner) Scan() (*ContentScanResult, error) {
+	normalizer, err := content_lib.NewNormalizer(r.IgnorePatterns, !r.NoDefaultPatterns)
+	if err != nil {
+		return nil, err
+	}
+	timeout := r.Timeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+
+	start := time.Now()
+	raw, err := r.fetch(timeout)
+	if err != nil {
+		return nil, err
+	}
+	content := normalizer.Normalize(raw)
+
+	data := &ContentScanResult{
+		TimeDelay: time.Since(start),
+		Hash:      content_lib.Hash(content),
+		Length:    len(content),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	snapshots := r.snapshots()
+	previous, ok, err := snapshots.Load(ctx, r.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		data.Baseline = true
+		return data, snapshots.Save(ctx, r.ID, content)
+	}
+
+	data.PreviousHash = content_lib.Hash(previous)
+	if data.PreviousHash == data.Hash {
+		return data, nil
+	}
+	data.Changed = true
+	if data.Diff, err = content_lib.Diff(previous, content, maxDiffLines); err != nil {
+		// TODO Log
+	}
+	return data, snapshots.Save(ctx, r.ID, content)
+}
+
+// fetch 取得页面内容，渲染模式下由浏览器执行脚本
+func (r ContentScanner) fetch(timeout time.Duration) (string, error) {
+	if r.Render {
+		result := playwright_lib.NewRenderer(playwright_lib.WithRenderTimeout(timeout)).Render(r.Dest, r.Selector)
+		if result.Error != nil {
+			return "", result.Error
+		}
+		switch r.Mode {
+		case "", content_lib.ModeText:
+			return result.Text, nil
+		case content_lib.ModeHTML:
+			return result.HTML, nil
+		}
+		return "", fmt.Errorf("unsupported content mode: %s", r.Mode)
+	}
+
+	result := web_lib.NewHTTPChecker(
+		web_lib.WithTimeout(timeout),
+		web_lib.WithMonitorID(r.ID),
+	).Check(r.Dest)
+	if result.Error != nil {
+		return "", result.Error
+	}
+	// 错误页不作为内容保存，避免故障期间反复告警内容变化
+	if !result.Available {
+		return "", fmt.Errorf("unexpected status code: %d", result.StatusCode)
+	}
+	return content_lib.Extract([]byte(result.Text), r.Selector, r.Mode)
+}
+
+func (r ContentScanner) snapshots() *content_lib.SnapshotStore {
+	if r.Snapshots != nil {
+		return r.Snapshots
+	}
+	return defaultSnapshots
+}
@@ -1,10 +1,15 @@
 package scanner
 
 import (
+	"redrock-dashboard/core/pkg/scanner/browser_scanner"
+	"redrock-dashboard/core/pkg/scanner/content_scanner"
 	"redrock-dashboard/core/pkg/scanner/dns_scanner"
 	"redrock-dashboard/core/pkg/scanner/icmp_scanner"
 	"redrock-dashboard/core/pkg/scanner/tcp_scanner"
+	"redrock-dashboard/core/pkg/scanner/tls_scanner"
+	"redrock-dashboard/core/pkg/scanner/udp_scanner"
 	"redrock-dashboard/core/pkg/scanner/web_scanner"
+	"redrock-dashboard/core/pkg/scanner/web_scanner/playwright_lib"
 )
 
 func GetDNSScanner(domain string, dest string) *dns_scanner.DNSScanner {
@@ -15,6 +20,14 @@ func GetTCPScanner(target string, port string) *tcp_scanner.TCPScanner {
 	return &tcp_scanner.TCPScanner{Taget: target, Port: port}
 }
 
+func GetUDPScanner(target string, port string) *udp_scanner.UDPScanner {
+	return &udp_scanner.UDPScanner{Target: target, Port: port}
+}
+
+func GetTLSScanner(target string, port string) *tls_scanner.TLSScanner {
+	return &tls_scanner.TLSScanner{Target: target, Port: port}
+}
+
 func GetICMPScanner(target string) *icmp_scanner.ICMPScanner {
 	return &icmp_scanner.ICMPScanner{Target: target}
 }
@@ -22,3 +35,11 @@ func GetICMPScanner(target string) *icmp_scanner.ICMPScanner {
 func GetWEBScanner(dest string) *web_scanner.WebScanner {
 	return &web_scanner.WebScanner{Dest: dest}
 }
+
+func GetBrowserScanner(steps []playwright_lib.Step) *browser_scanner.BrowserScanner {
+	return &browser_scanner.BrowserScanner{Steps: steps}
+}
+
+func GetContentScanner(dest string) *content_scanner.ContentScanner {
+	return &content_scanner.ContentScanner{Dest: dest}
+}
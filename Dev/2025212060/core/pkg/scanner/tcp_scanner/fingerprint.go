@@ -0,0 +1,119 @@
+package tcp_scanner
+
+import (
+	"fmt"
+	vscan "redrock-dashboard/core/pkg/scanner/tcp_scanner/service_lib"
+)
+
+// 指纹字段名，用于告警规则配置
+const (
+	FieldService    = "service"
+	FieldProduct    = "product"
+	FieldVersion    = "version"
+	FieldInfo       = "info"
+	FieldCPE        = "cpe"
+	FieldOS         = "os"
+	FieldHostname   = "hostname"
+	FieldDeviceType = "device_type"
+)
+
+// Fingerprint 服务指纹，随每次检测结果保存，用于和上一次比较
+type Fingerprint struct {
+	Service    string
+	Product    string
+	Version    string
+	Info       string
+	CPE        string
+	OS         string
+	Hostname   string
+	DeviceType string
+}
+
+// FingerprintChange 单个字段的变化
+type FingerprintChange struct {
+	Field string
+	Old   string
+	New   string
+}
+
+func (c FingerprintChange) String() string {
+	return fmt.Sprintf("%s: %q -> %q", c.Field, c.Old, c.New)
+}
+
+// FingerprintRule 指纹变化告警规则
+type FingerprintRule struct {
+	Fields      []string // 关注的字段，为空时关注 service、product、version
+	IgnoreEmpty bool     // 本次或上次未识别出服务（如探测超时）时不告警，避免抖动
+}
+
+func newFingerprint(result vscan.Result) *Fingerprint {
+	return &Fingerprint{
+		Service:    result.Service.Name,
+		Product:    result.Service.Extras.VendorProduct,
+		Version:    result.Service.Extras.Version,
+		Info:       result.Service.Extras.Info,
+		CPE:        result.Service.Extras.CPE,
+		OS:         result.Service.Extras.OperatingSystem,
+		Hostname:   result.Service.Extras.Hostname,
+		DeviceType: result.Service.Extras.DeviceType,
+	}
+}
+
+// identified 是否识别出了服务
+func (f Fingerprint) identified() bool {
+	return f.Service != "" && f.Service != "unknown"
+}
+
+func (f Fingerprint) field(name string) string {
+	switch name {
+	case FieldService:
+		return f.Service
+	case FieldProduct:
+		return f.Product
+	case FieldVersion:
+		return f.Version
+	case FieldInfo:
+		return f.Info
+	case FieldCPE:
+		return f.CPE
+	case FieldOS:
+		return f.OS
+	case FieldHostname:
+		return f.Hostname
+	case FieldDeviceType:
+		return f.DeviceType
+	}
+	return ""
+}
+
+// Diff 比较两次指纹，返回指定字段中发生变化的部分
+func (f Fingerprint) Diff(prev Fingerprint, fields ...string) []FingerprintChange {
+	if len(fields) == 0 {
+		fields = []string{FieldService, FieldProduct, FieldVersion, FieldInfo, FieldCPE, FieldOS, FieldHostname, FieldDeviceType}
+	}
+
+	var changes []FingerprintChange
+	for _, name := range fields {
+		if old, cur := prev.field(name), f.field(name); old != cur {
+			changes = append(changes, FingerprintChange{Field: name, Old: old, New: cur})
+		}
+	}
+	return changes
+}
+
+// Evaluate 检查本次指纹相对上次是否触发告警，返回触发告警的变化，未触发返回 nil
+func (r FingerprintRule) Evaluate(prev, cur *Fingerprint) []FingerprintChange {
+	// 没有历史指纹（首次检测）或本次端口未开放时不比较
+	if prev == nil || cur == nil {
+		return nil
+	}
+	if r.IgnoreEmpty && (!prev.identified() || !cur.identified()) {
+		return nil
+	}
+
+	fields := r.Fields
+	if len(fields) == 0 {
+		fields = []string{FieldService, FieldProduct, FieldVersion}
+	}
+	return cur.Diff(*prev, fields...)
+}
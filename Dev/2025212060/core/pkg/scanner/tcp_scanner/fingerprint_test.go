@@ -0,0 +1,109 @@
+package tcp_scanner
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFingerprintDiff(t *testing.T) {
+	nginx := Fingerprint{Service: "http", Product: "nginx", Version: "1.24.0", OS: "Linux"}
+	tests := []struct {
+		name   string
+		prev   Fingerprint
+		cur    Fingerprint
+		fields []string
+		want   []FingerprintChange
+	}{
+		{name: "same", prev: nginx, cur: nginx},
+		{
+			name: "all fields by default",
+			prev: nginx,
+			cur:  Fingerprint{Service: "http", Product: "Apache httpd", Version: "2.4.58", Hostname: "web"},
+			want: []FingerprintChange{
+				{Field: FieldProduct, Old: "nginx", New: "Apache httpd"},
+				{Field: FieldVersion, Old: "1.24.0", New: "2.4.58"},
+				{Field: FieldOS, Old: "Linux", New: ""},
+				{Field: FieldHostname, Old: "", New: "web"},
+			},
+		},
+		{
+			name:   "selected fields only",
+			prev:   nginx,
+			cur:    Fingerprint{Service: "http", Product: "nginx", Version: "1.25.3"},
+			fields: []string{FieldProduct, FieldOS},
+			want:   []FingerprintChange{{Field: FieldOS, Old: "Linux", New: ""}},
+		},
+		{
+			name:   "unknown field never changes",
+			prev:   nginx,
+			cur:    Fingerprint{},
+			fields: []string{"banner"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.cur.Diff(tt.prev, tt.fields...); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Diff = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFingerprintRuleEvaluate(t *testing.T) {
+	ssh := &Fingerprint{Service: "ssh", Product: "OpenSSH", Version: "8.9p1", Info: "Ubuntu"}
+	upgraded := &Fingerprint{Service: "ssh", Product: "OpenSSH", Version: "9.6p1", Info: "Debian"}
+	unknown := &Fingerprint{Service: "unknown"}
+	empty := &Fingerprint{}
+	tests := []struct {
+		name string
+		rule FingerprintRule
+		prev *Fingerprint
+		cur  *Fingerprint
+		want []FingerprintChange
+	}{
+		{name: "first scan", prev: nil, cur: ssh},
+		{name: "port closed", prev: ssh, cur: nil},
+		{name: "unchanged", prev: ssh, cur: ssh},
+		{
+			name: "default fields",
+			prev: ssh,
+			cur:  upgraded,
+			want: []FingerprintChange{{Field: FieldVersion, Old: "8.9p1", New: "9.6p1"}},
+		},
+		{
+			name: "custom fields",
+			rule: FingerprintRule{Fields: []string{FieldInfo}},
+			prev: ssh,
+			cur:  upgraded,
+			want: []FingerprintChange{{Field: FieldInfo, Old: "Ubuntu", New: "Debian"}},
+		},
+		{
+			name: "lost service alerts without IgnoreEmpty",
+			prev: ssh,
+			cur:  unknown,
+			want: []FingerprintChange{
+				{Field: FieldService, Old: "ssh", New: "unknown"},
+				{Field: FieldProduct, Old: "OpenSSH", New: ""},
+				{Field: FieldVersion, Old: "8.9p1", New: ""},
+			},
+		},
+		{name: "current unknown ignored", rule: FingerprintRule{IgnoreEmpty: true}, prev: ssh, cur: unknown},
+		{name: "current empty ignored", rule: FingerprintRule{IgnoreEmpty: true}, prev: ssh, cur: empty},
+		{name: "previous unknown ignored", rule: FingerprintRule{IgnoreEmpty: true}, prev: unknown, cur: ssh},
+		{name: "previous empty ignored", rule: FingerprintRule{IgnoreEmpty: true}, prev: empty, cur: ssh},
+		{
+			name: "identified change still alerts with IgnoreEmpty",
+			rule: FingerprintRule{IgnoreEmpty: true},
+			prev: ssh,
+			cur:  upgraded,
+			want: []FingerprintChange{{Field: FieldVersion, Old: "8.9p1", New: "9.6p1"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.rule.Evaluate(tt.prev, tt.cur); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Evaluate = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
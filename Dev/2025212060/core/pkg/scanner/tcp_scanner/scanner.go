@@ -1,51 +1,142 @@
 package tcp_scanner
 
 import (
+	"errors"
 	"fmt"
 	"net"
 	vscan "redrock-dashboard/core/pkg/scanner/tcp_scanner/service_lib"
+	"redrock-dashboard/core/pkg/scanner/tcp_scanner/tcp_lib"
+	"redrock-dashboard/core/pkg/scanner/tcp_scanner/vuln_lib"
 	"time"
 )
 
 type TCPScanResult struct {
-	TimeDelay     time.Duration
-	Open          bool
-	ServiceBanner string
+	TimeDelay        time.Duration
+	Open             bool
+	RemoteAddr       string
+	TLSHandshakeTime time.Duration
+	TLSVersion       string
+	TLSCipherSuite   string
+	TLSError         string // 端口开放但 TLS 握手失败的原因
+	ServiceBanner    string
+	Fingerprint      *Fingerprint // 结构化的服务识别结果，端口未开放时为 nil
+	Vulnerabilities  []vuln_lib.Vulnerability
+	MaxSeverity      string // 已知漏洞中最高的严重程度，没有时为空
 }
 
 type TCPScanner struct {
-	Taget string
-	Port  string
-}
+	Taget         string
+	Port          string
+	Timeout       time.Duration // 建连超时，为 0 时使用默认值
+	SourceAddr    string        // 绑定的源地址
+	IPVersion     int           // 0 自动，4 仅 IPv4，6 仅 IPv6
+	TLS           bool          // 建连后进行 TLS 握手
+	ServerName    string        // TLS SNI，为空时使用 Taget
+	SkipTLSVerify bool
 
-func (r TCPScanner) Scan() (*TCPScanResult, error) {
-	start := time.Now()
-	address, err := net.ResolveTCPAddr("tcp", net.JoinHostPort(r.Taget, r.Port))
-	if err != nil {
-		return nil, err
-	}
+	VersionIntensity int           // 服务版本探测强度 1-9，为 0 时使用默认值 7
+	ServiceTimeout   time.Duration // 服务识别总耗时上限，为 0 时使用默认值 10 秒
 
-	conn, err := net.DialTCP("tcp", nil, address)
-	defer conn.Close()
+	VulnDB *vuln_lib.Database // 本地漏洞库，为 nil 时不做漏洞匹配
+}
 
-	duration := time.Since(start)
+func (r TCPScanner) checkerOptions() []tcp_lib.CheckerOption {
+	opts := []tcp_lib.CheckerOption{tcp_lib.WithTimeout(5 * time.Second)}
+	if r.Timeout > 0 {
+		opts = append(opts, tcp_lib.WithTimeout(r.Timeout))
+	}
+	if r.SourceAddr != "" {
+		opts = append(opts, tcp_lib.WithSourceAddr(r.SourceAddr))
+	}
+	switch r.IPVersion {
+	case 4:
+		opts = append(opts, tcp_lib.WithIPv4())
+	case 6:
+		opts = append(opts, tcp_lib.WithIPv6())
+	}
+	if r.TLS {
+		opts = append(opts, tcp_lib.WithTLS(r.ServerName))
+	}
+	if r.SkipTLSVerify {
+		opts = append(opts, tcp_lib.SkipTLSVerify())
+	}
+	return opts
+}
 
-	if err != nil {
-		data := &TCPScanResult{
-			TimeDelay: duration,
-			Open:      false,
+func (r TCPScanner) exploreOptions() []vscan.ExploreOption {
+	// 探针连接与端口检测使用相同的建连设置
+	opts := []vscan.ExploreOption{
+		vscan.WithScanTimeout(10 * time.Second),
+		vscan.WithDialTimeout(5 * time.Second),
+		vscan.WithSourceAddr(r.SourceAddr),
+	}
+	if r.Timeout > 0 {
+		opts = append(opts, vscan.WithDialTimeout(r.Timeout))
+	}
+	switch r.IPVersion {
+	case 4:
+		opts = append(opts, vscan.WithNetwork("tcp4"))
+	case 6:
+		opts = append(opts, vscan.WithNetwork("tcp6"))
+	}
+	if r.VersionIntensity > 0 {
+		opts = append(opts, vscan.WithIntensity(r.VersionIntensity))
+	}
+	if r.ServiceTimeout > 0 {
+		opts = append(opts, vscan.WithScanTimeout(r.ServiceTimeout))
+	}
+	if r.TLS {
+		serverName := r.ServerName
+		if serverName == "" {
+			serverName = r.Taget
 		}
+		opts = append(opts, vscan.WithTLSTunnel(serverName))
+	}
+	return opts
+}
 
-		return data, nil
+func (r TCPScanner) Scan() (*TCPScanResult, error) {
+	if r.SourceAddr != "" && net.ParseIP(r.SourceAddr) == nil {
+		return nil, fmt.Errorf("invalid source address: %s", r.SourceAddr)
 	}
 
-	serviceBanner := vscan.GetProbes(fmt.Sprintf("%v, %v", r.Taget, r.Port))
+	checker := tcp_lib.NewTCPChecker(r.checkerOptions()...)
+	result := checker.Check(r.Taget, r.Port)
 
 	data := &TCPScanResult{
-		TimeDelay:     duration,
-		ServiceBanner: serviceBanner,
-		Open:          true,
+		TimeDelay:        result.ConnectTime,
+		Open:             result.Open,
+		RemoteAddr:       result.RemoteAddr,
+		TLSHandshakeTime: result.TLSHandshakeTime,
+		TLSVersion:       result.TLSVersion,
+		TLSCipherSuite:   result.TLSCipherSuite,
+	}
+
+	// 域名解析失败属于检测出错，端口未开放则不算
+	var dnsErr *net.DNSError
+	if errors.As(result.Error, &dnsErr) {
+		return nil, result.Error
+	}
+	if !result.Open {
+		return data, nil
+	}
+	// 端口开放但握手失败时保留已得到的连接信息，失败原因记录在 TLSError 中
+	if result.Error != nil {
+		data.TLSError = result.Error.Error()
+		return data, nil
+	}
+
+	probes, err := vscan.Shared()
+	if err != nil {
+		return nil, err
+	}
+	service, err := probes.Explore(net.JoinHostPort(r.Taget, r.Port), r.exploreOptions()...)
+	if err != nil {
+		return nil, err
 	}
+	data.ServiceBanner = service.Describe()
+	data.Fingerprint = newFingerprint(service)
+	data.Vulnerabilities, data.MaxSeverity = matchVulnerabilities(r.VulnDB, data.Fingerprint)
 
 	return data, nil
 }
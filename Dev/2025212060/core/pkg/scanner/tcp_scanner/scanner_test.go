@@ -0,0 +1,59 @@
+package tcp_scanner
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+// 端口开放但对端不是 TLS 服务时，结果里保留连接信息，握手错误记录在 TLSError 中
+func TestScanTLSHandshakeFailure(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conn.Write([]byte("220 plain text service\r\n"))
+			conn.Close()
+		}
+	}()
+
+	host, port, _ := net.SplitHostPort(ln.Addr().String())
+	scanner := TCPScanner{Taget: host, Port: port, TLS: true, Timeout: time.Second}
+	data, err := scanner.Scan()
+	if err != nil {
+		t.Fatalf("handshake failure should not be returned as an error: %v", err)
+	}
+	if !data.Open || data.RemoteAddr == "" || data.TLSError == "" {
+		t.Errorf("unexpected result %+v", data)
+	}
+}
+
+func TestScanClosedPort(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	host, port, _ := net.SplitHostPort(ln.Addr().String())
+	ln.Close()
+
+	data, err := TCPScanner{Taget: host, Port: port, Timeout: time.Second}.Scan()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.Open || data.Fingerprint != nil {
+		t.Errorf("closed port reported as %+v", data)
+	}
+}
+
+func TestScanInvalidSourceAddr(t *testing.T) {
+	if _, err := (TCPScanner{Taget: "127.0.0.1", Port: "22", SourceAddr: "bad"}).Scan(); err == nil {
+		t.Error("expected invalid source address error")
+	}
+}
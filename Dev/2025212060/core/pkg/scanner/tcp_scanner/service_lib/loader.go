@@ -0,0 +1,113 @@
+package vscan
+
+import (
+	"os"
+	"strings"
+
+	logger "gitee.com/liumou_site/logger"
+)
+
+type loadOptions struct {
+	probesFile       string // 替换内置库的 nmap-service-probes 文件
+	fingerprintsFile string // 追加到探针库的自定义指纹文件
+}
+
+// Option 探针库加载选项
+type Option func(*loadOptions)
+
+// WithProbesFile 使用外部 nmap-service-probes 文件代替内置探针库（例如更新版本的 nmap）
+func WithProbesFile(path string) Option {
+	return func(o *loadOptions) { o.probesFile = path }
+}
+
+// WithFingerprintsFile 追加自定义服务指纹，格式与 nmap-service-probes 相同。
+// 与已有探针同名的 Probe 只合并其 match/softmatch 与端口，且优先于内置规则匹配。
+func WithFingerprintsFile(path string) Option {
+	return func(o *loadOptions) { o.fingerprintsFile = path }
+}
+
+// NewVScan 按选项加载探针库
+func NewVScan(opts ...Option) (*VScan, error) {
+	var o loadOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	v := &VScan{}
+	if err := v.load(o); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func (v *VScan) load(o loadOptions) error {
+	v.logs = logger.NewLogger(1)
+	v.logs.Modular = "serviceScanLib"
+
+	var probes []Probe
+	var err error
+	if o.probesFile != "" {
+		probes, v.Exclude, err = readProbesFile(o.probesFile, false)
+	} else {
+		var content string
+		content, err = builtinProbes()
+		if err == nil {
+			probes, v.Exclude, err = parseProbesFromContent(content, "builtin nmap-service-probes", false)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	if o.fingerprintsFile != "" {
+		custom, _, err := readProbesFile(o.fingerprintsFile, true)
+		if err != nil {
+			return err
+		}
+		probes = mergeProbes(probes, custom)
+	}
+
+	v.Probes = probes
+	v.parseProbesToMapKName(v.Probes)
+	v.groupProbesByProtocol(v.Probes)
+	return nil
+}
+
+func readProbesFile(path string, strict bool) ([]Probe, string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, "", err
+	}
+	return parseProbesFromContent(string(content), path, strict)
+}
+
+// mergeProbes 将自定义探针合并进基础探针库
+func mergeProbes(base []Probe, custom []Probe) []Probe {
+	index := map[string]int{}
+	for i, probe := range base {
+		index[probe.Protocol+"/"+probe.Name] = i
+	}
+
+	for _, probe := range custom {
+		i, ok := index[probe.Protocol+"/"+probe.Name]
+		if !ok {
+			base = append(base, probe)
+			index[probe.Protocol+"/"+probe.Name] = len(base) - 1
+			continue
+		}
+
+		// 不修改内置探针共享的 Matchs，重新拼一份
+		matchs := append(append([]Match{}, *probe.Matchs...), *base[i].Matchs...)
+		base[i].Matchs = &matchs
+		base[i].Ports = joinPorts(base[i].Ports, probe.Ports)
+		base[i].SSLPorts = joinPorts(base[i].SSLPorts, probe.SSLPorts)
+	}
+	return base
+}
+
+func joinPorts(a, b string) string {
+	if a == "" || b == "" {
+		return a + b
+	}
+	return strings.TrimSuffix(a, ",") + "," + b
+}
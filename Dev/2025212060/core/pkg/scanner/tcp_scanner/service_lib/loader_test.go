@@ -0,0 +1,25 @@
+package vscan
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewVScanMalformedFingerprints(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "custom-probes")
+	content := "Probe TCP NULL q||\nmatch ssh m|^SSH-| p/OpenSSH/\nmatch ssh m|^SSH-\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := NewVScan(WithFingerprintsFile(path))
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("got %v, want *ParseError", err)
+	}
+	if parseErr.Source != path || parseErr.Line != 3 {
+		t.Errorf("error at %s:%d, want %s:3", parseErr.Source, parseErr.Line, path)
+	}
+}
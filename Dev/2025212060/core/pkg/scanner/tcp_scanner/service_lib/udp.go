@@ -0,0 +1,124 @@
+package vscan
+
+import (
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// UDP 端口状态，与 nmap 的表述一致
+const (
+	PortOpen         = "open"
+	PortClosed       = "closed"
+	PortOpenFiltered = "open|filtered"
+)
+
+// ExploreUDP 使用 UDP 探针识别服务。
+// UDP 没有握手：收到 ICMP 端口不可达视为 closed，收到任意响应视为 open，
+// 所有探针都没有回应时无法区分开放与被过滤，只能报告 open|filtered。
+func (v *VScan) ExploreUDP(addr string, opts ...ExploreOption) (Result, error) {
+	var target Target
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return Result{}, err
+	}
+	target.IP = host
+	target.Port, err = strconv.Atoi(port)
+	if err != nil {
+		return Result{}, err
+	}
+	target.Protocol = "udp"
+
+	cfg := newExploreConfig(opts...)
+	// 与 TCP 共用的网络选项换成对应的 UDP 网络：tcp4 -> udp4，tcp6 -> udp6
+	cfg.network = "udp" + strings.TrimPrefix(strings.TrimPrefix(cfg.network, "tcp"), "udp")
+	// UDP 上没有 TLS 隧道（DTLS 不在探测范围内）
+	cfg.tls = false
+	if _, err := cfg.dialer(time.Time{}); err != nil {
+		return Result{Target: target}, err
+	}
+
+	result := Result{Target: target, State: PortOpenFiltered}
+	if v.IsExcluded(target.Port, target.Protocol) {
+		return result, nil
+	}
+
+	probes := v.selectProbes(target, cfg)
+	// 没有可用探针时发一个空包，至少能借 ICMP 判断端口是否关闭
+	if len(probes) == 0 {
+		probes = []Probe{{Name: "NULL", Protocol: "udp", Matchs: &[]Match{}}}
+	}
+
+	softFound := false
+	start := time.Now()
+	for _, probe := range probes {
+		wait := cfg.waitFor(probe)
+		if wait <= 0 {
+			break
+		}
+
+		response, err := grabUDPResponse(target.GetAddress(), probe.payload, wait, cfg)
+		if errors.Is(err, syscall.ECONNREFUSED) {
+			result.State = PortClosed
+			result.RTT = time.Since(start)
+			return result, nil
+		}
+		if len(response) == 0 {
+			continue
+		}
+
+		if result.State != PortOpen {
+			result.State = PortOpen
+			result.RTT = time.Since(start)
+			result.Banner = trimBanner(response)
+			result.Service.Name = "unknown"
+		}
+
+		match, ok := v.findMatch(probe, response)
+		if !ok || (match.IsSoft && softFound) {
+			continue
+		}
+		rtt := result.RTT
+		result = buildResult(target, match, response, cfg)
+		result.State = PortOpen
+		result.RTT = rtt
+		if !match.IsSoft {
+			return result, nil
+		}
+		softFound = true
+	}
+	return result, nil
+}
+
+// grabUDPResponse 发送一个 UDP 探针并等待响应。
+// 对已连接的 UDP 套接字，内核收到 ICMP 端口不可达后读写会返回 ECONNREFUSED。
+func grabUDPResponse(addr string, data []byte, wait time.Duration, cfg *exploreConfig) ([]byte, error) {
+	deadline := time.Now().Add(wait)
+
+	dialer, err := cfg.dialer(deadline)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := dialer.Dial(cfg.network, addr)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if err := conn.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+	if _, err := conn.Write(data); err != nil {
+		return nil, err
+	}
+
+	buff := make([]byte, 65535)
+	n, err := conn.Read(buff)
+	if n > 0 {
+		return buff[:n], nil
+	}
+	return nil, err
+}
@@ -0,0 +1,84 @@
+package vscan
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestExploreUDPClosed(t *testing.T) {
+	v, err := NewVScan()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 关闭后的端口会回 ICMP 端口不可达
+	conn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := conn.LocalAddr().String()
+	conn.Close()
+
+	result, err := v.ExploreUDP(addr, WithNetwork("tcp4"), WithSourceAddr("127.0.0.1"), WithScanTimeout(5*time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.State != PortClosed {
+		t.Errorf("state = %q, want %q", result.State, PortClosed)
+	}
+}
+
+func TestExploreUDPNoReply(t *testing.T) {
+	v, err := NewVScan()
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	sources := make(chan string, 1)
+	go func() {
+		buf := make([]byte, 65535)
+		if _, from, err := conn.ReadFrom(buf); err == nil {
+			sources <- from.String()
+		}
+	}()
+
+	start := time.Now()
+	result, err := v.ExploreUDP(conn.LocalAddr().String(), WithSourceAddr("127.0.0.1"),
+		WithProbeWait(100*time.Millisecond), WithScanTimeout(time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.State != PortOpenFiltered {
+		t.Errorf("state = %q, want %q", result.State, PortOpenFiltered)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("explore took %v", elapsed)
+	}
+	select {
+	case from := <-sources:
+		if host, _, _ := net.SplitHostPort(from); host != "127.0.0.1" {
+			t.Errorf("probe sent from %s, want 127.0.0.1", from)
+		}
+	default:
+		t.Error("no probe received")
+	}
+}
+
+func TestExploreUDPNetwork(t *testing.T) {
+	v, err := NewVScan()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := v.ExploreUDP("127.0.0.1:53", WithSourceAddr("not-an-ip")); err == nil {
+		t.Error("invalid source address should fail")
+	}
+
+	// 只允许 IPv6 时不会发往 IPv4 地址
+	if _, err := grabUDPResponse("127.0.0.1:9", nil, 100*time.Millisecond, &exploreConfig{network: "udp6"}); err == nil {
+		t.Error("udp6 dial to an IPv4 address should fail")
+	}
+}
@@ -0,0 +1,333 @@
+package vscan
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// nmap 的正则是按字节匹配的（PCRE 非 UTF 模式），而 Go 的 regexp 按 UTF-8 字符匹配。
+// 这里把模式与响应都按 Latin-1 一一映射成字符，\xNN 就能准确地匹配原始字节。
+
+// latin1 将字节逐个映射为 U+0000-U+00FF 的字符
+func latin1(b []byte) string {
+	// 纯 ASCII 无需转换
+	i := 0
+	for i < len(b) && b[i] < utf8.RuneSelf {
+		i++
+	}
+	if i == len(b) {
+		return string(b)
+	}
+	out := make([]byte, i, len(b)+(len(b)-i))
+	copy(out, b[:i])
+	for _, c := range b[i:] {
+		out = utf8.AppendRune(out, rune(c))
+	}
+	return string(out)
+}
+
+// fromLatin1 latin1 的逆过程，还原原始字节
+func fromLatin1(s string) []byte {
+	b := make([]byte, 0, len(s))
+	for _, r := range s {
+		b = append(b, byte(r))
+	}
+	return b
+}
+
+// compilePattern 编译 nmap 的 match 模式，flags 为模式后的 i、s 选项
+func compilePattern(pattern string, flags string) (*regexp.Regexp, error) {
+	// \e 是 PCRE 的 ESC，Go 不支持
+	pattern = strings.ReplaceAll(pattern, `\e`, `\x1b`)
+	// nmap 大量 HTTP 规则用 (?!\r\n) 前瞻跳过响应头，RE2 不支持前瞻；
+	// 改写成只跨越非空行的等价写法，同样不会越过头部结尾的空行
+	pattern = strings.ReplaceAll(pattern, `(?:[^\r\n]*\r\n(?!\r\n))*?`, `(?:[^\r\n]+\r\n)*?`)
+
+	var modifiers string
+	if strings.Contains(flags, "i") {
+		modifiers += "i"
+	}
+	if strings.Contains(flags, "s") {
+		modifiers += "s"
+	}
+	if modifiers != "" {
+		pattern = "(?" + modifiers + ")" + pattern
+	}
+
+	compiled, err := regexp.Compile(latin1([]byte(pattern)))
+	if err != nil {
+		return nil, &regexpSyntaxError{err}
+	}
+	return compiled, nil
+}
+
+// versionField versioninfo 中的一个字段，如 p/OpenSSH/ 或 cpe:/a:openbsd:openssh:$1/a
+type versionField struct {
+	Name  string // p、v、i、h、o、d、cpe
+	Value string // 未替换的模板
+	Flags string // 目前只有 cpe 的 a 标志
+}
+
+// parseVersionTemplate 解析 versioninfo 小语言，字段的分隔符可以是任意字节
+func parseVersionTemplate(s string) ([]versionField, error) {
+	var fields []versionField
+
+	for {
+		s = strings.TrimLeft(s, " \t")
+		if s == "" {
+			return fields, nil
+		}
+
+		var field versionField
+		switch {
+		case strings.HasPrefix(s, "cpe:"):
+			field.Name = "cpe"
+			s = s[len("cpe:"):]
+		case strings.IndexByte("pvihod", s[0]) >= 0:
+			field.Name = s[:1]
+			s = s[1:]
+		default:
+			return fields, fmt.Errorf("unknown versioninfo field at %q", s)
+		}
+
+		if s == "" {
+			return fields, fmt.Errorf("missing delimiter for field %s", field.Name)
+		}
+		// 与 nmap 一致，分隔符是单个字节，不按 UTF-8 解码
+		delimiter := s[0]
+		s = s[1:]
+		end := strings.IndexByte(s, delimiter)
+		if end < 0 {
+			return fields, fmt.Errorf("field %s is not terminated by %q", field.Name, delimiter)
+		}
+		field.Value = s[:end]
+		s = s[end+1:]
+
+		// 紧跟在结束分隔符后的字母是该字段的标志
+		flagsEnd := strings.IndexAny(s, " \t")
+		if flagsEnd < 0 {
+			flagsEnd = len(s)
+		}
+		field.Flags = s[:flagsEnd]
+		s = s[flagsEnd:]
+
+		fields = append(fields, field)
+	}
+}
+
+// captureIndex 解析 $1-$9 中的序号
+func captureIndex(s string, captures [][]byte) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	if len(s) != 1 || s[0] < '1' || s[0] > '9' {
+		return nil, fmt.Errorf("bad capture reference %q", s)
+	}
+	index := int(s[0] - '0')
+	if index >= len(captures) {
+		return nil, nil
+	}
+	return captures[index], nil
+}
+
+// helperArgs 解析 $SUBST(1,"a","b") 形式的参数列表，引号内可以含逗号
+func helperArgs(s string) []string {
+	var args []string
+	var current strings.Builder
+	quoted := false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '"':
+			quoted = !quoted
+		case c == '\\' && quoted && i+1 < len(s):
+			i++
+			current.WriteByte(s[i])
+		case c == ',' && !quoted:
+			args = append(args, current.String())
+			current.Reset()
+		default:
+			current.WriteByte(c)
+		}
+	}
+	return append(args, current.String())
+}
+
+// helperEnd 返回辅助函数参数结尾的 ')' 位置，跳过引号内的括号与转义，未闭合返回 -1
+func helperEnd(s string) int {
+	quoted := false
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '"':
+			quoted = !quoted
+		case c == '\\' && quoted:
+			i++
+		case c == ')' && !quoted:
+			return i
+		}
+	}
+	return -1
+}
+
+// printable 只保留可打印的 ASCII 字符，对应 nmap 的 $P()
+func printable(b []byte) []byte {
+	var out []byte
+	for _, c := range b {
+		if c >= 0x20 && c < 0x7f {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// unpackInt 把捕获的字节按大端或小端解成无符号整数，对应 nmap 的 $I()
+func unpackInt(b []byte, order string) ([]byte, error) {
+	if len(b) == 0 || len(b) > 8 {
+		return nil, fmt.Errorf("$I() needs 1-8 bytes, got %d", len(b))
+	}
+	buf := make([]byte, 8)
+	switch order {
+	case ">":
+		copy(buf[8-len(b):], b)
+		return []byte(strconv.FormatUint(binary.BigEndian.Uint64(buf), 10)), nil
+	case "<":
+		copy(buf, b)
+		return []byte(strconv.FormatUint(binary.LittleEndian.Uint64(buf), 10)), nil
+	}
+	return nil, fmt.Errorf("$I() byte order must be > or <, got %q", order)
+}
+
+// expandTemplate 替换字段模板中的 $1-$9、$P()、$SUBST()、$I()
+func expandTemplate(template string, captures [][]byte) (string, error) {
+	var out []byte
+
+	for i := 0; i < len(template); i++ {
+		if template[i] != '$' || i+1 >= len(template) {
+			out = append(out, template[i])
+			continue
+		}
+
+		rest := template[i+1:]
+		if rest[0] >= '1' && rest[0] <= '9' {
+			value, _ := captureIndex(rest[:1], captures)
+			out = append(out, value...)
+			i++
+			continue
+		}
+
+		var name string
+		for _, helper := range []string{"P(", "SUBST(", "I("} {
+			if strings.HasPrefix(rest, helper) {
+				name = helper
+				break
+			}
+		}
+		if name == "" {
+			out = append(out, '$')
+			continue
+		}
+
+		end := helperEnd(rest[len(name):])
+		if end < 0 {
+			return "", fmt.Errorf("unterminated $%s", name)
+		}
+		end += len(name)
+		args := helperArgs(rest[len(name):end])
+		value, err := captureIndex(args[0], captures)
+		if err != nil {
+			return "", err
+		}
+
+		switch name {
+		case "P(":
+			out = append(out, printable(value)...)
+		case "SUBST(":
+			if len(args) != 3 {
+				return "", fmt.Errorf("$SUBST() needs 3 arguments, got %d", len(args))
+			}
+			out = append(out, bytes.ReplaceAll(value, []byte(args[1]), []byte(args[2]))...)
+		case "I(":
+			if len(args) != 2 {
+				return "", fmt.Errorf("$I() needs 2 arguments, got %d", len(args))
+			}
+			number, err := unpackInt(value, strings.TrimSpace(args[1]))
+			if err != nil {
+				return "", err
+			}
+			out = append(out, number...)
+		}
+		i += end + 1
+	}
+
+	// 捕获内容可能不是 UTF-8（如 GBK 或二进制），按 Latin-1 解读，保证结果是合法字符串
+	if !utf8.Valid(out) {
+		return latin1(out), nil
+	}
+	return string(out), nil
+}
+
+// findCaptures 按字节语义匹配 latin1 转换后的响应，返回包含整体匹配在内的捕获组
+func (m *Match) findCaptures(text string) [][]byte {
+	found := m.PatternCompiled.FindStringSubmatch(text)
+	if found == nil {
+		return nil
+	}
+	captures := make([][]byte, len(found))
+	for i, value := range found {
+		captures[i] = fromLatin1(value)
+	}
+	return captures
+}
+
+func (m *Match) MatchPattern(response []byte) (matched bool) {
+	return m.matchText(latin1(response))
+}
+
+// matchText 匹配已经 latin1 转换过的响应，同一响应逐条匹配规则时只需转换一次
+func (m *Match) matchText(text string) bool {
+	return m.PatternCompiled.MatchString(text)
+}
+
+// ParseVersionInfo 用响应中的捕获组填充 versioninfo 模板。
+// 单个字段的模板出错时只跳过该字段，不会影响其他字段，也不会 panic。
+func (m *Match) ParseVersionInfo(response []byte) Extras {
+	var extras = Extras{}
+
+	captures := m.findCaptures(latin1(response))
+	if captures == nil {
+		return extras
+	}
+
+	for _, field := range m.versionFields {
+		value, err := expandTemplate(field.Value, captures)
+		if err != nil {
+			continue
+		}
+		value = strings.TrimSpace(value)
+
+		switch field.Name {
+		case "p":
+			extras.VendorProduct = value
+		case "v":
+			extras.Version = value
+		case "i":
+			extras.Info = value
+		case "h":
+			extras.Hostname = value
+		case "o":
+			extras.OperatingSystem = value
+		case "d":
+			extras.DeviceType = value
+		case "cpe":
+			extras.CPEs = append(extras.CPEs, value)
+			// 首选应用类 CPE，供漏洞匹配使用
+			if extras.CPE == "" || (!strings.HasPrefix(extras.CPE, "a:") && strings.HasPrefix(value, "a:")) {
+				extras.CPE = value
+			}
+		}
+	}
+	return extras
+}
@@ -0,0 +1,139 @@
+package vscan
+
+import (
+	"reflect"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestLatin1RoundTrip(t *testing.T) {
+	all := make([]byte, 256)
+	for i := range all {
+		all[i] = byte(i)
+	}
+	for _, b := range [][]byte{nil, []byte("SSH-2.0-OpenSSH\r\n"), all, {0x80, 'a', 0xff}} {
+		s := latin1(b)
+		if utf8.RuneCountInString(s) != len(b) || !utf8.ValidString(s) {
+			t.Errorf("latin1(% x) = %q", b, s)
+		}
+		if got := fromLatin1(s); string(got) != string(b) {
+			t.Errorf("fromLatin1(latin1(% x)) = % x", b, got)
+		}
+	}
+}
+
+func TestParseVersionTemplate(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want []versionField
+	}{
+		{"empty", "", nil},
+		{"simple", "p/OpenSSH/ v/$2/", []versionField{{Name: "p", Value: "OpenSSH"}, {Name: "v", Value: "$2"}}},
+		{"other delimiter", "i|protocol 2.0| o|Linux|", []versionField{{Name: "i", Value: "protocol 2.0"}, {Name: "o", Value: "Linux"}}},
+		{"cpe flags", "cpe:/a:openbsd:openssh:$1/a", []versionField{{Name: "cpe", Value: "a:openbsd:openssh:$1", Flags: "a"}}},
+		{"non-utf8 delimiter", "p\xfdApache\xfd", []versionField{{Name: "p", Value: "Apache"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseVersionTemplate(tt.in)
+			if err != nil {
+				t.Fatalf("parseVersionTemplate(%q) error: %v", tt.in, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseVersionTemplate(%q) = %+v, want %+v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseVersionTemplateErrors(t *testing.T) {
+	for _, in := range []string{"x/foo/", "p", "p/unterminated", "d\xfd\xeb"} {
+		if _, err := parseVersionTemplate(in); err == nil {
+			t.Errorf("parseVersionTemplate(%q) expected error", in)
+		}
+	}
+}
+
+func TestExpandTemplate(t *testing.T) {
+	captures := [][]byte{[]byte("all"), []byte("8.9p1"), []byte("a\x01b\x7fc"), {0x01, 0x02}, []byte("1_2_3"), []byte(`(v"1)`)}
+	tests := []struct {
+		template string
+		want     string
+	}{
+		{"OpenSSH $1", "OpenSSH 8.9p1"},
+		{"$9", ""},
+		{"cost $", "cost $"},
+		{"$P(2)", "abc"},
+		{`$SUBST(4,"_",".")`, "1.2.3"},
+		{"$I(3,>)", "258"},
+		{"$I(3,<)", "513"},
+		{"$X(1)", "$X(1)"},
+		// 引号内的括号和逗号属于参数
+		{`$SUBST(5,")","")`, `(v"1`},
+		{`$SUBST(5,"(","[,")-$1`, `[,v"1)-8.9p1`},
+		{`$SUBST(5,"\"","")`, "(v1)"},
+	}
+	for _, tt := range tests {
+		got, err := expandTemplate(tt.template, captures)
+		if err != nil {
+			t.Errorf("expandTemplate(%q) error: %v", tt.template, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("expandTemplate(%q) = %q, want %q", tt.template, got, tt.want)
+		}
+	}
+
+	for _, template := range []string{"$P(1", "$SUBST(1,a)", `$SUBST(1,")"`, "$I(6,>)", "$I(3,x)", "$P(x)"} {
+		if _, err := expandTemplate(template, captures); err == nil {
+			t.Errorf("expandTemplate(%q) expected error", template)
+		}
+	}
+}
+
+func TestExpandTemplateNonUTF8(t *testing.T) {
+	got, err := expandTemplate("$1", [][]byte{nil, {0xc4, 0xe3}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !utf8.ValidString(got) || got != "Äã" {
+		t.Errorf("expandTemplate non-utf8 = %q, want latin-1 %q", got, "Äã")
+	}
+}
+
+func FuzzParseVersionTemplate(f *testing.F) {
+	for _, seed := range []string{
+		"p/OpenSSH/ v/$2/ i/protocol $1/",
+		"cpe:/a:apache:http_server:$1/a",
+		"p|x| o|Linux| d/router/",
+		"d\xfd\xeb",
+		"p\xe4\xb8\xad",
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		fields, err := parseVersionTemplate(s)
+		if err != nil {
+			return
+		}
+		for _, field := range fields {
+			if len(field.Value) > len(s) {
+				t.Fatalf("field value %q longer than input %q", field.Value, s)
+			}
+		}
+	})
+}
+
+func FuzzExpandTemplate(f *testing.F) {
+	for _, seed := range []string{"$1", "$P(2)", `$SUBST(1,"_",".")`, "$I(2,>)", "$I(2,<", "$$1$", "$SUBST(1,\"\\\"\",)"} {
+		f.Add(seed, []byte("abc\x00\xff"))
+	}
+	f.Fuzz(func(t *testing.T, template string, capture []byte) {
+		captures := [][]byte{capture, capture, capture[:len(capture)/2]}
+		out, err := expandTemplate(template, captures)
+		if err == nil && !utf8.ValidString(out) {
+			t.Fatalf("expandTemplate(%q) returned invalid UTF-8 %q", template, out)
+		}
+	})
+}
@@ -3,21 +3,25 @@ package vscan
 import (
 	"bytes"
 	"compress/gzip"
+	"context"
 	"crypto/tls"
 	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
 	"io/ioutil"
 	"net"
 	"net/http"
+	"redrock-dashboard/core/pkg/charset"
 	"redrock-dashboard/core/pkg/scanner/tcp_scanner/service_lib/proberbyte"
 	"regexp"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	logger "gitee.com/liumou_site/logger"
-
-	"github.com/axgle/mahonia"
 )
 
 type VScan struct {
@@ -27,6 +31,9 @@ type VScan struct {
 
 	ProbesMapKName map[string]Probe
 
+	// 按协议分组并按 rarity 排好序的探针，Init 后只读
+	probesByProtocol map[string][]Probe
+
 	logs *logger.LocalLogger
 }
 
@@ -35,9 +42,12 @@ type Match struct {
 
 	Service     string
 	Pattern     string
+	Flags       string // 模式后的 i、s 选项
 	VersionInfo string
 
 	PatternCompiled *regexp.Regexp
+
+	versionFields []versionField
 }
 
 type Probe struct {
@@ -54,6 +64,8 @@ type Probe struct {
 	Fallback     string
 
 	Matchs *[]Match
+
+	payload []byte // 解码后的探针数据，解析时生成
 }
 
 type Directive struct {
@@ -63,121 +75,142 @@ type Directive struct {
 	DirectiveStr  string
 }
 
-func (p *Probe) getDirectiveSyntax(data string) (directive Directive) {
+func (p *Probe) getDirectiveSyntax(data string) (directive Directive, err error) {
 	directive = Directive{}
 
 	blankIndex := strings.Index(data, " ")
+	if blankIndex <= 0 {
+		return directive, fmt.Errorf("malformed directive %q", data)
+	}
 	directiveName := data[:blankIndex]
-	Flag := data[blankIndex+1 : blankIndex+2]
-	delimiter := data[blankIndex+2 : blankIndex+3]
-	directiveStr := data[blankIndex+3:]
+	// 名称与 m|...| 之间允许多个空格
+	rest := strings.TrimLeft(data[blankIndex:], " \t")
+	if len(rest) < 2 {
+		return directive, fmt.Errorf("malformed directive %q", data)
+	}
+	Flag := rest[:1]
+	delimiter := rest[1:2]
+	directiveStr := rest[2:]
 
 	directive.DirectiveName = directiveName
 	directive.Flag = Flag
 	directive.Delimiter = delimiter
 	directive.DirectiveStr = directiveStr
 
-	return directive
+	return directive, nil
 }
 
-func (p *Probe) parseProbeInfo(probeStr string) {
+func (p *Probe) parseProbeInfo(probeStr string) error {
+	if len(probeStr) < 4 {
+		return fmt.Errorf("bad probe line %q", probeStr)
+	}
 	proto := probeStr[:4]
 	other := probeStr[4:]
 
 	if !(proto == "TCP " || proto == "UDP ") {
-		panic("Probe <protocol>must be either TCP or UDP.")
+		return fmt.Errorf("probe <protocol> must be either TCP or UDP")
 	}
 	if len(other) == 0 {
-		panic("nmap-service-probes - bad probe name")
+		return fmt.Errorf("bad probe name")
 	}
 
-	directive := p.getDirectiveSyntax(other)
+	directive, err := p.getDirectiveSyntax(other)
+	if err != nil {
+		return err
+	}
+	if directive.Flag != "q" {
+		return fmt.Errorf("probe string must begin with q, got %q", directive.Flag)
+	}
+	if !strings.Contains(directive.DirectiveStr, directive.Delimiter) {
+		return fmt.Errorf("probe string of %s is not terminated by %q", directive.DirectiveName, directive.Delimiter)
+	}
 
 	p.Name = directive.DirectiveName
 	p.Data = strings.Split(directive.DirectiveStr, directive.Delimiter)[0]
 	p.Protocol = strings.ToLower(strings.TrimSpace(proto))
+	p.payload, _ = DecodeData(p.Data)
+	return nil
 }
 
-func (p *Probe) fromString(data string) error {
-	var err error
-
-	data = strings.TrimSpace(data)
-	lines := strings.Split(data, "\n")
-	probeStr := lines[0]
+// parseDirective 解析 Probe 行之后的单条指令。
+// strict 为 false 时跳过 Go 正则无法编译的 match（nmap 使用 PCRE，内置库中有少量不兼容写法）。
+func (p *Probe) parseDirective(line string, strict bool) error {
+	name, value, _ := strings.Cut(line, " ")
+	value = strings.TrimSpace(value)
 
-	p.parseProbeInfo(probeStr)
-
-	var matchs []Match
-	for _, line := range lines {
-		if strings.HasPrefix(line, "match ") {
-			match, err := p.getMatch(line)
-			if err != nil {
-				continue
-			}
-			matchs = append(matchs, match)
-		} else if strings.HasPrefix(line, "softmatch ") {
-			softMatch, err := p.getSoftMatch(line)
-			if err != nil {
-				continue
-			}
-			matchs = append(matchs, softMatch)
-		} else if strings.HasPrefix(line, "ports ") {
-			p.parsePorts(line)
-		} else if strings.HasPrefix(line, "sslports ") {
-			p.parseSSLPorts(line)
-		} else if strings.HasPrefix(line, "totalwaitms ") {
-			p.parseTotalWaitMS(line)
-		} else if strings.HasPrefix(line, "totalwaitms ") {
-			p.parseTotalWaitMS(line)
-		} else if strings.HasPrefix(line, "tcpwrappedms ") {
-			p.parseTCPWrappedMS(line)
-		} else if strings.HasPrefix(line, "rarity ") {
-			p.parseRarity(line)
-		} else if strings.HasPrefix(line, "fallback ") {
-			p.parseFallback(line)
+	var err error
+	switch name {
+	case "match", "softmatch":
+		if value == "" {
+			return fmt.Errorf("%s directive has no value", name)
 		}
+		var match Match
+		match, err = p.parseMatch(value, name == "softmatch")
+		if err == nil {
+			*p.Matchs = append(*p.Matchs, match)
+		}
+	case "ports":
+		return setDirectiveValue(&p.Ports, name, value)
+	case "sslports":
+		return setDirectiveValue(&p.SSLPorts, name, value)
+	case "fallback":
+		return setDirectiveValue(&p.Fallback, name, value)
+	case "totalwaitms":
+		p.TotalWaitMS, err = strconv.Atoi(value)
+		return err
+	case "tcpwrappedms":
+		p.TCPWrappedMS, err = strconv.Atoi(value)
+		return err
+	case "rarity":
+		return p.parseRarity(value)
+	default:
+		return fmt.Errorf("unknown directive %q", name)
 	}
-	p.Matchs = &matchs
-	return err
-}
-
-func (p *Probe) parsePorts(data string) {
-	p.Ports = data[len("ports")+1:]
-}
 
-func (p *Probe) parseSSLPorts(data string) {
-	p.SSLPorts = data[len("sslports")+1:]
-}
-
-func (p *Probe) parseTotalWaitMS(data string) {
-	p.TotalWaitMS, _ = strconv.Atoi(string(data[len("totalwaitms")+1:]))
+	var syntaxErr *regexpSyntaxError
+	if err != nil && !strict && errors.As(err, &syntaxErr) {
+		return nil
+	}
+	return err
 }
 
-func (p *Probe) parseTCPWrappedMS(data string) {
-	p.TCPWrappedMS, _ = strconv.Atoi(string(data[len("tcpwrappedms")+1:]))
+// setDirectiveValue 保存 ports、sslports、fallback 等字符串指令的值
+func setDirectiveValue(dst *string, name, value string) error {
+	if value == "" {
+		return fmt.Errorf("%s directive has no value", name)
+	}
+	*dst = value
+	return nil
 }
 
-func (p *Probe) parseRarity(data string) {
-	p.Rarity, _ = strconv.Atoi(string(data[len("rarity")+1:]))
+func (p *Probe) parseRarity(value string) (err error) {
+	p.Rarity, err = strconv.Atoi(value)
+	if err == nil && (p.Rarity < 1 || p.Rarity > 9) {
+		err = fmt.Errorf("rarity must be between 1 and 9, got %d", p.Rarity)
+	}
+	return err
 }
 
-func (p *Probe) parseFallback(data string) {
-	p.Fallback = data[len("fallback")+1:]
-}
+// 转义解析用到的正则在包初始化时编译一次，避免每次检测重复编译
+var (
+	hexCodeRe     = regexp.MustCompile(`\\x[0-9a-fA-F]{2}`)
+	octalCodeRe   = regexp.MustCompile(`\\[0-7]{1,3}`)
+	structCodeRe  = regexp.MustCompile(`\\[aftnrv]`)
+	otherEscapeRe = regexp.MustCompile(`\\[^\\]`)
+	escapeCodeRe  = regexp.MustCompile(`\\(x[0-9a-fA-F]{2}|[0-7]{1,3}|[aftnrv])`)
+	escapeCharRe  = regexp.MustCompile(`\\([^\\])`)
+)
 
 func isHexCode(b []byte) bool {
-	matchRe := regexp.MustCompile(`\\x[0-9a-fA-F]{2}`)
-	return matchRe.Match(b)
+	return hexCodeRe.Match(b)
 }
 
 func isOctalCode(b []byte) bool {
-	matchRe := regexp.MustCompile(`\\[0-7]{1,3}`)
-	return matchRe.Match(b)
+	return octalCodeRe.Match(b)
 }
 
 func isStructCode(b []byte) bool {
-	matchRe := regexp.MustCompile(`\\[aftnrv]`)
-	return matchRe.Match(b)
+	return structCodeRe.Match(b)
 }
 
 func isReChar(n int64) bool {
@@ -191,57 +224,78 @@ func isReChar(n int64) bool {
 }
 
 func isOtherEscapeCode(b []byte) bool {
-	matchRe := regexp.MustCompile(`\\[^\\]`)
-	return matchRe.Match(b)
+	return otherEscapeRe.Match(b)
 }
 
-func (v *VScan) parseProbesFromContent(content string) {
-	var probes []Probe
+// ParseError 探针文件解析错误，带来源和行号
+type ParseError struct {
+	Source string
+	Line   int
+	Err    error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%s:%d: %v", e.Source, e.Line, e.Err)
+}
 
-	var lines []string
-	linesTemp := strings.Split(content, "\n")
-	for _, lineTemp := range linesTemp {
-		lineTemp = strings.TrimSpace(lineTemp)
-		if lineTemp == "" || strings.HasPrefix(lineTemp, "#") {
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// regexpSyntaxError match 正则无法被 Go regexp 编译
+type regexpSyntaxError struct {
+	err error
+}
+
+func (e *regexpSyntaxError) Error() string {
+	return "compile pattern failed: " + e.err.Error()
+}
+
+func (e *regexpSyntaxError) Unwrap() error {
+	return e.err
+}
+
+// parseProbesFromContent 逐行解析 nmap-service-probes 格式内容，source 用于错误定位
+func parseProbesFromContent(content string, source string, strict bool) (probes []Probe, exclude string, err error) {
+	var current *Probe
+	lineCount := 0
+
+	for index, line := range strings.Split(content, "\n") {
+		lineNo := index + 1
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
 			continue
 		}
-		lines = append(lines, lineTemp)
-	}
-	if len(lines) == 0 {
-		panic("Failed to read nmap-service-probes file for probe data, 0 lines read.")
-	}
-	c := 0
-	for _, line := range lines {
-		if strings.HasPrefix(line, "Exclude ") {
-			c += 1
-		}
-		if c > 1 {
-			panic("Only 1 Exclude directive is allowed in the nmap-service-probes file")
+		lineCount++
+
+		switch {
+		case strings.HasPrefix(line, "Exclude "):
+			if exclude != "" {
+				return nil, "", &ParseError{source, lineNo, errors.New("only 1 Exclude directive is allowed")}
+			}
+			if len(probes) > 0 {
+				return nil, "", &ParseError{source, lineNo, errors.New("Exclude directive must precede all probes")}
+			}
+			exclude = strings.TrimSpace(strings.TrimPrefix(line, "Exclude "))
+		case strings.HasPrefix(line, "Probe "):
+			probes = append(probes, Probe{Matchs: &[]Match{}})
+			current = &probes[len(probes)-1]
+			if err := current.parseProbeInfo(strings.TrimPrefix(line, "Probe ")); err != nil {
+				return nil, "", &ParseError{source, lineNo, err}
+			}
+		case current == nil:
+			return nil, "", &ParseError{source, lineNo, errors.New(`line was expected to begin with "Probe " or "Exclude "`)}
+		default:
+			if err := current.parseDirective(line, strict); err != nil {
+				return nil, "", &ParseError{source, lineNo, err}
+			}
 		}
 	}
-	l := lines[0]
-	if !(strings.HasPrefix(l, "Exclude ") || strings.HasPrefix(l, "Probe ")) {
-		panic("Parse error on nmap-service-probes file: line was expected to begin with \"Probe \" or \"Exclude \"")
-	}
-	if c == 1 {
-		v.Exclude = l[len("Exclude")+1:]
-		lines = lines[1:]
-	}
-	content = strings.Join(lines, "\n")
-	content = "\n" + content
 
-	probeParts := strings.Split(content, "\nProbe")
-	probeParts = probeParts[1:]
-
-	for _, probePart := range probeParts {
-		probe := Probe{}
-		err := probe.fromString(probePart)
-		if err != nil {
-			continue
-		}
-		probes = append(probes, probe)
+	if lineCount == 0 {
+		return nil, "", &ParseError{source, 0, errors.New("no probe data read")}
 	}
-	v.Probes = probes
+	return probes, exclude, nil
 }
 
 func (v *VScan) parseProbesToMapKName(probes []Probe) {
@@ -252,59 +306,65 @@ func (v *VScan) parseProbesToMapKName(probes []Probe) {
 	v.ProbesMapKName = probesMap
 }
 
-func (p *Probe) getMatch(data string) (match Match, err error) {
-	match = Match{}
-
-	matchText := data[len("match")+1:]
-	directive := p.getDirectiveSyntax(matchText)
-
-	textSplited := strings.Split(directive.DirectiveStr, directive.Delimiter)
-
-	pattern, versionInfo := textSplited[0], strings.Join(textSplited[1:], "")
-
-	patternUnescaped, _ := DecodePattern(pattern)
-	patternUnescapedStr := string([]rune(string(patternUnescaped)))
-	patternCompiled, ok := regexp.Compile(patternUnescapedStr)
-	if ok != nil {
-		return match, ok
+func (v *VScan) groupProbesByProtocol(probes []Probe) {
+	var probesGrouped = map[string][]Probe{}
+	for _, probe := range probes {
+		protocol := strings.ToLower(probe.Protocol)
+		probesGrouped[protocol] = append(probesGrouped[protocol], probe)
 	}
+	for protocol, group := range probesGrouped {
+		probesGrouped[protocol] = sortProbesByRarity(group)
+	}
+	v.probesByProtocol = probesGrouped
+}
 
-	match.Service = directive.DirectiveName
-	match.Pattern = pattern
-	match.PatternCompiled = patternCompiled
-	match.VersionInfo = versionInfo
+// parseMatch 解析 "<service> m|pattern|flags versioninfo" 形式的规则
+func (p *Probe) parseMatch(matchText string, soft bool) (match Match, err error) {
+	match = Match{IsSoft: soft}
 
-	return match, nil
-}
+	directive, err := p.getDirectiveSyntax(matchText)
+	if err != nil {
+		return match, err
+	}
+	if directive.Flag != "m" {
+		return match, fmt.Errorf("pattern must begin with m, got %q", directive.Flag)
+	}
+	end := strings.Index(directive.DirectiveStr, directive.Delimiter)
+	if end < 0 {
+		return match, fmt.Errorf("pattern of %s is not terminated by %q", directive.DirectiveName, directive.Delimiter)
+	}
 
-func (p *Probe) getSoftMatch(data string) (softMatch Match, err error) {
-	softMatch = Match{IsSoft: true}
+	pattern, rest := directive.DirectiveStr[:end], directive.DirectiveStr[end+1:]
 
-	matchText := data[len("softmatch")+1:]
-	directive := p.getDirectiveSyntax(matchText)
+	// 结束分隔符后紧跟的 i、s 是正则选项，其余部分才是 versioninfo
+	flagsEnd := strings.IndexAny(rest, " \t")
+	if flagsEnd < 0 {
+		flagsEnd = len(rest)
+	}
+	match.Flags, match.VersionInfo = rest[:flagsEnd], strings.TrimSpace(rest[flagsEnd:])
+	if strings.Trim(match.Flags, "is") != "" {
+		return match, fmt.Errorf("unknown pattern flags %q", match.Flags)
+	}
 
-	textSplited := strings.Split(directive.DirectiveStr, directive.Delimiter)
+	match.versionFields, err = parseVersionTemplate(match.VersionInfo)
+	if err != nil {
+		return match, err
+	}
 
-	pattern, versionInfo := textSplited[0], strings.Join(textSplited[1:], "")
-	patternUnescaped, _ := DecodePattern(pattern)
-	patternUnescapedStr := string([]rune(string(patternUnescaped)))
-	patternCompiled, ok := regexp.Compile(patternUnescapedStr)
-	if ok != nil {
-		return softMatch, ok
+	match.PatternCompiled, err = compilePattern(pattern, match.Flags)
+	if err != nil {
+		return match, err
 	}
 
-	softMatch.Service = directive.DirectiveName
-	softMatch.Pattern = pattern
-	softMatch.PatternCompiled = patternCompiled
-	softMatch.VersionInfo = versionInfo
+	match.Service = directive.DirectiveName
+	match.Pattern = pattern
 
-	return softMatch, nil
+	return match, nil
 }
 
 func DecodePattern(s string) ([]byte, error) {
 	sByteOrigin := []byte(s)
-	matchRe := regexp.MustCompile(`\\(x[0-9a-fA-F]{2}|[0-7]{1,3}|[aftnrv])`)
-	sByteDec := matchRe.ReplaceAllFunc(sByteOrigin, func(match []byte) (v []byte) {
+	sByteDec := escapeCodeRe.ReplaceAllFunc(sByteOrigin, func(match []byte) (v []byte) {
 		var replace []byte
 		if isHexCode(match) {
 			hexNum := match[2:]
@@ -327,15 +387,18 @@ func DecodePattern(s string) ([]byte, error) {
 			replace = structCodeMap[int(match[1])]
 		}
 		if isOctalCode(match) {
-			octalNum := match[2:]
+			octalNum := match[1:]
 			byteNum, _ := strconv.ParseInt(string(octalNum), 8, 32)
-			replace = []byte{uint8(byteNum)}
+			if isReChar(byteNum) {
+				replace = []byte{'\\', uint8(byteNum)}
+			} else {
+				replace = []byte{uint8(byteNum)}
+			}
 		}
 		return replace
 	})
 
-	matchRe2 := regexp.MustCompile(`\\([^\\])`)
-	sByteDec2 := matchRe2.ReplaceAllFunc(sByteDec, func(match []byte) (v []byte) {
+	sByteDec2 := escapeCharRe.ReplaceAllFunc(sByteDec, func(match []byte) (v []byte) {
 		var replace []byte
 		if isOtherEscapeCode(match) {
 			replace = match
@@ -378,6 +441,9 @@ type Result struct {
 	Target
 	Service
 
+	State string        // 端口状态，仅 UDP 识别时填写：open、closed、open|filtered
+	RTT   time.Duration // 收到首个响应（含 ICMP 端口不可达）的耗时，仅 UDP 识别时填写
+
 	Error string
 }
 
@@ -395,176 +461,243 @@ type Extras struct {
 	Hostname        string
 	OperatingSystem string
 	DeviceType      string
-	CPE             string
+	CPE             string   // 首个应用类 CPE（不含 cpe:/ 前缀）
+	CPEs            []string // 规则给出的全部 CPE
 	Sign            string
 	StatusCode      int
 	ServiceURL      string
 }
 
-func (p *Probe) ContainsPort(testPort int) bool {
-	ports := strings.Split(p.Ports, ",")
-
-	for _, port := range ports {
-		cmpPort, _ := strconv.Atoi(port)
-		if testPort == cmpPort {
-			return true
+// portSpecContains 判断端口是否落在 "80,443,8000-8100" 形式的端口列表中
+func portSpecContains(spec string, testPort int) bool {
+	for _, port := range strings.Split(spec, ",") {
+		port = strings.TrimSpace(port)
+		if port == "" {
+			continue
 		}
-	}
-	for _, port := range ports {
 		if strings.Contains(port, "-") {
-			portRange := strings.Split(port, "-")
-			start, _ := strconv.Atoi(portRange[0])
-			end, _ := strconv.Atoi(portRange[1])
-			for cmpPort := start; cmpPort <= end; cmpPort++ {
-				if testPort == cmpPort {
-					return true
-				}
+			portRange := strings.SplitN(port, "-", 2)
+			start, errStart := strconv.Atoi(portRange[0])
+			end, errEnd := strconv.Atoi(portRange[1])
+			if errStart == nil && errEnd == nil && testPort >= start && testPort <= end {
+				return true
 			}
+			continue
+		}
+		if cmpPort, err := strconv.Atoi(port); err == nil && testPort == cmpPort {
+			return true
 		}
 	}
 	return false
 }
 
-func (v *VScan) Explore(addr string) (Result, error) {
-	var target Target
-	target.IP = strings.Split(addr, ":")[0]
-	portstr, err := strconv.Atoi(strings.Split(addr, ":")[1])
-	if err == nil {
-		target.Port = portstr
+func (e Extras) isEmpty() bool {
+	return e.VendorProduct == "" && e.Version == "" && e.Info == "" && e.Hostname == "" &&
+		e.OperatingSystem == "" && e.DeviceType == "" && len(e.CPEs) == 0 && e.CPE == "" &&
+		e.Sign == "" && e.StatusCode == 0 && e.ServiceURL == ""
+}
+
+func (p *Probe) ContainsPort(testPort int) bool {
+	return portSpecContains(p.Ports, testPort)
+}
+
+func (p *Probe) ContainsSSLPort(testPort int) bool {
+	return portSpecContains(p.SSLPorts, testPort)
+}
+
+// IsExcluded 判断端口是否被 Exclude 指令排除，格式如 "T:9100-9107,U:30000-40000,1-5"
+func (v *VScan) IsExcluded(port int, protocol string) bool {
+	var prefix string
+	switch strings.ToLower(protocol) {
+	case "tcp":
+		prefix = "T:"
+	case "udp":
+		prefix = "U:"
 	}
-	target.Protocol = "tcp"
-	var probesUsed []Probe
 
-	for _, probe := range v.Probes {
-		if strings.ToLower(probe.Protocol) == strings.ToLower(target.Protocol) {
-			probesUsed = append(probesUsed, probe)
+	// 未带协议前缀的端口同时作用于 TCP 与 UDP，前缀作用到下一个前缀出现为止
+	current := ""
+	for _, item := range strings.Split(v.Exclude, ",") {
+		item = strings.TrimSpace(item)
+		if len(item) > 2 && item[1] == ':' {
+			current = strings.ToUpper(item[:2])
+			item = item[2:]
+		}
+		if (current == "" || current == prefix) && portSpecContains(item, port) {
+			return true
 		}
 	}
+	return false
+}
 
-	probesUsed = append(probesUsed, v.ProbesMapKName["NULL"])
+// exploreConfig 单次服务识别的参数
+type exploreConfig struct {
+	intensity  int           // 版本探测强度 0-9，与 nmap --version-intensity 一致
+	probeWait  time.Duration // 探针未声明 totalwaitms 时的等待时间
+	timeout    time.Duration // 整次识别的时间上限，默认 10 秒，0 表示不限
+	tls        bool          // 探针经 TLS 发送
+	serverName string
+	deadline   time.Time
+
+	network     string        // tcp、tcp4、tcp6，UDP 探测时为 udp、udp4、udp6
+	sourceAddr  string        // 绑定的源地址，为空则由系统选择
+	dialTimeout time.Duration // 单次建连超时，0 表示只受探针等待时间限制
+}
 
-	probesUsed = sortProbesByRarity(probesUsed)
+// ExploreOption 服务识别选项
+type ExploreOption func(*exploreConfig)
 
-	var probesUsedFiltered []Probe
-	for _, probe := range probesUsed {
-		probesUsedFiltered = append(probesUsedFiltered, probe)
-	}
-	probesUsed = probesUsedFiltered
+func WithIntensity(n int) ExploreOption {
+	return func(c *exploreConfig) { c.intensity = n }
+}
 
-	result, err := v.scanWithProbes(target, &probesUsed)
+func WithProbeWait(d time.Duration) ExploreOption {
+	return func(c *exploreConfig) { c.probeWait = d }
+}
 
-	return result, err
+// WithScanTimeout 整次识别的时间上限，默认 10 秒，传 0 不限制
+func WithScanTimeout(d time.Duration) ExploreOption {
+	return func(c *exploreConfig) { c.timeout = d }
+}
+
+// WithNetwork 探针连接使用的网络：tcp、tcp4 或 tcp6，默认 tcp。
+// UDP 探测时 tcp4/tcp6 分别对应 udp4/udp6
+func WithNetwork(network string) ExploreOption {
+	return func(c *exploreConfig) { c.network = network }
+}
+
+// WithSourceAddr 探针连接绑定的源地址
+func WithSourceAddr(addr string) ExploreOption {
+	return func(c *exploreConfig) { c.sourceAddr = addr }
+}
+
+// WithDialTimeout 探针单次建连超时
+func WithDialTimeout(d time.Duration) ExploreOption {
+	return func(c *exploreConfig) { c.dialTimeout = d }
 }
 
-func (m *Match) MatchPattern(response []byte) (matched bool) {
-	responseStr := string([]rune(string(response)))
-	foundItems := m.PatternCompiled.FindStringSubmatch(responseStr)
-	if len(foundItems) > 0 {
-		matched = true
-		return
+// WithTLSTunnel 目标端口本身是 TLS 端口，直接在 TLS 之上发送探针
+func WithTLSTunnel(serverName string) ExploreOption {
+	return func(c *exploreConfig) {
+		c.tls = true
+		c.serverName = serverName
 	}
-	return false
 }
 
-func (m *Match) ParseVersionInfo(response []byte) Extras {
-	var extras = Extras{}
-
-	responseStr := string([]rune(string(response)))
-	foundItems := m.PatternCompiled.FindStringSubmatch(responseStr)
-
-	versionInfo := m.VersionInfo
-	foundItems = foundItems[1:]
-	for index, value := range foundItems {
-		dollarName := "$" + strconv.Itoa(index+1)
-		versionInfo = strings.Replace(versionInfo, dollarName, value, -1)
-	}
-
-	v := versionInfo
-	if strings.Contains(v, " p/") {
-		regex := regexp.MustCompile(`p/([^/]*)/`)
-		vendorProductName := regex.FindStringSubmatch(v)
-		extras.VendorProduct = vendorProductName[1]
-	}
-	if strings.Contains(v, " p|") {
-		regex := regexp.MustCompile(`p|([^|]*)|`)
-		vendorProductName := regex.FindStringSubmatch(v)
-		extras.VendorProduct = vendorProductName[1]
-	}
-	if strings.Contains(v, " v/") {
-		regex := regexp.MustCompile(`v/([^/]*)/`)
-		version := regex.FindStringSubmatch(v)
-		extras.Version = version[1]
-	}
-	if strings.Contains(v, " v|") {
-		regex := regexp.MustCompile(`v|([^|]*)|`)
-		version := regex.FindStringSubmatch(v)
-		extras.Version = version[1]
-	}
-	if strings.Contains(v, " i/") {
-		regex := regexp.MustCompile(`i/([^/]*)/`)
-		info := regex.FindStringSubmatch(v)
-		extras.Info = info[1]
-	}
-	if strings.Contains(v, " i|") {
-		regex := regexp.MustCompile(`i|([^|]*)|`)
-		info := regex.FindStringSubmatch(v)
-		extras.Info = info[1]
-	}
-	if strings.Contains(v, " h/") {
-		regex := regexp.MustCompile(`h/([^/]*)/`)
-		hostname := regex.FindStringSubmatch(v)
-		extras.Hostname = hostname[1]
-	}
-	if strings.Contains(v, " h|") {
-		regex := regexp.MustCompile(`h|([^|]*)|`)
-		hostname := regex.FindStringSubmatch(v)
-		extras.Hostname = hostname[1]
-	}
-	if strings.Contains(v, " o/") {
-		regex := regexp.MustCompile(`o/([^/]*)/`)
-		operatingSystem := regex.FindStringSubmatch(v)
-		extras.OperatingSystem = operatingSystem[1]
-	}
-	if strings.Contains(v, " o|") {
-		regex := regexp.MustCompile(`o|([^|]*)|`)
-		operatingSystem := regex.FindStringSubmatch(v)
-		extras.OperatingSystem = operatingSystem[1]
-	}
-	if strings.Contains(v, " d/") {
-		regex := regexp.MustCompile(`d/([^/]*)/`)
-		deviceType := regex.FindStringSubmatch(v)
-		extras.DeviceType = deviceType[1]
-	}
-	if strings.Contains(v, " d|") {
-		regex := regexp.MustCompile(`d|([^|]*)|`)
-		deviceType := regex.FindStringSubmatch(v)
-		extras.DeviceType = deviceType[1]
-	}
-	if strings.Contains(v, " cpe:/") {
-		regex := regexp.MustCompile(`cpe:/([^/]*)/`)
-		cpeName := regex.FindStringSubmatch(v)
-		if len(cpeName) > 1 {
-			extras.CPE = cpeName[1]
-		} else {
-			extras.CPE = cpeName[0]
+// newExploreConfig 生成默认参数并应用选项，设置了总时长时从此刻开始计算截止时间
+func newExploreConfig(opts ...ExploreOption) *exploreConfig {
+	cfg := &exploreConfig{
+		intensity: 7,
+		probeWait: 2 * time.Second,
+		timeout:   10 * time.Second,
+		network:   "tcp",
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	if cfg.timeout > 0 {
+		cfg.deadline = time.Now().Add(cfg.timeout)
+	}
+	return cfg
+}
+
+// bounded 把 d 限制在整体截止时间之内，已超时返回值不大于 0
+func (c *exploreConfig) bounded(d time.Duration) time.Duration {
+	if !c.deadline.IsZero() {
+		if remain := time.Until(c.deadline); remain < d {
+			return remain
 		}
 	}
-	if strings.Contains(v, " cpe:|") {
-		regex := regexp.MustCompile(`cpe:|([^|]*)|`)
-		cpeName := regex.FindStringSubmatch(v)
-		if len(cpeName) > 1 {
-			extras.CPE = cpeName[1]
+	return d
+}
+
+// dialer 按建连设置创建 Dialer，连接不晚于 deadline 建立
+func (c *exploreConfig) dialer(deadline time.Time) (*net.Dialer, error) {
+	dialer := &net.Dialer{Timeout: c.dialTimeout, Deadline: deadline}
+	if c.sourceAddr != "" {
+		ip := net.ParseIP(c.sourceAddr)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid source address: %s", c.sourceAddr)
+		}
+		if strings.HasPrefix(c.network, "udp") {
+			dialer.LocalAddr = &net.UDPAddr{IP: ip}
 		} else {
-			extras.CPE = cpeName[0]
+			dialer.LocalAddr = &net.TCPAddr{IP: ip}
+		}
+	}
+	return dialer, nil
+}
+
+// waitFor 计算某个探针的等待时间，不超过整体截止时间
+func (c *exploreConfig) waitFor(probe Probe) time.Duration {
+	wait := c.probeWait
+	if probe.TotalWaitMS > 0 {
+		wait = time.Duration(probe.TotalWaitMS) * time.Millisecond
+	}
+	return c.bounded(wait)
+}
+
+// selectProbes 按 nmap 的顺序挑选探针：NULL 最先，其次是端口匹配的探针，
+// 最后是 rarity 不超过强度的其余探针。TLS 隧道内按 sslports 判断端口匹配。
+func (v *VScan) selectProbes(target Target, cfg *exploreConfig) []Probe {
+	var nullProbes, portMatched, others []Probe
+
+	for _, probe := range v.probesByProtocol[target.Protocol] {
+		if cfg.tls && (probe.Name == "SSLSessionReq" || probe.Name == "TLSSessionReq") {
+			continue
 		}
+
+		matched := probe.ContainsPort(target.Port)
+		if cfg.tls {
+			matched = probe.ContainsSSLPort(target.Port)
+		}
+
+		switch {
+		case probe.Name == "NULL":
+			nullProbes = append(nullProbes, probe)
+		case matched:
+			portMatched = append(portMatched, probe)
+		case probe.Rarity <= cfg.intensity:
+			others = append(others, probe)
+		}
+	}
+
+	probes := append(nullProbes, portMatched...)
+	return append(probes, others...)
+}
+
+func (v *VScan) Explore(addr string, opts ...ExploreOption) (Result, error) {
+	var target Target
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return Result{}, err
+	}
+	target.IP = host
+	target.Port, err = strconv.Atoi(port)
+	if err != nil {
+		return Result{}, err
+	}
+	target.Protocol = "tcp"
+
+	cfg := newExploreConfig(opts...)
+	if _, err := cfg.dialer(time.Time{}); err != nil {
+		return Result{Target: target}, err
 	}
-	return extras
+
+	if v.IsExcluded(target.Port, target.Protocol) {
+		return Result{Target: target}, nil
+	}
+
+	probesUsed := v.selectProbes(target, cfg)
+
+	result, err := v.scanWithProbes(target, &probesUsed, cfg)
+
+	return result, err
 }
 
 func DecodeData(s string) ([]byte, error) {
 	sByteOrigin := []byte(s)
-	matchRe := regexp.MustCompile(`\\(x[0-9a-fA-F]{2}|[0-7]{1,3}|[aftnrv])`)
-	sByteDec := matchRe.ReplaceAllFunc(sByteOrigin, func(match []byte) (v []byte) {
+	sByteDec := escapeCodeRe.ReplaceAllFunc(sByteOrigin, func(match []byte) (v []byte) {
 		var replace []byte
 		if isHexCode(match) {
 			hexNum := match[2:]
@@ -583,15 +716,14 @@ func DecodeData(s string) ([]byte, error) {
 			replace = structCodeMap[int(match[1])]
 		}
 		if isOctalCode(match) {
-			octalNum := match[2:]
+			octalNum := match[1:]
 			byteNum, _ := strconv.ParseInt(string(octalNum), 8, 32)
 			replace = []byte{uint8(byteNum)}
 		}
 		return replace
 	})
 
-	matchRe2 := regexp.MustCompile(`\\([^\\])`)
-	sByteDec2 := matchRe2.ReplaceAllFunc(sByteDec, func(match []byte) (v []byte) {
+	sByteDec2 := escapeCharRe.ReplaceAllFunc(sByteDec, func(match []byte) (v []byte) {
 		var replace []byte
 		if isOtherEscapeCode(match) {
 			replace = match
@@ -604,7 +736,7 @@ func DecodeData(s string) ([]byte, error) {
 }
 
 func (t *Target) GetAddress() string {
-	return t.IP + ":" + strconv.Itoa(t.Port)
+	return net.JoinHostPort(t.IP, strconv.Itoa(t.Port))
 }
 
 func trimBanner(buf []byte) string {
@@ -671,15 +803,6 @@ func trimHtml(src string) string {
 	return strings.TrimSpace(src)
 }
 
-func ConvertToString(src string, srcCode string, tagCode string) string {
-	srcCoder := mahonia.NewDecoder(srcCode)
-	srcResult := srcCoder.ConvertString(src)
-	tagCoder := mahonia.NewDecoder(tagCode)
-	_, cdata, _ := tagCoder.Translate([]byte(srcResult), true)
-	result := string(cdata)
-	return result
-}
-
 type HttpInfo struct {
 	ServiceURL   string
 	StatusCode   int
@@ -687,12 +810,17 @@ type HttpInfo struct {
 	ServerSign   string
 }
 
-func getHttpBanner(url string) (statsu bool, res HttpInfo) {
+func getHttpBanner(url string, timeout time.Duration, cfg *exploreConfig) (statsu bool, res HttpInfo) {
 	var tag HttpInfo
+	dialer, err := cfg.dialer(time.Time{})
+	if err != nil {
+		return false, tag
+	}
+	dialer.Timeout = min(2*time.Second, timeout)
 	transport := &http.Transport{
-		DialContext: (&net.Dialer{
-			Timeout: time.Duration(2) * time.Second,
-		}).DialContext,
+		DialContext: func(ctx context.Context, _, addr string) (net.Conn, error) {
+			return dialer.DialContext(ctx, cfg.network, addr)
+		},
 		TLSClientConfig: &tls.Config{
 			InsecureSkipVerify: true,
 		},
@@ -700,7 +828,7 @@ func getHttpBanner(url string) (statsu bool, res HttpInfo) {
 
 	client := &http.Client{
 		Transport: transport,
-		Timeout:   3 * time.Second,
+		Timeout:   timeout,
 	}
 	resp, err := client.Get(url)
 	if err != nil {
@@ -717,253 +845,290 @@ func getHttpBanner(url string) (statsu bool, res HttpInfo) {
 	tag.StatusCode = resp.StatusCode
 	tag.ServiceURL = url
 
-	if strings.Contains(string(resp.Header.Get("Content-Type")), "2312") {
-		tag.ServerBanner = trimHtml(ConvertToString(string(content), "gbk", "utf-8"))
-	} else {
-		tag.ServerBanner = trimHtml(string(content))
-	}
+	text, _ := charset.Decode(content, resp.Header.Get("Content-Type"))
+	tag.ServerBanner = trimHtml(text)
 	return true, tag
 }
 
-func (v *VScan) scanWithProbes(target Target, probes *[]Probe) (Result, error) {
+// isHttpsPort 常见的 HTTPS 端口
+func isHttpsPort(port int) bool {
+	switch port {
+	case 443, 2443, 3443, 4443, 5443, 6443, 7443, 8443, 9443, 4430:
+		return true
+	}
+	return false
+}
+
+// fillHttpInfo 对识别为 HTTP 的服务补充抓取首页信息，最多 3 秒且不超过整体截止时间
+func fillHttpInfo(result *Result, https bool, cfg *exploreConfig) bool {
+	url := "http://" + result.Target.GetAddress()
+	if https {
+		url = "https://" + result.Target.GetAddress()
+	}
+	result.Service.Extras.ServiceURL = url
+
+	timeout := cfg.bounded(3 * time.Second)
+	if timeout <= 0 {
+		return false
+	}
+	status, tag := getHttpBanner(url, timeout, cfg)
+	if !status {
+		return false
+	}
+	result.Banner = tag.ServerBanner
+	result.Service.Extras.Sign = tag.ServerSign
+	result.Service.Extras.StatusCode = tag.StatusCode
+	result.Service.Extras.ServiceURL = tag.ServiceURL
+	return true
+}
+
+// findMatch 在探针及其 fallback 探针的规则中查找匹配，硬匹配优先，其次返回第一个软匹配
+func (v *VScan) findMatch(probe Probe, response []byte) (*Match, bool) {
+	var softMatch *Match
+
+	probesToMatch := []Probe{probe}
+	for _, fallback := range strings.Split(probe.Fallback, ",") {
+		if fbProbe, ok := v.ProbesMapKName[strings.TrimSpace(fallback)]; ok {
+			probesToMatch = append(probesToMatch, fbProbe)
+		}
+	}
+
+	// 响应只转换一次，探针的每条规则都在同一份文本上匹配
+	text := latin1(response)
+	for _, p := range probesToMatch {
+		for i := range *p.Matchs {
+			match := &(*p.Matchs)[i]
+			if !match.matchText(text) {
+				continue
+			}
+			if !match.IsSoft {
+				return match, true
+			}
+			if softMatch == nil {
+				softMatch = match
+			}
+		}
+	}
+	return softMatch, softMatch != nil
+}
+
+// buildResult 根据匹配到的规则组装结果
+func buildResult(target Target, match *Match, response []byte, cfg *exploreConfig) Result {
 	var result = Result{Target: target}
-	retry := 0
-	allowRetry := 5
+	extras := match.ParseVersionInfo(response)
+	result.Service.Name = match.Service
+	result.Service.Extras = extras
+
+	switch {
+	case match.Service == "http" && !match.IsSoft:
+		if !fillHttpInfo(&result, cfg.tls || isHttpsPort(target.Port), cfg) {
+			result.Banner = trimBanner(response)
+		}
+	case (match.Service == "ssl/http" || match.Service == "ssl-ms-rdp") && (isHttpsPort(target.Port) || (target.Port >= 80 && target.Port <= 99) || (target.Port >= 7000 && target.Port <= 9999)):
+		if !fillHttpInfo(&result, true, cfg) {
+			result.Banner = trimBanner(response)
+		}
+	default:
+		result.Banner = trimBanner(response)
+	}
 
-	for _, probe := range *probes {
-		var response []byte
-		retry++
+	if cfg.tls {
+		result.Service.Name = "ssl/" + result.Service.Name
+	}
+	return result
+}
+
+// exploreTLS 识别出 ssl 后，在 TLS 之上重新探测隧道内的服务
+func (v *VScan) exploreTLS(target Target, cfg *exploreConfig) (Result, bool) {
+	tlsCfg := *cfg
+	tlsCfg.tls = true
+	if tlsCfg.serverName == "" && net.ParseIP(target.IP) == nil {
+		tlsCfg.serverName = target.IP
+	}
 
-		probeData, _ := DecodeData(probe.Data)
+	probes := v.selectProbes(target, &tlsCfg)
+	result, err := v.scanWithProbes(target, &probes, &tlsCfg)
+	if err != nil || result.Service.Name == "" || result.Service.Name == "ssl/unknown" {
+		return result, false
+	}
+	return result, true
+}
 
-		addr := target.GetAddress()
+func (v *VScan) scanWithProbes(target Target, probes *[]Probe, cfg *exploreConfig) (Result, error) {
+	var result = Result{Target: target}
 
-		response, _ = grabResponse(addr, probeData)
+	var softMatch *Match
+	var softResponse, firstResponse []byte
 
-		if retry > allowRetry {
+	for _, probe := range *probes {
+		wait := cfg.waitFor(probe)
+		if wait <= 0 {
 			break
 		}
+
+		grab := grabResponse(target.GetAddress(), probe.payload, wait, cfg)
+
+		// NULL 探针没拿到数据且连接很快被对端关闭，视为 tcpwrapped
+		if probe.Name == "NULL" && len(grab.response) == 0 && grab.closedAfter > 0 &&
+			probe.TCPWrappedMS > 0 && grab.closedAfter < time.Duration(probe.TCPWrappedMS)*time.Millisecond {
+			result.Service.Name = "tcpwrapped"
+			return result, nil
+		}
+
+		response := grab.response
 		if len(response) == 0 {
 			continue
 		}
-		if len(response) > 0 {
-			found := false
-
-			softFound := false
-			var softMatch Match
-
-			for _, match := range *probe.Matchs {
-				matched := match.MatchPattern(response)
-				if matched && !match.IsSoft {
-					extras := match.ParseVersionInfo(response)
-					result.Service.Name = match.Service
-					if match.Service == "http" {
-						if target.Port == 443 || target.Port == 2443 || target.Port == 3443 || target.Port == 4443 || target.Port == 5443 || target.Port == 6443 || target.Port == 7443 || target.Port == 8443 || target.Port == 9443 || target.Port == 4430 {
-							url := "https://" + target.GetAddress()
-							status, tag := getHttpBanner(url)
-							if status {
-								result.Banner = tag.ServerBanner
-								result.Service.Extras = extras
-								result.Service.Extras.Sign = tag.ServerSign
-								result.Service.Extras.StatusCode = tag.StatusCode
-								result.Service.Extras.ServiceURL = tag.ServiceURL
-							} else {
-								result.Service.Extras = extras
-								result.Service.Extras.ServiceURL = url
-							}
-						} else {
-							url := "http://" + target.GetAddress()
-							status, tag := getHttpBanner(url)
-							if status {
-								result.Banner = tag.ServerBanner
-								result.Service.Extras = extras
-								result.Service.Extras.Sign = tag.ServerSign
-								result.Service.Extras.StatusCode = tag.StatusCode
-								result.Service.Extras.ServiceURL = tag.ServiceURL
-							} else {
-								result.Service.Extras = extras
-								result.Service.Extras.ServiceURL = url
-							}
-						}
-					} else if (match.Service == "ssl" || match.Service == "ssl/http" || match.Service == "ssl-ms-rdp") && (target.Port == 443 || target.Port == 2443 || target.Port == 3443 || target.Port == 4443 || target.Port == 5443 || target.Port == 6443 || target.Port == 4430 || (target.Port >= 80 && target.Port <= 99) || (target.Port >= 7000 && target.Port <= 9999)) {
-						url := "https://" + target.GetAddress()
-						status, tag := getHttpBanner(url)
-						if status {
-							result.Banner = tag.ServerBanner
-							result.Service.Extras = extras
-							result.Service.Extras.Sign = tag.ServerSign
-							result.Service.Extras.StatusCode = tag.StatusCode
-							result.Service.Extras.ServiceURL = tag.ServiceURL
-						} else {
-							result.Service.Extras = extras
-							result.Service.Extras.ServiceURL = url
-						}
-					} else {
-						result.Banner = trimBanner(response)
-						result.Service.Extras = extras
-					}
-					found = true
-					return result, nil
-				} else if matched && match.IsSoft && !softFound {
-					softFound = true
-					softMatch = match
-				}
-			}
+		if firstResponse == nil {
+			firstResponse = response
+		}
 
-			fallback := probe.Fallback
-			if _, ok := v.ProbesMapKName[fallback]; ok {
-				fbProbe := v.ProbesMapKName[fallback]
-				for _, match := range *fbProbe.Matchs {
-					matched := match.MatchPattern(response)
-					if matched && !match.IsSoft {
-						extras := match.ParseVersionInfo(response)
-						result.Service.Name = match.Service
-						if match.Service == "http" {
-							if target.Port == 443 || target.Port == 2443 || target.Port == 3443 || target.Port == 4443 || target.Port == 5443 || target.Port == 6443 || target.Port == 7443 || target.Port == 8443 || target.Port == 9443 || target.Port == 4430 {
-								url := "https://" + target.GetAddress()
-								status, tag := getHttpBanner(url)
-								result.Service.Extras.ServiceURL = tag.ServiceURL
-								if status {
-									result.Banner = tag.ServerBanner
-									result.Service.Extras.Sign = tag.ServerSign
-									result.Service.Extras.StatusCode = tag.StatusCode
-								} else {
-									result.Banner = trimBanner(response)
-									result.Service.Extras = extras
-								}
-							} else {
-								url := "http://" + target.GetAddress()
-								status, tag := getHttpBanner(url)
-								result.Service.Extras.ServiceURL = tag.ServiceURL
-								if status {
-									result.Banner = tag.ServerBanner
-									result.Service.Extras.Sign = tag.ServerSign
-									result.Service.Extras.StatusCode = tag.StatusCode
-								} else {
-									result.Banner = trimBanner(response)
-									result.Service.Extras = extras
-								}
-							}
-						} else {
-							result.Banner = trimBanner(response)
-							result.Service.Extras = extras
-						}
-						found = true
-						return result, nil
-					} else if matched && match.IsSoft && !softFound {
-						softFound = true
-						softMatch = match
-					}
-				}
+		match, ok := v.findMatch(probe, response)
+		if !ok {
+			continue
+		}
+		if match.IsSoft {
+			if softMatch == nil {
+				softMatch = match
+				softResponse = response
 			}
-			if !found {
-				if !softFound {
-
-					result.Banner = trimBanner(response)
-
-					if strings.Contains(result.Banner, "HTTP/") {
-						result.Service.Name = "http"
-					} else if strings.Contains(result.Banner, "html") {
-						result.Service.Name = "http"
-					} else {
-						result.Service.Name = "unknown"
-					}
+			continue
+		}
 
-					if result.Service.Name == "http" {
-						if target.Port == 443 || target.Port == 2443 || target.Port == 3443 || target.Port == 4443 || target.Port == 5443 || target.Port == 6443 || target.Port == 7443 || target.Port == 8443 || target.Port == 9443 || target.Port == 4430 {
-							url := "https://" + target.GetAddress()
-							status, tag := getHttpBanner(url)
-							result.Service.Extras.ServiceURL = tag.ServiceURL
-							if status {
-								result.Banner = tag.ServerBanner
-								result.Service.Extras.Sign = tag.ServerSign
-								result.Service.Extras.StatusCode = tag.StatusCode
-							}
-						} else {
-							url := "http://" + target.GetAddress()
-							status, tag := getHttpBanner(url)
-							result.Service.Extras.ServiceURL = tag.ServiceURL
-							if status {
-								result.Banner = tag.ServerBanner
-								result.Service.Extras.Sign = tag.ServerSign
-								result.Service.Extras.StatusCode = tag.StatusCode
-							}
-						}
-					}
+		if match.Service == "ssl" && !cfg.tls {
+			if tlsResult, ok := v.exploreTLS(target, cfg); ok {
+				return tlsResult, nil
+			}
+		}
+		return buildResult(target, match, response, cfg), nil
+	}
 
-					return result, nil
-				} else {
+	if softMatch != nil {
+		return buildResult(target, softMatch, softResponse, cfg), nil
+	}
 
-					result.Banner = trimBanner(response)
+	if firstResponse != nil {
+		result.Banner = trimBanner(firstResponse)
 
-					extras := softMatch.ParseVersionInfo(response)
-					result.Service.Extras = extras
-					result.Service.Name = softMatch.Service
+		if strings.Contains(result.Banner, "HTTP/") {
+			result.Service.Name = "http"
+		} else if strings.Contains(result.Banner, "html") {
+			result.Service.Name = "http"
+		} else {
+			result.Service.Name = "unknown"
+		}
 
-					return result, nil
-				}
-			}
+		if result.Service.Name == "http" {
+			fillHttpInfo(&result, cfg.tls || isHttpsPort(target.Port), cfg)
+		}
+		if cfg.tls {
+			result.Service.Name = "ssl/" + result.Service.Name
 		}
 	}
 	return result, nil
 }
 
-func grabResponse(addr string, data []byte) ([]byte, error) {
-	var response []byte
+// grabResult 单次探测的响应
+type grabResult struct {
+	response    []byte
+	closedAfter time.Duration // 对端主动关闭连接所用的时间，未关闭为 0
+	err         error
+}
+
+// readIdleGap 收到首段响应后继续等待后续数据的时间
+const readIdleGap = 500 * time.Millisecond
 
-	dialer := net.Dialer{}
+// grabResponse 建立连接、发送探针并在 wait 时间内收集响应
+func grabResponse(addr string, data []byte, wait time.Duration, cfg *exploreConfig) grabResult {
+	var result grabResult
+	start := time.Now()
+	deadline := start.Add(wait)
 
-	conn, errConn := dialer.Dial("tcp", addr)
-	if errConn != nil {
-		return response, errConn
+	dialer, err := cfg.dialer(deadline)
+	if err != nil {
+		result.err = err
+		return result
+	}
+
+	var conn net.Conn
+	conn, result.err = dialer.Dial(cfg.network, addr)
+	if result.err != nil {
+		return result
 	}
 	defer conn.Close()
 
-	if len(data) > 0 {
-		conn.SetWriteDeadline(time.Now().Add(time.Second * 2))
-		_, errWrite := conn.Write(data)
-		if errWrite != nil {
-			return response, errWrite
+	if err := conn.SetDeadline(deadline); err != nil {
+		result.err = err
+		return result
+	}
+
+	if cfg.tls {
+		tlsConn := tls.Client(conn, &tls.Config{
+			ServerName:         cfg.serverName,
+			InsecureSkipVerify: true,
+		})
+		if result.err = tlsConn.Handshake(); result.err != nil {
+			return result
 		}
+		conn = tlsConn
 	}
 
-	if err := conn.SetReadDeadline(time.Now().Add(time.Second * 2)); err != nil {
-		return response, err
+	start = time.Now()
+	if len(data) > 0 {
+		if _, result.err = conn.Write(data); result.err != nil {
+			return result
+		}
 	}
 
+	buff := make([]byte, 1024)
 	for {
-		buff := make([]byte, 1024)
 		n, errRead := conn.Read(buff)
 		if n > 0 {
-			response = append(response, buff[:n]...)
-		} else {
-			break
+			result.response = append(result.response, buff[:n]...)
+			// 已收到数据后只再等一小段空闲时间，不必耗满整个 totalwaitms
+			if idle := time.Now().Add(readIdleGap); idle.Before(deadline) {
+				deadline = idle
+				conn.SetReadDeadline(deadline)
+			}
 		}
 		if errRead != nil {
-			if len(response) > 0 {
-				break
-			} else {
-				return response, errRead
+			if errors.Is(errRead, io.EOF) {
+				result.closedAfter = time.Since(start)
+			} else if len(result.response) == 0 {
+				result.err = errRead
 			}
+			break
 		}
 	}
-	return response, nil
+	return result
 }
 
-func (v *VScan) Tagetsacn(targetIP string) string {
+// Describe 将识别结果拼接为一行便于展示的描述
+func (result Result) Describe() string {
 	var info string
-	result, err := v.Explore(targetIP)
-	//if result =
-	//mutex.Lock()
-	if err == nil {
-		if result.Service.Name == "http" {
-			banner := result.Service.Banner
-			if len(banner) > 30 {
-				banner = banner[:30] + "..."
-			}
-			info = banner
+	if result.Service.Name == "http" {
+		info = charset.Truncate(result.Service.Banner, 30)
+		if result.Service.Extras.Version != "" {
+			info = result.Service.Extras.Version + " - " + info
+		}
+		if result.Service.Extras.VendorProduct != "" {
+			info = result.Service.Extras.VendorProduct + " - " + info
+		}
+		if result.Service.Extras.Sign != "" {
+			info = result.Service.Extras.Sign + " - " + info
+		}
+	} else if result.Service.Name == "microsoft-ds" && (strings.Contains(result.Service.Banner, "hostname") || strings.Contains(result.Service.Banner, "domain")) {
+		info = result.Service.Extras.VendorProduct + " - " + result.Service.Name + " - " + result.Service.Banner
+	} else if result.Service.Name == "ssl-ms-rdp" {
+		info = result.Service.Name
+	} else {
+		info = result.Service.Name
+		if result.Service.Banner != "" && result.Service.Banner != "." && result.Service.Banner != ".@." {
+			info = result.Service.Name + " - " + result.Service.Banner
+		}
+		if !result.Service.Extras.isEmpty() {
 			if result.Service.Extras.Version != "" {
-				info = result.Service.Extras.Version + " - " + info
+				info = info + " - " + result.Service.Extras.Version
 			}
 			if result.Service.Extras.VendorProduct != "" {
 				info = result.Service.Extras.VendorProduct + " - " + info
@@ -971,54 +1136,86 @@ func (v *VScan) Tagetsacn(targetIP string) string {
 			if result.Service.Extras.Sign != "" {
 				info = result.Service.Extras.Sign + " - " + info
 			}
-		} else if result.Service.Name == "microsoft-ds" && (strings.Contains(result.Service.Banner, "hostname") || strings.Contains(result.Service.Banner, "domain")) {
-			info = result.Service.Extras.VendorProduct + " - " + result.Service.Name + " - " + result.Service.Banner
-		} else if result.Service.Name == "ssl-ms-rdp" {
-			info = result.Service.Name
-		} else {
-			info = result.Service.Name
-			if result.Service.Banner != "" && result.Service.Banner != "." && result.Service.Banner != ".@." {
-				info = result.Service.Name + " - " + result.Service.Banner
-			}
-			if result.Service.Extras != (Extras{}) {
-				if result.Service.Extras.Version != "" {
-					info = info + " - " + result.Service.Extras.Version
-				}
-				if result.Service.Extras.VendorProduct != "" {
-					info = result.Service.Extras.VendorProduct + " - " + info
-				}
-				if result.Service.Extras.Sign != "" {
-					info = result.Service.Extras.Sign + " - " + info
-				}
-			}
-		}
-		if info == "" {
-			info = "unknown"
 		}
+	}
+	if info == "" {
+		info = "unknown"
+	}
+	return info
+}
 
+func (v *VScan) Tagetsacn(targetIP string, opts ...ExploreOption) string {
+	var info string
+	result, err := v.Explore(targetIP, opts...)
+	if err == nil {
+		info = result.Describe()
 		v.logs.Alert("%s:%d (%s)", result.IP, result.Port, info)
-
 	}
 
 	return info
 
 }
 
-func (v *VScan) Init() {
-	proberContent := bytes.NewReader(proberbyte.GetProber())
-	proberReader, _ := gzip.NewReader(proberContent)
-	proberStr, _ := ioutil.ReadAll(proberReader)
+// builtinProbes 解压内置的 nmap-service-probes
+func builtinProbes() (string, error) {
+	proberReader, err := gzip.NewReader(bytes.NewReader(proberbyte.GetProber()))
+	if err != nil {
+		return "", err
+	}
+	proberStr, err := ioutil.ReadAll(proberReader)
+	if err != nil {
+		return "", err
+	}
+	return string(proberStr), nil
+}
 
-	v.logs = logger.NewLogger(1)
-	v.logs.Modular = "serviceScanLib"
+// Init 加载内置探针库
+func (v *VScan) Init() error {
+	return v.load(loadOptions{})
+}
+
+var (
+	sharedVScan     *VScan
+	sharedVScanErr  error
+	sharedVScanOnce sync.Once
+)
 
-	v.parseProbesFromContent(string(proberStr))
-	v.parseProbesToMapKName(v.Probes)
+// Shared 返回进程内共享的探针库，首次调用时才解压并解析探针文件（内置库或 Configure 指定的文件）。
+// 返回的 VScan 初始化后只读，可被多个 TCP 监控并发使用；加载失败时每次调用都返回同一个错误。
+func Shared() (*VScan, error) {
+	sharedVScanOnce.Do(func() {
+		v := &VScan{}
+		if err := v.Init(); err != nil {
+			sharedVScanErr = fmt.Errorf("load builtin probes failed: %w", err)
+			return
+		}
+		sharedVScan = v
+	})
+	return sharedVScan, sharedVScanErr
 }
 
-func GetProbes(aliveHosts string) string {
-	v := VScan{}
-	v.Init()
+// Configure 使用自定义探针文件初始化共享探针库，须在第一次扫描前调用
+func Configure(opts ...Option) error {
+	v, err := NewVScan(opts...)
+	if err != nil {
+		return err
+	}
 
-	return v.Tagetsacn(aliveHosts)
+	configured := false
+	sharedVScanOnce.Do(func() {
+		sharedVScan = v
+		configured = true
+	})
+	if !configured {
+		return errors.New("shared probe database already initialised")
+	}
+	return nil
+}
+
+func GetProbes(aliveHosts string, opts ...ExploreOption) string {
+	v, err := Shared()
+	if err != nil {
+		return ""
+	}
+	return v.Tagetsacn(aliveHosts, opts...)
 }
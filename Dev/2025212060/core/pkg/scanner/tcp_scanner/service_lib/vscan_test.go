@@ -0,0 +1,325 @@
+package vscan
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+const sampleProbes = `# sample
+Exclude T:9100-9107
+Probe TCP NULL q||
+totalwaitms 6000
+tcpwrappedms 3000
+match ssh m|^SSH-([\d.]+)-OpenSSH_([\w._-]+)[ -]|s p/OpenSSH/ v/$2/ i/protocol $1/ cpe:/a:openbsd:openssh:$2/a
+softmatch ftp m|^220[- ]| p/generic ftp/
+Probe TCP GetRequest q|GET / HTTP/1.0\r\n\r\n|
+rarity 1
+ports 80,8080
+sslports 443
+fallback NULL
+match http m|^HTTP/1\.[01] \d\d\d .*\r\nServer: nginx/([\d.]+)|s p/nginx/ v/$1/
+`
+
+func TestParseProbesFromContent(t *testing.T) {
+	probes, exclude, err := parseProbesFromContent(sampleProbes, "sample", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exclude != "T:9100-9107" {
+		t.Errorf("exclude = %q", exclude)
+	}
+	if len(probes) != 2 {
+		t.Fatalf("got %d probes, want 2", len(probes))
+	}
+	null, get := probes[0], probes[1]
+	if null.TotalWaitMS != 6000 || len(*null.Matchs) != 2 || !(*null.Matchs)[1].IsSoft {
+		t.Errorf("NULL probe parsed wrong: %+v", null)
+	}
+	if get.Rarity != 1 || get.Ports != "80,8080" || get.SSLPorts != "443" || get.Fallback != "NULL" {
+		t.Errorf("GetRequest probe parsed wrong: %+v", get)
+	}
+	if string(get.payload) != "GET / HTTP/1.0\r\n\r\n" {
+		t.Errorf("payload = %q", get.payload)
+	}
+
+	extras := (*null.Matchs)[0].ParseVersionInfo([]byte("SSH-2.0-OpenSSH_8.9p1 Ubuntu-3\r\n"))
+	if extras.VendorProduct != "OpenSSH" || extras.Version != "8.9p1" || extras.Info != "protocol 2.0" || extras.CPE != "a:openbsd:openssh:8.9p1" {
+		t.Errorf("ParseVersionInfo = %+v", extras)
+	}
+}
+
+func TestParseBuiltinProbes(t *testing.T) {
+	content, err := builtinProbes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	probes, _, err := parseProbesFromContent(content, "builtin", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(probes) < 100 {
+		t.Errorf("only %d builtin probes parsed", len(probes))
+	}
+}
+
+func FuzzParseProbesFromContent(f *testing.F) {
+	f.Add(sampleProbes)
+	f.Add("Probe TCP NULL q||\nmatch x m|a| d\xfd\xeb\n")
+	f.Add("Probe UDP DNS q|\\x00\\x01|\nrarity 10\n")
+	f.Add("Exclude 1\nExclude 2\n")
+	f.Fuzz(func(t *testing.T, content string) {
+		for _, strict := range []bool{true, false} {
+			probes, _, err := parseProbesFromContent(content, "fuzz", strict)
+			if err != nil {
+				var parseErr *ParseError
+				if !errors.As(err, &parseErr) {
+					t.Fatalf("error is not a *ParseError: %v", err)
+				}
+				continue
+			}
+			for _, probe := range probes {
+				if probe.Matchs == nil {
+					t.Fatalf("probe %s has nil Matchs", probe.Name)
+				}
+			}
+		}
+	})
+}
+
+func TestParseProbesFromContentMalformed(t *testing.T) {
+	lines := []string{
+		"match",
+		"match ssh",
+		"match ssh m",
+		"match ssh m|abc",
+		"match ssh m|abc|z",
+		"match ssh m|(abc| p/x/",
+		"match ssh m|abc| p/x",
+		"match ssh q|abc|",
+		"softmatch ftp m|",
+		"rarity x",
+		"rarity 10",
+		"totalwaitms soon",
+		"ports",
+		"fallback",
+		"sslports",
+		"bogus directive",
+		"Probe TCP",
+		"Probe SCTP X q||",
+		"Probe TCP X q|abc",
+	}
+	for _, line := range lines {
+		content := "Probe TCP NULL q||\n# comment\n" + line + "\n"
+		_, _, err := parseProbesFromContent(content, "custom", true)
+		var parseErr *ParseError
+		if !errors.As(err, &parseErr) {
+			t.Errorf("%q: got %v, want *ParseError", line, err)
+			continue
+		}
+		if parseErr.Source != "custom" || parseErr.Line != 3 {
+			t.Errorf("%q: error at %s:%d, want custom:3", line, parseErr.Source, parseErr.Line)
+		}
+	}
+}
+
+func TestDecodeEscapes(t *testing.T) {
+	data := []struct {
+		in   string
+		want string
+	}{
+		{`\0`, "\x00"},
+		{`\7`, "\x07"},
+		{`\12`, "\n"},
+		{`\177`, "\x7f"},
+		{`\377\x41\r\n`, "\xffA\r\n"},
+	}
+	for _, tt := range data {
+		if got, err := DecodeData(tt.in); err != nil || string(got) != tt.want {
+			t.Errorf("DecodeData(%q) = %q, %v, want %q", tt.in, got, err, tt.want)
+		}
+	}
+
+	// 正则元字符解码后保留转义，仍按字面匹配
+	pattern := []struct {
+		in   string
+		want string
+	}{
+		{`^\177\001`, "^\x7f\x01"},
+		{`a\056b`, `a\.b`},
+		{`\x2e\x41`, `\.A`},
+	}
+	for _, tt := range pattern {
+		if got, err := DecodePattern(tt.in); err != nil || string(got) != tt.want {
+			t.Errorf("DecodePattern(%q) = %q, %v, want %q", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestNewExploreConfigDeadline(t *testing.T) {
+	cfg := newExploreConfig()
+	if cfg.timeout != 10*time.Second || cfg.deadline.IsZero() {
+		t.Errorf("default config has no deadline: %+v", cfg)
+	}
+	if remain := time.Until(cfg.deadline); remain <= 9*time.Second || remain > 10*time.Second {
+		t.Errorf("default deadline in %v, want about 10s", remain)
+	}
+
+	if cfg := newExploreConfig(WithScanTimeout(0)); !cfg.deadline.IsZero() {
+		t.Errorf("WithScanTimeout(0) should disable the deadline, got %v", cfg.deadline)
+	}
+
+	expired := newExploreConfig(WithScanTimeout(time.Nanosecond))
+	time.Sleep(time.Millisecond)
+	if wait := expired.waitFor(Probe{TotalWaitMS: 6000}); wait > 0 {
+		t.Errorf("waitFor after deadline = %v, want <= 0", wait)
+	}
+}
+
+// 对端接受连接但从不响应时，识别应在截止时间附近结束
+func TestExploreStopsAtDeadline(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	v, err := NewVScan()
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	if _, err := v.Explore(ln.Addr().String(), WithScanTimeout(500*time.Millisecond)); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Explore took %v with a 500ms deadline", elapsed)
+	}
+}
+
+func TestGrabResponseDialSettings(t *testing.T) {
+	ln, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	remotes := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		remotes <- conn.RemoteAddr().String()
+		conn.Write([]byte("SSH-2.0-test\r\n"))
+		conn.Close()
+	}()
+
+	cfg := newExploreConfig(WithSourceAddr("127.0.0.1"), WithNetwork("tcp4"), WithDialTimeout(time.Second))
+	grab := grabResponse(ln.Addr().String(), nil, time.Second, cfg)
+	if grab.err != nil || string(grab.response) != "SSH-2.0-test\r\n" {
+		t.Fatalf("grabResponse = %q, %v", grab.response, grab.err)
+	}
+	if host, _, _ := net.SplitHostPort(<-remotes); host != "127.0.0.1" {
+		t.Errorf("connected from %s, want 127.0.0.1", host)
+	}
+
+	// 只允许 IPv6 时不会连到 IPv4 地址
+	grab = grabResponse(ln.Addr().String(), nil, time.Second, newExploreConfig(WithNetwork("tcp6")))
+	if grab.err == nil {
+		t.Error("tcp6 dial to an IPv4 address should fail")
+	}
+}
+
+func TestExploreInvalidSourceAddr(t *testing.T) {
+	v, err := NewVScan()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := v.Explore("127.0.0.1:22", WithSourceAddr("not-an-ip")); err == nil {
+		t.Error("expected invalid source address error")
+	}
+}
+
+func TestShared(t *testing.T) {
+	first, err := Shared()
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := Shared()
+	if err != nil || second != first {
+		t.Fatalf("Shared returned a different database: %p, %p, %v", first, second, err)
+	}
+	if len(first.Probes) == 0 || len(first.probesByProtocol["tcp"]) == 0 {
+		t.Error("shared database has no probes")
+	}
+	if err := Configure(); err == nil {
+		t.Error("Configure after Shared should fail")
+	}
+}
+
+// BenchmarkShared 每次检测获取共享探针库的开销，应与探针数量无关
+func BenchmarkShared(b *testing.B) {
+	if _, err := Shared(); err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := Shared(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkNewVScan 完整解压并解析内置探针库，即改为共享前每次检测的开销
+func BenchmarkNewVScan(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		if _, err := NewVScan(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSelectProbes(b *testing.B) {
+	v, err := Shared()
+	if err != nil {
+		b.Fatal(err)
+	}
+	target := Target{IP: "192.0.2.1", Port: 8080, Protocol: "tcp"}
+	cfg := newExploreConfig()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		v.selectProbes(target, cfg)
+	}
+}
+
+func BenchmarkFindMatch(b *testing.B) {
+	v, err := Shared()
+	if err != nil {
+		b.Fatal(err)
+	}
+	null, ok := v.ProbesMapKName["NULL"]
+	if !ok {
+		b.Fatal("builtin NULL probe missing")
+	}
+	responses := map[string][]byte{
+		"ssh":     []byte("SSH-2.0-OpenSSH_8.9p1 Ubuntu-3ubuntu0.6\r\n"),
+		"unknown": []byte("\x00\x01\x02 no such service banner\r\n"),
+	}
+	for name, response := range responses {
+		b.Run(name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				v.findMatch(null, response)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package tcp_lib
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"time"
+)
+
+// TCPChecker TCP 连接检测器结构体
+type TCPChecker struct {
+	timeout       time.Duration // 建连超时（TLS 握手共用）
+	network       string        // "tcp"、"tcp4" 或 "tcp6"
+	sourceAddr    string        // 绑定的源地址，为空则由系统选择
+	useTLS        bool          // 建连后是否进行 TLS 握手
+	serverName    string        // TLS SNI，为空时使用目标主机名
+	skipTLSVerify bool
+}
+
+// CheckResult 检测结果
+type CheckResult struct {
+	Address          string // host:port
+	RemoteAddr       string // 实际连接到的地址
+	Open             bool
+	ConnectTime      time.Duration
+	TLSHandshakeTime time.Duration
+	TLSVersion       string
+	TLSCipherSuite   string
+	Error            error
+}
+
+// CheckerOption 配置选项
+type CheckerOption func(*TCPChecker)
+
+// NewTCPChecker 创建检测器
+func NewTCPChecker(opts ...CheckerOption) *TCPChecker {
+	c := &TCPChecker{
+		timeout: 5 * time.Second,
+		network: "tcp",
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+func WithTimeout(d time.Duration) CheckerOption {
+	return func(c *TCPChecker) { c.timeout = d }
+}
+
+func WithSourceAddr(addr string) CheckerOption {
+	return func(c *TCPChecker) { c.sourceAddr = addr }
+}
+
+func WithIPv4() CheckerOption {
+	return func(c *TCPChecker) { c.network = "tcp4" }
+}
+
+func WithIPv6() CheckerOption {
+	return func(c *TCPChecker) { c.network = "tcp6" }
+}
+
+func WithTLS(serverName string) CheckerOption {
+	return func(c *TCPChecker) {
+		c.useTLS = true
+		c.serverName = serverName
+	}
+}
+
+func SkipTLSVerify() CheckerOption {
+	return func(c *TCPChecker) { c.skipTLSVerify = true }
+}
+
+// Check 检测单个 host:port（唯一对外接口）
+func (c *TCPChecker) Check(host, port string) *CheckResult {
+	result := &CheckResult{
+		Address: net.JoinHostPort(host, port),
+	}
+
+	dialer := &net.Dialer{Timeout: c.timeout}
+	if c.sourceAddr != "" {
+		ip := net.ParseIP(c.sourceAddr)
+		if ip == nil {
+			result.Error = fmt.Errorf("invalid source address: %s", c.sourceAddr)
+			return result
+		}
+		dialer.LocalAddr = &net.TCPAddr{IP: ip}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	conn, err := dialer.DialContext(ctx, c.network, result.Address)
+	result.ConnectTime = time.Since(start)
+	if err != nil {
+		result.Error = fmt.Errorf("dial failed: %w", err)
+		return result
+	}
+	defer conn.Close()
+
+	result.Open = true
+	result.RemoteAddr = conn.RemoteAddr().String()
+
+	if !c.useTLS {
+		return result
+	}
+
+	serverName := c.serverName
+	if serverName == "" {
+		serverName = host
+	}
+	tlsConn := tls.Client(conn, &tls.Config{
+		ServerName:         serverName,
+		InsecureSkipVerify: c.skipTLSVerify,
+	})
+
+	start = time.Now()
+	err = tlsConn.HandshakeContext(ctx)
+	result.TLSHandshakeTime = time.Since(start)
+	if err != nil {
+		result.Error = fmt.Errorf("tls handshake failed: %w", err)
+		return result
+	}
+
+	state := tlsConn.ConnectionState()
+	result.TLSVersion = tls.VersionName(state.Version)
+	result.TLSCipherSuite = tls.CipherSuiteName(state.CipherSuite)
+
+	return result
+}
@@ -0,0 +1,54 @@
+package tcp_scanner
+
+import (
+	"redrock-dashboard/core/pkg/scanner/tcp_scanner/vuln_lib"
+)
+
+// VulnerabilityRule 已知漏洞告警规则
+type VulnerabilityRule struct {
+	MinSeverity string   // 达到该严重程度才告警，为空时默认 HIGH
+	Ignore      []string // 已评估、接受风险的漏洞编号
+}
+
+// matchVulnerabilities 用指纹中的 CPE 与版本查询本地漏洞库
+func matchVulnerabilities(db *vuln_lib.Database, fp *Fingerprint) ([]vuln_lib.Vulnerability, string) {
+	if db == nil || fp == nil || fp.CPE == "" {
+		return nil, ""
+	}
+
+	vulns := db.Match(fp.CPE, fp.Version)
+	maxSeverity := ""
+	for _, vuln := range vulns {
+		if maxSeverity == "" || vuln_lib.SeverityRank(vuln.Severity) > vuln_lib.SeverityRank(maxSeverity) {
+			maxSeverity = vuln.Severity
+		}
+	}
+	return vulns, maxSeverity
+}
+
+// Evaluate 返回本次检测中触发告警的漏洞，未触发返回 nil
+func (r VulnerabilityRule) Evaluate(result *TCPScanResult) []vuln_lib.Vulnerability {
+	if result == nil {
+		return nil
+	}
+
+	minSeverity := r.MinSeverity
+	if minSeverity == "" {
+		minSeverity = vuln_lib.SeverityHigh
+	}
+	ignored := map[string]bool{}
+	for _, id := range r.Ignore {
+		ignored[id] = true
+	}
+
+	var hits []vuln_lib.Vulnerability
+	for _, vuln := range result.Vulnerabilities {
+		if ignored[vuln.ID] {
+			continue
+		}
+		if vuln_lib.SeverityRank(vuln.Severity) >= vuln_lib.SeverityRank(minSeverity) {
+			hits = append(hits, vuln)
+		}
+	}
+	return hits
+}
@@ -0,0 +1,130 @@
+package vuln_lib
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// cvssV3Weights CVSS v3.x 基础指标的取值权重，PR 在 S:C 时另见 cvssV3ScopeChangedPR
+var cvssV3Weights = map[string]map[string]float64{
+	"AV": {"N": 0.85, "A": 0.62, "L": 0.55, "P": 0.2},
+	"AC": {"L": 0.77, "H": 0.44},
+	"PR": {"N": 0.85, "L": 0.62, "H": 0.27},
+	"UI": {"N": 0.85, "R": 0.62},
+	"C":  {"H": 0.56, "L": 0.22, "N": 0},
+	"I":  {"H": 0.56, "L": 0.22, "N": 0},
+	"A":  {"H": 0.56, "L": 0.22, "N": 0},
+}
+
+var cvssV3ScopeChangedPR = map[string]float64{"N": 0.85, "L": 0.68, "H": 0.5}
+
+// cvssV2Weights CVSS v2 基础指标的取值权重
+var cvssV2Weights = map[string]map[string]float64{
+	"AV": {"L": 0.395, "A": 0.646, "N": 1.0},
+	"AC": {"H": 0.35, "M": 0.61, "L": 0.71},
+	"Au": {"M": 0.45, "S": 0.56, "N": 0.704},
+	"C":  {"N": 0, "P": 0.275, "C": 0.660},
+	"I":  {"N": 0, "P": 0.275, "C": 0.660},
+	"A":  {"N": 0, "P": 0.275, "C": 0.660},
+}
+
+// CVSSBaseScore 根据 CVSS v3.0/v3.1 或 v2 向量计算基础分，如
+// "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H" -> 9.8。v4 向量依赖查表，暂不支持。
+func CVSSBaseScore(vector string) (float64, error) {
+	vector = strings.TrimSpace(vector)
+	switch {
+	case strings.HasPrefix(vector, "CVSS:3.0/"), strings.HasPrefix(vector, "CVSS:3.1/"):
+		return cvssV3BaseScore(vector[len("CVSS:3.x/"):], strings.HasPrefix(vector, "CVSS:3.1/"))
+	case strings.HasPrefix(vector, "CVSS:"):
+		return 0, fmt.Errorf("unsupported cvss version: %s", vector)
+	}
+	return cvssV2BaseScore(strings.TrimPrefix(strings.TrimSuffix(strings.TrimPrefix(vector, "("), ")"), "CVSS:2.0/"))
+}
+
+// parseCVSSMetrics 解析 "AV:N/AC:L/..." 为指标表，weights 中的指标必须出现且取值合法
+func parseCVSSMetrics(vector string, weights map[string]map[string]float64) (map[string]string, error) {
+	metrics := make(map[string]string)
+	for _, part := range strings.Split(vector, "/") {
+		name, value, ok := strings.Cut(part, ":")
+		if !ok {
+			return nil, fmt.Errorf("malformed cvss metric %q", part)
+		}
+		metrics[name] = value
+	}
+	for name, values := range weights {
+		if _, ok := values[metrics[name]]; !ok {
+			return nil, fmt.Errorf("cvss metric %s has invalid value %q", name, metrics[name])
+		}
+	}
+	return metrics, nil
+}
+
+func cvssV3BaseScore(vector string, v31 bool) (float64, error) {
+	m, err := parseCVSSMetrics(vector, cvssV3Weights)
+	if err != nil {
+		return 0, err
+	}
+	changed := false
+	switch m["S"] {
+	case "U":
+	case "C":
+		changed = true
+	default:
+		return 0, fmt.Errorf("cvss metric S has invalid value %q", m["S"])
+	}
+
+	w := func(name string) float64 { return cvssV3Weights[name][m[name]] }
+	pr := w("PR")
+	if changed {
+		pr = cvssV3ScopeChangedPR[m["PR"]]
+	}
+
+	iss := 1 - (1-w("C"))*(1-w("I"))*(1-w("A"))
+	impact := 6.42 * iss
+	if changed {
+		impact = 7.52*(iss-0.029) - 3.25*math.Pow(iss-0.02, 15)
+	}
+	if impact <= 0 {
+		return 0, nil
+	}
+	exploitability := 8.22 * w("AV") * w("AC") * pr * w("UI")
+
+	roundUp := cvssV30RoundUp
+	if v31 {
+		roundUp = cvssV31RoundUp
+	}
+	if changed {
+		return roundUp(math.Min(1.08*(impact+exploitability), 10)), nil
+	}
+	return roundUp(math.Min(impact+exploitability, 10)), nil
+}
+
+// cvssV31RoundUp 规范附录 A 的向上取整，避免浮点误差把 4.0 变成 4.1
+func cvssV31RoundUp(x float64) float64 {
+	n := int64(math.Round(x * 100000))
+	if n%10000 == 0 {
+		return float64(n) / 100000
+	}
+	return float64(n/10000+1) / 10
+}
+
+func cvssV30RoundUp(x float64) float64 {
+	return math.Ceil(x*10) / 10
+}
+
+func cvssV2BaseScore(vector string) (float64, error) {
+	m, err := parseCVSSMetrics(vector, cvssV2Weights)
+	if err != nil {
+		return 0, err
+	}
+	w := func(name string) float64 { return cvssV2Weights[name][m[name]] }
+
+	impact := 10.41 * (1 - (1-w("C"))*(1-w("I"))*(1-w("A")))
+	exploitability := 20 * w("AV") * w("AC") * w("Au")
+	if impact == 0 {
+		return 0, nil
+	}
+	score := (0.6*impact + 0.4*exploitability - 1.5) * 1.176
+	return math.Round(score*10) / 10, nil
+}
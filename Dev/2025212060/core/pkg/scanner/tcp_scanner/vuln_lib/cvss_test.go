@@ -0,0 +1,45 @@
+package vuln_lib
+
+import "testing"
+
+func TestCVSSBaseScore(t *testing.T) {
+	tests := []struct {
+		vector string
+		want   float64
+	}{
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H", 9.8},
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:C/C:H/I:H/A:H", 10.0},
+		{"CVSS:3.1/AV:N/AC:L/PR:L/UI:N/S:U/C:H/I:N/A:N", 6.5},
+		{"CVSS:3.1/AV:L/AC:L/PR:L/UI:N/S:U/C:H/I:H/A:H", 7.8},
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:R/S:C/C:L/I:L/A:N", 6.1},
+		{"CVSS:3.0/AV:N/AC:H/PR:N/UI:N/S:U/C:H/I:N/A:N", 5.9},
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:N/A:N", 0},
+		// 指标顺序不影响结果，可带时间指标
+		{"CVSS:3.1/S:U/AV:N/AC:L/PR:N/UI:N/C:H/I:H/A:H/E:P", 9.8},
+		{"AV:N/AC:L/Au:N/C:P/I:P/A:P", 7.5},
+		{"AV:N/AC:M/Au:N/C:N/I:P/A:N", 4.3},
+		{"(AV:N/AC:L/Au:N/C:C/I:C/A:C)", 10.0},
+	}
+	for _, tt := range tests {
+		got, err := CVSSBaseScore(tt.vector)
+		if err != nil {
+			t.Errorf("CVSSBaseScore(%q) error: %v", tt.vector, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("CVSSBaseScore(%q) = %v, want %v", tt.vector, got, tt.want)
+		}
+	}
+
+	for _, vector := range []string{
+		"",
+		"CVSS:4.0/AV:N/AC:L/AT:N/PR:N/UI:N/VC:H/VI:H/VA:H/SC:N/SI:N/SA:N",
+		"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/C:H/I:H/A:H",
+		"CVSS:3.1/AV:X/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H",
+		"AV:N/AC:L",
+	} {
+		if _, err := CVSSBaseScore(vector); err == nil {
+			t.Errorf("CVSSBaseScore(%q) expected error", vector)
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package vuln_lib
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// nvdCPEMatch NVD 配置节点中的 CPE 匹配项，1.1 与 2.0 字段名不同
+type nvdCPEMatch struct {
+	Vulnerable            bool   `json:"vulnerable"`
+	CPE23URI              string `json:"cpe23Uri"` // 1.1
+	Criteria              string `json:"criteria"` // 2.0
+	VersionStartIncluding string `json:"versionStartIncluding"`
+	VersionStartExcluding string `json:"versionStartExcluding"`
+	VersionEndIncluding   string `json:"versionEndIncluding"`
+	VersionEndExcluding   string `json:"versionEndExcluding"`
+}
+
+type nvdNode struct {
+	Negate   bool          `json:"negate"`
+	CPEMatch []nvdCPEMatch `json:"cpe_match"` // 1.1
+	Matches  []nvdCPEMatch `json:"cpeMatch"`  // 2.0
+	Children []nvdNode     `json:"children"`
+}
+
+// nvdFeed NVD JSON 1.1 数据源（nvdcve-1.1-*.json）
+type nvdFeed struct {
+	CVEItems []struct {
+		CVE struct {
+			Meta struct {
+				ID string `json:"ID"`
+			} `json:"CVE_data_meta"`
+			Description struct {
+				Data []nvdLangString `json:"description_data"`
+			} `json:"description"`
+			References struct {
+				Data []struct {
+					URL string `json:"url"`
+				} `json:"reference_data"`
+			} `json:"references"`
+		} `json:"cve"`
+		Configurations struct {
+			Nodes []nvdNode `json:"nodes"`
+		} `json:"configurations"`
+		Impact struct {
+			V3 struct {
+				CVSS struct {
+					BaseScore    float64 `json:"baseScore"`
+					BaseSeverity string  `json:"baseSeverity"`
+				} `json:"cvssV3"`
+			} `json:"baseMetricV3"`
+			V2 struct {
+				CVSS struct {
+					BaseScore float64 `json:"baseScore"`
+				} `json:"cvssV2"`
+				Severity string `json:"severity"`
+			} `json:"baseMetricV2"`
+		} `json:"impact"`
+	} `json:"CVE_Items"`
+}
+
+// nvdAPI NVD 2.0 API 响应及 2.0 数据源（nvdcve-2.0-*.json）
+type nvdAPI struct {
+	Vulnerabilities []struct {
+		CVE struct {
+			ID           string          `json:"id"`
+			Descriptions []nvdLangString `json:"descriptions"`
+			References   []struct {
+				URL string `json:"url"`
+			} `json:"references"`
+			Metrics struct {
+				V31 []nvdMetric `json:"cvssMetricV31"`
+				V30 []nvdMetric `json:"cvssMetricV30"`
+				V2  []nvdMetric `json:"cvssMetricV2"`
+			} `json:"metrics"`
+			Configurations []struct {
+				Nodes []nvdNode `json:"nodes"`
+			} `json:"configurations"`
+		} `json:"cve"`
+	} `json:"vulnerabilities"`
+}
+
+type nvdLangString struct {
+	Lang  string `json:"lang"`
+	Value string `json:"value"`
+}
+
+type nvdMetric struct {
+	Data struct {
+		BaseScore    float64 `json:"baseScore"`
+		BaseSeverity string  `json:"baseSeverity"`
+	} `json:"cvssData"`
+	BaseSeverity string `json:"baseSeverity"` // v2 的严重程度在外层
+}
+
+func englishText(texts []nvdLangString) string {
+	for _, text := range texts {
+		if text.Lang == "en" {
+			return text.Value
+		}
+	}
+	if len(texts) > 0 {
+		return texts[0].Value
+	}
+	return ""
+}
+
+// collectAffected 展开配置节点中所有受影响的应用 CPE，忽略取反节点与平台限定（如 o:linux）
+func collectAffected(nodes []nvdNode) []affected {
+	var items []affected
+	for _, node := range nodes {
+		if node.Negate {
+			continue
+		}
+		for _, match := range append(node.CPEMatch, node.Matches...) {
+			if !match.Vulnerable {
+				continue
+			}
+			uri := match.CPE23URI
+			if uri == "" {
+				uri = match.Criteria
+			}
+			vendor, product, version := ParseCPE(uri)
+			if product == "" || !strings.HasPrefix(strings.TrimPrefix(uri, "cpe:2.3:"), "a:") {
+				continue
+			}
+			items = append(items, affected{
+				vendor:         vendor,
+				product:        product,
+				version:        version,
+				startIncluding: match.VersionStartIncluding,
+				startExcluding: match.VersionStartExcluding,
+				endIncluding:   match.VersionEndIncluding,
+				endExcluding:   match.VersionEndExcluding,
+			})
+		}
+		items = append(items, collectAffected(node.Children)...)
+	}
+	return items
+}
+
+func (db *Database) loadNVDFeed(content []byte) error {
+	var feed nvdFeed
+	if err := json.Unmarshal(content, &feed); err != nil {
+		return err
+	}
+
+	for _, item := range feed.CVEItems {
+		vuln := &Vulnerability{
+			ID:      item.CVE.Meta.ID,
+			Summary: englishText(item.CVE.Description.Data),
+		}
+		for _, ref := range item.CVE.References.Data {
+			vuln.References = append(vuln.References, ref.URL)
+		}
+
+		switch {
+		case item.Impact.V3.CVSS.BaseScore > 0:
+			vuln.Score = item.Impact.V3.CVSS.BaseScore
+			vuln.Severity = strings.ToUpper(item.Impact.V3.CVSS.BaseSeverity)
+		case item.Impact.V2.CVSS.BaseScore > 0:
+			vuln.Score = item.Impact.V2.CVSS.BaseScore
+			vuln.Severity = strings.ToUpper(item.Impact.V2.Severity)
+		}
+		if vuln.Severity == "" {
+			vuln.Severity = severityFromScore(vuln.Score)
+		}
+
+		db.add(vuln, collectAffected(item.Configurations.Nodes))
+	}
+	return nil
+}
+
+func (db *Database) loadNVDAPI(content []byte) error {
+	var feed nvdAPI
+	if err := json.Unmarshal(content, &feed); err != nil {
+		return err
+	}
+
+	for _, item := range feed.Vulnerabilities {
+		vuln := &Vulnerability{
+			ID:      item.CVE.ID,
+			Summary: englishText(item.CVE.Descriptions),
+		}
+		for _, ref := range item.CVE.References {
+			vuln.References = append(vuln.References, ref.URL)
+		}
+
+		for _, metrics := range [][]nvdMetric{item.CVE.Metrics.V31, item.CVE.Metrics.V30, item.CVE.Metrics.V2} {
+			if len(metrics) == 0 {
+				continue
+			}
+			vuln.Score = metrics[0].Data.BaseScore
+			vuln.Severity = strings.ToUpper(metrics[0].Data.BaseSeverity)
+			if vuln.Severity == "" {
+				vuln.Severity = strings.ToUpper(metrics[0].BaseSeverity)
+			}
+			break
+		}
+		if vuln.Severity == "" {
+			vuln.Severity = severityFromScore(vuln.Score)
+		}
+
+		var items []affected
+		for _, config := range item.CVE.Configurations {
+			items = append(items, collectAffected(config.Nodes)...)
+		}
+		db.add(vuln, items)
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+package vuln_lib
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+// osvEntry OSV 格式的漏洞条目，见 https://ossf.github.io/osv-schema/
+type osvEntry struct {
+	ID       string   `json:"id"`
+	Aliases  []string `json:"aliases"`
+	Summary  string   `json:"summary"`
+	Details  string   `json:"details"`
+	Severity []struct {
+		Type  string `json:"type"`
+		Score string `json:"score"`
+	} `json:"severity"`
+	Affected []struct {
+		Package struct {
+			Ecosystem string `json:"ecosystem"`
+			Name      string `json:"name"`
+		} `json:"package"`
+		Ranges []struct {
+			Type   string `json:"type"`
+			Events []struct {
+				Introduced   string `json:"introduced"`
+				Fixed        string `json:"fixed"`
+				LastAffected string `json:"last_affected"`
+			} `json:"events"`
+		} `json:"ranges"`
+		Versions         []string `json:"versions"`
+		DatabaseSpecific struct {
+			Severity string `json:"severity"`
+		} `json:"database_specific"`
+	} `json:"affected"`
+	DatabaseSpecific struct {
+		Severity string  `json:"severity"`
+		CVSS     float64 `json:"cvss_score"`
+	} `json:"database_specific"`
+	References []struct {
+		URL string `json:"url"`
+	} `json:"references"`
+}
+
+// osvServiceEcosystems 发行版与镜像仓库的生态，包名即服务软件名，可以和服务识别出的 CPE 比较。
+// PyPI、npm、Go 等语言生态是程序库，与服务同名（如 PyPI 的 redis 客户端）也不是同一个软件，不导入
+var osvServiceEcosystems = map[string]bool{
+	"almalinux":   true,
+	"alpine":      true,
+	"azure linux": true,
+	"bitnami":     true,
+	"chainguard":  true,
+	"debian":      true,
+	"mageia":      true,
+	"minimos":     true,
+	"openeuler":   true,
+	"opensuse":    true,
+	"photon os":   true,
+	"red hat":     true,
+	"rocky linux": true,
+	"suse":        true,
+	"ubuntu":      true,
+	"wolfi":       true,
+}
+
+// osvServiceEcosystem 生态是否为服务软件所在的生态，忽略 "Debian:12"、"Alpine:v3.18" 这类版本后缀
+func osvServiceEcosystem(ecosystem string) bool {
+	name, _, _ := strings.Cut(ecosystem, ":")
+	return osvServiceEcosystems[strings.ToLower(strings.TrimSpace(name))]
+}
+
+// osvPackageName OSV 包名一般带路径或组织前缀，只保留最后一段与 CPE 的产品名比较
+func osvPackageName(name string) string {
+	name = strings.ToLower(name)
+	if index := strings.LastIndexAny(name, "/:"); index >= 0 {
+		name = name[index+1:]
+	}
+	return name
+}
+
+func (db *Database) loadOSV(content []byte) error {
+	var entries []osvEntry
+	if bytes.HasPrefix(content, []byte("[")) {
+		if err := json.Unmarshal(content, &entries); err != nil {
+			return err
+		}
+	} else {
+		var entry osvEntry
+		if err := json.Unmarshal(content, &entry); err != nil {
+			return err
+		}
+		entries = append(entries, entry)
+	}
+
+	for _, entry := range entries {
+		vuln := &Vulnerability{
+			ID:       entry.ID,
+			Aliases:  entry.Aliases,
+			Summary:  entry.Summary,
+			Severity: strings.ToUpper(entry.DatabaseSpecific.Severity),
+			Score:    entry.DatabaseSpecific.CVSS,
+		}
+		if vuln.Summary == "" {
+			vuln.Summary = entry.Details
+		}
+		for _, ref := range entry.References {
+			vuln.References = append(vuln.References, ref.URL)
+		}
+
+		var items []affected
+		for _, pkg := range entry.Affected {
+			if !osvServiceEcosystem(pkg.Package.Ecosystem) {
+				continue
+			}
+			if vuln.Severity == "" {
+				vuln.Severity = strings.ToUpper(pkg.DatabaseSpecific.Severity)
+			}
+			product := osvPackageName(pkg.Package.Name)
+
+			for _, version := range pkg.Versions {
+				items = append(items, affected{product: product, version: version})
+			}
+			for _, r := range pkg.Ranges {
+				// GIT 范围是提交哈希，无法与服务版本号比较
+				if r.Type == "GIT" {
+					continue
+				}
+				var current *affected
+				for _, event := range r.Events {
+					switch {
+					case event.Introduced != "":
+						items = append(items, affected{product: product})
+						current = &items[len(items)-1]
+						if event.Introduced != "0" {
+							current.startIncluding = event.Introduced
+						}
+					case current == nil:
+						continue
+					case event.Fixed != "":
+						current.endExcluding = event.Fixed
+						current = nil
+					case event.LastAffected != "":
+						current.endIncluding = event.LastAffected
+						current = nil
+					}
+				}
+			}
+		}
+		if vuln.Score == 0 {
+			vuln.Score = osvScore(entry)
+		}
+		if vuln.Severity == "" {
+			vuln.Severity = severityFromScore(vuln.Score)
+		}
+		db.add(vuln, items)
+	}
+	return nil
+}
+
+// osvScore 从 severity 中的 CVSS 向量计算基础分，有多个时取版本最新的一个，无法计算时为 0
+func osvScore(entry osvEntry) float64 {
+	var score float64
+	rank := 0
+	for _, severity := range entry.Severity {
+		r := map[string]int{"CVSS_V2": 1, "CVSS_V3": 2}[severity.Type]
+		if r <= rank {
+			continue
+		}
+		if s, err := CVSSBaseScore(severity.Score); err == nil {
+			score, rank = s, r
+		}
+	}
+	return score
+}
@@ -0,0 +1,378 @@
+package vuln_lib
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// 严重程度，与 NVD 的 baseSeverity 一致
+const (
+	SeverityNone     = "NONE"
+	SeverityLow      = "LOW"
+	SeverityMedium   = "MEDIUM"
+	SeverityHigh     = "HIGH"
+	SeverityCritical = "CRITICAL"
+	SeverityUnknown  = "UNKNOWN"
+)
+
+// SeverityRank 严重程度排序值，越大越严重，未知按 0 处理
+func SeverityRank(severity string) int {
+	switch strings.ToUpper(severity) {
+	case SeverityLow:
+		return 1
+	case SeverityMedium:
+		return 2
+	case SeverityHigh:
+		return 3
+	case SeverityCritical:
+		return 4
+	}
+	return 0
+}
+
+// severityFromScore 按 CVSS v3 区间换算严重程度
+func severityFromScore(score float64) string {
+	switch {
+	case score >= 9.0:
+		return SeverityCritical
+	case score >= 7.0:
+		return SeverityHigh
+	case score >= 4.0:
+		return SeverityMedium
+	case score > 0:
+		return SeverityLow
+	}
+	return SeverityUnknown
+}
+
+// Vulnerability 漏洞条目
+type Vulnerability struct {
+	ID         string
+	Aliases    []string
+	Summary    string
+	Severity   string
+	Score      float64 // CVSS 基础分，未知为 0
+	References []string
+}
+
+// affected 受影响的产品与版本范围
+type affected struct {
+	vendor  string // 为空表示不限厂商（OSV 数据只有包名）
+	product string
+	version string // 精确版本，为空或 "*" 时看范围
+
+	startIncluding string
+	startExcluding string
+	endIncluding   string
+	endExcluding   string
+
+	vuln *Vulnerability
+}
+
+// 压缩格式的文件头
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zipMagic  = []byte("PK\x03\x04")
+)
+
+// Database 本地漏洞库，加载后只读，可被多个监控并发使用
+type Database struct {
+	mu        sync.RWMutex
+	byProduct map[string][]affected
+	count     int
+}
+
+// NewDatabase 创建空的漏洞库
+func NewDatabase() *Database {
+	return &Database{byProduct: map[string][]affected{}}
+}
+
+// Len 已加载的漏洞数量
+func (db *Database) Len() int {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	return db.count
+}
+
+func (db *Database) add(vuln *Vulnerability, items []affected) {
+	if len(items) == 0 {
+		return
+	}
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for _, item := range items {
+		item.vuln = vuln
+		item.product = normalizeName(item.product)
+		item.vendor = normalizeName(item.vendor)
+		db.byProduct[item.product] = append(db.byProduct[item.product], item)
+	}
+	db.count++
+}
+
+// LoadFile 按内容自动识别 NVD（1.1 数据源或 2.0 API/数据源）与 OSV 格式并导入，
+// 按文件头识别 gzip 压缩和 OSV 的 zip 打包导出，与扩展名无关。导入只读本地文件，检测时不访问网络。
+func (db *Database) LoadFile(path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	if bytes.HasPrefix(content, zipMagic) {
+		return db.loadZip(content, path)
+	}
+	if bytes.HasPrefix(content, gzipMagic) {
+		reader, err := gzip.NewReader(bytes.NewReader(content))
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if content, err = io.ReadAll(reader); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	if err := db.loadJSON(content); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+func (db *Database) loadZip(content []byte, path string) error {
+	archive, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	for _, file := range archive.File {
+		if !strings.HasSuffix(file.Name, ".json") {
+			continue
+		}
+		reader, err := file.Open()
+		if err != nil {
+			return fmt.Errorf("%s/%s: %w", path, file.Name, err)
+		}
+		data, err := io.ReadAll(reader)
+		reader.Close()
+		if err != nil {
+			return fmt.Errorf("%s/%s: %w", path, file.Name, err)
+		}
+		if err := db.loadJSON(data); err != nil {
+			return fmt.Errorf("%s/%s: %w", path, file.Name, err)
+		}
+	}
+	return nil
+}
+
+func (db *Database) loadJSON(content []byte) error {
+	trimmed := bytes.TrimSpace(content)
+	switch {
+	case bytes.Contains(trimmed, []byte(`"CVE_Items"`)):
+		return db.loadNVDFeed(trimmed)
+	case bytes.Contains(trimmed, []byte(`"vulnerabilities"`)):
+		return db.loadNVDAPI(trimmed)
+	case bytes.HasPrefix(trimmed, []byte("[")) || bytes.Contains(trimmed, []byte(`"affected"`)):
+		return db.loadOSV(trimmed)
+	}
+	return fmt.Errorf("unrecognised vulnerability feed format")
+}
+
+// Match 根据识别出的 CPE 与版本查找已知漏洞，按严重程度从高到低排序。
+// cpe 支持 "cpe:/a:vendor:product:version"、nmap 输出的 "a:vendor:product:version"
+// 以及 CPE 2.3 格式；cpe 中没有版本时使用 version。
+func (db *Database) Match(cpe string, version string) []Vulnerability {
+	vendor, product, cpeVersion := ParseCPE(cpe)
+	if product == "" {
+		return nil
+	}
+	if cpeVersion != "" && cpeVersion != "*" && cpeVersion != "-" {
+		version = cpeVersion
+	}
+	version = normalizeVersion(version)
+	if version == "" {
+		return nil
+	}
+
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	seen := map[string]bool{}
+	var vulns []Vulnerability
+	for _, item := range db.byProduct[normalizeName(product)] {
+		if item.vendor != "" && vendor != "" && item.vendor != normalizeName(vendor) {
+			continue
+		}
+		if seen[item.vuln.ID] || !item.contains(version) {
+			continue
+		}
+		seen[item.vuln.ID] = true
+		vulns = append(vulns, *item.vuln)
+	}
+
+	sort.SliceStable(vulns, func(i, j int) bool {
+		if vulns[i].Score != vulns[j].Score {
+			return vulns[i].Score > vulns[j].Score
+		}
+		return SeverityRank(vulns[i].Severity) > SeverityRank(vulns[j].Severity)
+	})
+	return vulns
+}
+
+func (a affected) contains(version string) bool {
+	if a.version != "" && a.version != "*" && a.version != "-" {
+		return CompareVersions(version, a.version) == 0
+	}
+	if a.startIncluding != "" && CompareVersions(version, a.startIncluding) < 0 {
+		return false
+	}
+	if a.startExcluding != "" && CompareVersions(version, a.startExcluding) <= 0 {
+		return false
+	}
+	if a.endIncluding != "" && CompareVersions(version, a.endIncluding) > 0 {
+		return false
+	}
+	if a.endExcluding != "" && CompareVersions(version, a.endExcluding) >= 0 {
+		return false
+	}
+	// 既没有精确版本也没有任何范围，视为所有版本受影响
+	return true
+}
+
+// ParseCPE 从 CPE 2.2 URI、2.3 格式串或 nmap 输出中取出厂商、产品与版本
+func ParseCPE(cpe string) (vendor, product, version string) {
+	cpe = strings.TrimSpace(cpe)
+	cpe = strings.TrimPrefix(cpe, "cpe:2.3:")
+	cpe = strings.TrimPrefix(cpe, "cpe:/")
+	cpe = strings.TrimSuffix(cpe, "/")
+
+	parts := strings.Split(cpe, ":")
+	if len(parts) < 3 {
+		return "", "", ""
+	}
+	vendor, product = parts[1], parts[2]
+	if len(parts) > 3 {
+		version = parts[3]
+	}
+	return vendor, product, version
+}
+
+func normalizeName(name string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(name)), " ", "_")
+}
+
+// normalizeVersion 只取版本号的第一段，nmap 的版本常带发行版说明，如 "8.9p1 Ubuntu 3ubuntu0.6"
+func normalizeVersion(version string) string {
+	fields := strings.Fields(version)
+	if len(fields) == 0 {
+		return ""
+	}
+	return strings.TrimPrefix(strings.ToLower(fields[0]), "v")
+}
+
+// preRelease 比正式版小的预发布标记
+var preRelease = map[string]bool{"dev": true, "alpha": true, "a": true, "beta": true, "b": true, "pre": true, "rc": true}
+
+// splitVersion 将版本号拆成数字段与字母段，如 "8.9p1" -> ["8" "9" "p" "1"]
+func splitVersion(version string) []string {
+	var parts []string
+	var current []rune
+	var digit bool
+	flush := func() {
+		if len(current) > 0 {
+			parts = append(parts, string(current))
+			current = current[:0]
+		}
+	}
+	for _, r := range strings.ToLower(version) {
+		switch {
+		case unicode.IsDigit(r):
+			if !digit {
+				flush()
+			}
+			digit = true
+			current = append(current, r)
+		case unicode.IsLetter(r):
+			if digit {
+				flush()
+			}
+			digit = false
+			current = append(current, r)
+		default:
+			flush()
+		}
+	}
+	flush()
+	return parts
+}
+
+// splitEpoch 拆出 Debian/RPM 版本的 epoch，如 "1:2.0" -> 1, "2.0"，没有 epoch 时为 0
+func splitEpoch(version string) (int, string) {
+	prefix, rest, ok := strings.Cut(version, ":")
+	if !ok {
+		return 0, version
+	}
+	epoch, err := strconv.Atoi(prefix)
+	if err != nil {
+		return 0, version
+	}
+	return epoch, rest
+}
+
+// CompareVersions 比较两个版本号，a<b 返回 -1，相等返回 0，a>b 返回 1。
+// epoch 优先比较：1:2.0 > 2.1
+func CompareVersions(a, b string) int {
+	epochA, a := splitEpoch(normalizeVersion(a))
+	epochB, b := splitEpoch(normalizeVersion(b))
+	if epochA != epochB {
+		return compareInt(epochA, epochB)
+	}
+	pa, pb := splitVersion(a), splitVersion(b)
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		switch {
+		case i >= len(pa):
+			return -extraPartOrder(pb[i])
+		case i >= len(pb):
+			return extraPartOrder(pa[i])
+		}
+
+		na, errA := strconv.Atoi(pa[i])
+		nb, errB := strconv.Atoi(pb[i])
+		switch {
+		case errA == nil && errB == nil:
+			if na != nb {
+				return compareInt(na, nb)
+			}
+		case errA == nil:
+			// 数字段大于字母段：1.0.1 > 1.0rc1
+			return 1
+		case errB == nil:
+			return -1
+		default:
+			if pa[i] != pb[i] {
+				return strings.Compare(pa[i], pb[i])
+			}
+		}
+	}
+	return 0
+}
+
+// extraPartOrder 多出来的版本段让版本更大，预发布标记让版本更小：1.0 < 1.0.1、1.0rc1 < 1.0
+func extraPartOrder(part string) int {
+	if preRelease[part] {
+		return -1
+	}
+	return 1
+}
+
+func compareInt(a, b int) int {
+	if a < b {
+		return -1
+	}
+	return 1
+}
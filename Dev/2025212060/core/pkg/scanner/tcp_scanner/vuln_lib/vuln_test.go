@@ -0,0 +1,273 @@
+package vuln_lib
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.0", "1.0", 0},
+		{"1.0", "1.0.1", -1},
+		{"1.10", "1.9", 1},
+		{"8.9p1", "8.9", 1},
+		{"8.9p1", "8.10", -1},
+		{"1.0rc1", "1.0", -1},
+		{"1.0.1", "1.0rc1", 1},
+		{"v2.4.49", "2.4.50", -1},
+		{"8.9p1 Ubuntu 3ubuntu0.6", "8.9p1", 0},
+		// epoch 优先于版本号
+		{"1:2.0", "2.1", 1},
+		{"2.1", "1:2.0", -1},
+		{"1:2.0", "1:2.1", -1},
+		{"0:2.1", "2.1", 0},
+	}
+	for _, tt := range tests {
+		if got := CompareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("CompareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestParseCPE(t *testing.T) {
+	tests := []struct {
+		cpe                      string
+		vendor, product, version string
+	}{
+		{"cpe:/a:openbsd:openssh:8.9p1", "openbsd", "openssh", "8.9p1"},
+		{"a:apache:http_server:2.4.49", "apache", "http_server", "2.4.49"},
+		{"cpe:2.3:a:nginx:nginx:1.18.0:*:*:*:*:*:*:*", "nginx", "nginx", "1.18.0"},
+		{"cpe:/a:openbsd:openssh", "openbsd", "openssh", ""},
+		{"bogus", "", "", ""},
+	}
+	for _, tt := range tests {
+		vendor, product, version := ParseCPE(tt.cpe)
+		if vendor != tt.vendor || product != tt.product || version != tt.version {
+			t.Errorf("ParseCPE(%q) = %q, %q, %q", tt.cpe, vendor, product, version)
+		}
+	}
+}
+
+// 只有 CVSS 向量的 OSV 条目按向量计算分数与严重程度，达到默认的 HIGH 告警阈值
+func TestLoadOSVSeverityFromVector(t *testing.T) {
+	feed := `[{
+		"id": "OSV-2024-1",
+		"summary": "remote code execution",
+		"severity": [
+			{"type": "CVSS_V2", "score": "AV:N/AC:M/Au:N/C:N/I:P/A:N"},
+			{"type": "CVSS_V3", "score": "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H"}
+		],
+		"affected": [{
+			"package": {"ecosystem": "Debian:12", "name": "openssh"},
+			"ranges": [{"type": "ECOSYSTEM", "events": [{"introduced": "0"}, {"fixed": "1:9.2p1-2"}]}]
+		}]
+	}]`
+	db := NewDatabase()
+	if err := db.loadJSON([]byte(feed)); err != nil {
+		t.Fatal(err)
+	}
+
+	vulns := db.Match("cpe:/a:openbsd:openssh", "1:9.2p1")
+	if len(vulns) != 1 {
+		t.Fatalf("got %d vulnerabilities, want 1", len(vulns))
+	}
+	if vulns[0].Score != 9.8 || vulns[0].Severity != SeverityCritical {
+		t.Errorf("score %v severity %s, want 9.8 CRITICAL", vulns[0].Score, vulns[0].Severity)
+	}
+	if SeverityRank(vulns[0].Severity) < SeverityRank(SeverityHigh) {
+		t.Error("vector-only entry stays below the HIGH threshold")
+	}
+
+	// 修复版本及更高 epoch 不受影响
+	for _, version := range []string{"1:9.2p1-2", "2:1.0"} {
+		if vulns := db.Match("cpe:/a:openbsd:openssh", version); len(vulns) != 0 {
+			t.Errorf("version %s matched %v", version, vulns)
+		}
+	}
+}
+
+// 语言生态里与服务同名的程序库不会匹配到服务的 CPE
+func TestLoadOSVLibraryEcosystems(t *testing.T) {
+	feed := `[
+		{"id": "PYSEC-1", "affected": [{"package": {"ecosystem": "PyPI", "name": "redis"}, "versions": ["7.0.0"]}]},
+		{"id": "GHSA-1", "affected": [{"package": {"ecosystem": "npm", "name": "@types/nginx"}, "versions": ["1.24.0"]}]},
+		{"id": "GO-1", "affected": [{"package": {"ecosystem": "Go", "name": "github.com/go-sql-driver/mysql"}, "versions": ["8.0.0"]}]},
+		{"id": "DSA-1", "affected": [
+			{"package": {"ecosystem": "PyPI", "name": "redis"}, "versions": ["7.0.0"]},
+			{"package": {"ecosystem": "Debian:12", "name": "redis"}, "versions": ["7.0.0"]}
+		]}
+	]`
+	db := NewDatabase()
+	if err := db.loadJSON([]byte(feed)); err != nil {
+		t.Fatal(err)
+	}
+	if db.Len() != 1 {
+		t.Errorf("loaded %d vulnerabilities, want 1", db.Len())
+	}
+
+	if vulns := db.Match("cpe:/a:redislabs:redis", "7.0.0"); len(vulns) != 1 || vulns[0].ID != "DSA-1" {
+		t.Errorf("redis matched %v, want DSA-1", vulns)
+	}
+	for _, cpe := range []string{"cpe:/a:nginx:nginx:1.24.0", "cpe:/a:mysql:mysql:8.0.0"} {
+		if vulns := db.Match(cpe, ""); len(vulns) != 0 {
+			t.Errorf("%s matched %v", cpe, vulns)
+		}
+	}
+}
+
+const nvdFeed11 = `{
+	"CVE_data_type": "CVE",
+	"CVE_Items": [{
+		"cve": {
+			"CVE_data_meta": {"ID": "CVE-2021-41773"},
+			"description": {"description_data": [{"lang": "en", "value": "Path traversal in Apache HTTP Server 2.4.49"}]},
+			"references": {"reference_data": [{"url": "https://httpd.apache.org/security/vulnerabilities_24.html"}]}
+		},
+		"configurations": {"nodes": [{
+			"operator": "AND",
+			"children": [
+				{"cpe_match": [{"vulnerable": true, "cpe23Uri": "cpe:2.3:a:apache:http_server:2.4.49:*:*:*:*:*:*:*"}]},
+				{"cpe_match": [{"vulnerable": false, "cpe23Uri": "cpe:2.3:o:fedoraproject:fedora:35:*:*:*:*:*:*:*"}]}
+			]
+		}]},
+		"impact": {
+			"baseMetricV3": {"cvssV3": {"baseScore": 7.5, "baseSeverity": "HIGH"}},
+			"baseMetricV2": {"cvssV2": {"baseScore": 4.3}, "severity": "MEDIUM"}
+		}
+	}]
+}`
+
+const nvdAPI20 = `{
+	"resultsPerPage": 1,
+	"format": "NVD_CVE",
+	"version": "2.0",
+	"vulnerabilities": [{
+		"cve": {
+			"id": "CVE-2023-38408",
+			"descriptions": [{"lang": "es", "value": "..."}, {"lang": "en", "value": "PKCS#11 feature in ssh-agent"}],
+			"metrics": {"cvssMetricV31": [{"cvssData": {"baseScore": 9.8, "baseSeverity": "CRITICAL"}}]},
+			"configurations": [{"nodes": [
+				{"cpeMatch": [{"vulnerable": true, "criteria": "cpe:2.3:a:openbsd:openssh:*:*:*:*:*:*:*:*", "versionEndExcluding": "9.3"}]},
+				{"negate": true, "cpeMatch": [{"vulnerable": true, "criteria": "cpe:2.3:a:openbsd:openssh:9.5:*:*:*:*:*:*:*"}]}
+			]}]
+		}
+	}]
+}`
+
+func writeFeed(t *testing.T, name string, content []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, content, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func gzipped(t *testing.T, content string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	w.Write([]byte(content))
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func zipped(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for name, content := range files {
+		f, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Write([]byte(content))
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestLoadFileNVD(t *testing.T) {
+	tests := []struct {
+		name      string
+		file      string
+		content   []byte
+		feed, api bool // 是否包含 1.1 / 2.0 的条目
+	}{
+		{"1.1 feed", "nvdcve-1.1-2021.json", []byte(nvdFeed11), true, false},
+		{"2.0 api", "nvdcve-2.0-2023.json", []byte(nvdAPI20), false, true},
+		{"gzip", "nvdcve-1.1-2021.json.gz", gzipped(t, nvdFeed11), true, false},
+		{"gzip without extension", "nvdcve-2.0-2023", gzipped(t, nvdAPI20), false, true},
+		{"zip", "feeds.zip", zipped(t, map[string]string{"a.json": nvdFeed11, "b.json": nvdAPI20, "README.txt": "not a feed"}), true, true},
+		{"zip without extension", "feeds.bin", zipped(t, map[string]string{"a.json": nvdFeed11, "b.json": nvdAPI20}), true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := NewDatabase()
+			if err := db.LoadFile(writeFeed(t, tt.file, tt.content)); err != nil {
+				t.Fatal(err)
+			}
+
+			httpd := db.Match("cpe:/a:apache:http_server:2.4.49", "")
+			ssh := db.Match("cpe:/a:openbsd:openssh", "8.9p1")
+			if (len(httpd) == 1) != tt.feed || (len(ssh) == 1) != tt.api {
+				t.Fatalf("httpd matched %v, openssh matched %v", httpd, ssh)
+			}
+			if tt.feed {
+				v := httpd[0]
+				if v.ID != "CVE-2021-41773" || v.Score != 7.5 || v.Severity != SeverityHigh ||
+					v.Summary != "Path traversal in Apache HTTP Server 2.4.49" || len(v.References) != 1 {
+					t.Errorf("1.1 entry = %+v", v)
+				}
+			}
+			if tt.api {
+				v := ssh[0]
+				if v.ID != "CVE-2023-38408" || v.Score != 9.8 || v.Severity != SeverityCritical || v.Summary != "PKCS#11 feature in ssh-agent" {
+					t.Errorf("2.0 entry = %+v", v)
+				}
+			}
+
+			// 版本范围、厂商与平台限定
+			for _, miss := range []struct{ cpe, version string }{
+				{"cpe:/a:apache:http_server:2.4.50", ""},
+				{"cpe:/a:other:http_server:2.4.49", ""},
+				{"cpe:/o:fedoraproject:fedora:35", ""},
+				{"cpe:/a:openbsd:openssh", "9.3p1"},
+				{"cpe:/a:openbsd:openssh", "9.5"},
+			} {
+				if vulns := db.Match(miss.cpe, miss.version); len(vulns) != 0 {
+					t.Errorf("%s %s matched %v", miss.cpe, miss.version, vulns)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadFileErrors(t *testing.T) {
+	db := NewDatabase()
+	for name, content := range map[string][]byte{
+		"unknown.json":   []byte(`{"foo": 1}`),
+		"broken.json.gz": {0x1f, 0x8b, 0x00},
+		"broken.zip":     []byte("PK\x03\x04broken"),
+		"bad.zip":        zipped(t, map[string]string{"a.json": `{"CVE_Items": 1}`}),
+	} {
+		if err := db.LoadFile(writeFeed(t, name, content)); err == nil || !strings.Contains(err.Error(), name) {
+			t.Errorf("%s: error %v", name, err)
+		}
+	}
+	if err := db.LoadFile(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("missing file should fail")
+	}
+}
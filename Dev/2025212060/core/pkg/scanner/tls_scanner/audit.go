@@ -0,0 +1,93 @@
+package tls_scanner
+
+import (
+	"crypto/tls"
+	"fmt"
+	"slices"
+)
+
+// AuditProfile TLS 配置基线，TLS 1.3 的套件均视为合规
+type AuditProfile struct {
+	Name                  string
+	MinVersion            uint16   // 最低允许的协议版本，如 tls.VersionTLS12
+	AllowedCipherSuites   []string // TLS 1.2 及以下允许的套件名称，为空时不限制
+	AllowInsecure         bool     // 是否允许 crypto/tls 标记为不安全的套件
+	RequireForwardSecrecy bool
+	RequireALPN           []string // 必须支持的 ALPN 协议，如 h2
+}
+
+// 内置基线，参考 Mozilla 服务端 TLS 推荐配置
+var (
+	ProfileModern = AuditProfile{
+		Name:                  "modern",
+		MinVersion:            tls.VersionTLS13,
+		RequireForwardSecrecy: true,
+	}
+	ProfileIntermediate = AuditProfile{
+		Name:       "intermediate",
+		MinVersion: tls.VersionTLS12,
+		AllowedCipherSuites: []string{
+			"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256",
+			"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256",
+			"TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384",
+			"TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384",
+			"TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256",
+			"TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256",
+		},
+		RequireForwardSecrecy: true,
+	}
+)
+
+// GetAuditProfile 按名称获取内置基线
+func GetAuditProfile(name string) (AuditProfile, error) {
+	switch name {
+	case "", ProfileIntermediate.Name:
+		return ProfileIntermediate, nil
+	case ProfileModern.Name:
+		return ProfileModern, nil
+	}
+	return AuditProfile{}, fmt.Errorf("unknown tls audit profile: %s", name)
+}
+
+// Evaluate 检查审计结果是否违反基线，返回违规项，没有违规或未开启审计时返回 nil；审计失败本身算一项违规
+func (p AuditProfile) Evaluate(result *TLSScanResult) []string {
+	if result == nil {
+		return nil
+	}
+	if result.AuditError != "" {
+		return []string{"tls audit failed: " + result.AuditError}
+	}
+	if result.Audit == nil {
+		return nil
+	}
+
+	var violations []string
+	for _, version := range result.Audit.Versions {
+		if !version.Supported {
+			continue
+		}
+		if version.ID < p.MinVersion {
+			violations = append(violations, fmt.Sprintf("%s enabled (minimum %s)", version.Version, tls.VersionName(p.MinVersion)))
+		}
+		if version.ID == tls.VersionTLS13 {
+			continue
+		}
+		for _, suite := range version.CipherSuites {
+			switch {
+			case suite.Insecure && !p.AllowInsecure:
+				violations = append(violations, fmt.Sprintf("%s: insecure cipher suite %s", version.Version, suite.Name))
+			case len(p.AllowedCipherSuites) > 0 && !slices.Contains(p.AllowedCipherSuites, suite.Name):
+				violations = append(violations, fmt.Sprintf("%s: cipher suite %s not allowed", version.Version, suite.Name))
+			case p.RequireForwardSecrecy && !suite.ForwardSecrecy:
+				violations = append(violations, fmt.Sprintf("%s: cipher suite %s lacks forward secrecy", version.Version, suite.Name))
+			}
+		}
+	}
+
+	for _, proto := range p.RequireALPN {
+		if !slices.Contains(result.Audit.ALPN, proto) {
+			violations = append(violations, fmt.Sprintf("alpn %s not supported", proto))
+		}
+	}
+	return violations
+}
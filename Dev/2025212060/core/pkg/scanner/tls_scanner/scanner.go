@@ -0,0 +1,141 @@
+package tls_scanner
+
+import (
+	"fmt"
+	"redrock-dashboard/core/pkg/scanner/tls_scanner/tls_lib"
+	"strings"
+	"time"
+)
+
+type TLSScanResult struct {
+	TimeDelay     time.Duration
+	TLSVersion    string
+	CipherSuite   string
+	Leaf          *tls_lib.CertInfo
+	Chain         []tls_lib.CertInfo
+	Trusted       bool
+	VerifyError   string
+	HostnameMatch bool
+	SelfSigned    bool
+	Expired       bool
+	DaysLeft      int
+	NotAfter      time.Time // 链上最早的过期时间
+	OCSPStatus    string
+	Valid         bool // 受信任、主机名匹配、未过期且未被吊销
+
+	Audit      *tls_lib.AuditResult // 开启审计模式时的协议版本、密码套件与 ALPN 枚举结果
+	AuditError string               // 审计失败的原因，证书检测结果不受影响
+}
+
+type TLSScanner struct {
+	Target     string
+	Port       string        // 为空时使用 443，开启 STARTTLS 时使用对应协议的默认端口
+	ServerName string        // SNI 与主机名校验使用的名称，为空时使用 Target
+	CAFile     string        // 自定义 CA 证书包，为空时使用系统根证书
+	Timeout    time.Duration // 为 0 时使用默认值
+	StartTLS   string        // smtp、imap、pop3、ftp、ldap、postgres，为空时直接 TLS
+	Audit      bool          // 审计模式：额外枚举协议版本、密码套件与 ALPN，需要多次握手
+}
+
+// CertificateRule 证书告警规则
+type CertificateRule struct {
+	WarnDays        int  // 距离过期不足多少天时告警，为 0 时默认 14 天
+	AllowSelfSigned bool // 内网服务可放行自签名证书
+	AllowUntrusted  bool // 放行无法验证到根证书的链（如私有 CA 未配置 CAFile）
+}
+
+func (r TLSScanner) Scan() (*TLSScanResult, error) {
+	port := r.Port
+	if port == "" {
+		port = "443"
+		if r.StartTLS != "" {
+			port = tls_lib.StartTLSPorts[strings.ToLower(r.StartTLS)]
+		}
+	}
+
+	opts := []tls_lib.CheckerOption{tls_lib.WithServerName(r.ServerName)}
+	if r.Timeout > 0 {
+		opts = append(opts, tls_lib.WithTimeout(r.Timeout))
+	}
+	if r.CAFile != "" {
+		opts = append(opts, tls_lib.WithCAFile(r.CAFile))
+	}
+	if r.StartTLS != "" {
+		if _, ok := tls_lib.StartTLSPorts[strings.ToLower(r.StartTLS)]; !ok {
+			return nil, fmt.Errorf("unsupported starttls protocol: %s", r.StartTLS)
+		}
+		opts = append(opts, tls_lib.WithStartTLS(r.StartTLS))
+	}
+
+	checker := tls_lib.NewTLSChecker(opts...)
+	result := checker.Check(r.Target, port)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	data := &TLSScanResult{
+		TimeDelay:     result.HandshakeTime,
+		TLSVersion:    result.TLSVersion,
+		CipherSuite:   result.CipherSuite,
+		Leaf:          result.Leaf,
+		Chain:         result.Chain,
+		Trusted:       result.Trusted,
+		VerifyError:   result.VerifyError,
+		HostnameMatch: result.HostnameMatch,
+		SelfSigned:    result.SelfSigned,
+		Expired:       result.Expired,
+		DaysLeft:      result.DaysLeft,
+		NotAfter:      result.ChainNotAfter,
+		OCSPStatus:    result.OCSPStatus,
+	}
+	data.Valid = data.Trusted && data.HostnameMatch && !data.Expired && data.OCSPStatus != tls_lib.OCSPRevoked
+
+	if r.Audit {
+		// 审计失败不影响已完成的证书检测，记录原因交给 AuditProfile 告警
+		audit := checker.Audit(r.Target, port)
+		if audit.Error != nil {
+			data.AuditError = audit.Error.Error()
+		} else {
+			data.Audit = audit
+		}
+	}
+
+	return data, nil
+}
+
+// Evaluate 检查证书是否需要告警，返回告警原因，没有问题时返回 nil
+func (r CertificateRule) Evaluate(result *TLSScanResult) []string {
+	if result == nil {
+		return nil
+	}
+
+	warnDays := r.WarnDays
+	if warnDays == 0 {
+		warnDays = 14
+	}
+
+	var problems []string
+	switch {
+	case result.Expired:
+		problems = append(problems, "certificate expired or not yet valid")
+	case result.DaysLeft < warnDays:
+		problems = append(problems, fmt.Sprintf("certificate expires in %d days", result.DaysLeft))
+	}
+	if !result.Expired && !result.NotAfter.IsZero() && result.Leaf != nil && result.NotAfter.Before(result.Leaf.NotAfter) &&
+		time.Until(result.NotAfter) < time.Duration(warnDays)*24*time.Hour {
+		problems = append(problems, fmt.Sprintf("certificate chain expires at %s", result.NotAfter.Format(time.RFC3339)))
+	}
+	if !result.HostnameMatch {
+		problems = append(problems, "hostname mismatch")
+	}
+	if result.SelfSigned && !r.AllowSelfSigned {
+		problems = append(problems, "self-signed certificate")
+	}
+	if !result.Trusted && !result.SelfSigned && !r.AllowUntrusted {
+		problems = append(problems, "untrusted certificate chain: "+result.VerifyError)
+	}
+	if result.OCSPStatus == tls_lib.OCSPRevoked {
+		problems = append(problems, "certificate revoked (OCSP)")
+	}
+	return problems
+}
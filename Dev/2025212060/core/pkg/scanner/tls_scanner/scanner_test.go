@@ -0,0 +1,269 @@
+package tls_scanner
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newOneShotTLSServer 只完成第一次 TLS 握手，之后的连接直接关闭，使审计无法协商任何版本
+func newOneShotTLSServer(t *testing.T) (host, port string) {
+	certSrv := httptest.NewTLSServer(http.NotFoundHandler())
+	config := &tls.Config{Certificates: certSrv.TLS.Certificates}
+	certSrv.Close()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for first := true; ; first = false {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			if first {
+				tlsConn := tls.Server(conn, config)
+				tlsConn.Handshake()
+				tlsConn.Close()
+				continue
+			}
+			conn.Close()
+		}
+	}()
+	host, port, _ = net.SplitHostPort(ln.Addr().String())
+	return host, port
+}
+
+func TestScanAuditFailureKeepsResult(t *testing.T) {
+	host, port := newOneShotTLSServer(t)
+
+	data, err := TLSScanner{Target: host, Port: port, ServerName: "example.com", Timeout: time.Second, Audit: true}.Scan()
+	if err != nil {
+		t.Fatalf("audit failure should not fail the scan: %v", err)
+	}
+	if data.TLSVersion == "" || data.Leaf == nil {
+		t.Errorf("certificate check result missing: %+v", data)
+	}
+	if data.Audit != nil || data.AuditError == "" {
+		t.Errorf("audit = %+v, error = %q", data.Audit, data.AuditError)
+	}
+	if violations := ProfileIntermediate.Evaluate(data); len(violations) != 1 {
+		t.Errorf("audit failure should be reported as a violation, got %v", violations)
+	}
+}
+
+func TestScanAudit(t *testing.T) {
+	srv := httptest.NewUnstartedServer(http.NotFoundHandler())
+	srv.EnableHTTP2 = true
+	srv.StartTLS()
+	defer srv.Close()
+	host, port, _ := net.SplitHostPort(srv.Listener.Addr().String())
+
+	data, err := TLSScanner{Target: host, Port: port, Timeout: time.Second, Audit: true}.Scan()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.Audit == nil || data.AuditError != "" {
+		t.Fatalf("audit = %+v, error = %q", data.Audit, data.AuditError)
+	}
+	if data.Trusted || data.Valid {
+		t.Error("httptest certificate should not be trusted")
+	}
+	if !slices.Contains(data.Audit.ALPN, "h2") {
+		t.Errorf("ALPN = %v, want h2", data.Audit.ALPN)
+	}
+	profile := AuditProfile{Name: "test", MinVersion: tls.VersionTLS12, RequireALPN: []string{"h2", "h3"}}
+	if violations := profile.Evaluate(data); !slices.Contains(violations, "alpn h3 not supported") {
+		t.Errorf("violations = %v", violations)
+	}
+}
+
+func TestCertificateRuleEvaluate(t *testing.T) {
+	leafExpiry := time.Now().Add(90 * 24 * time.Hour)
+	healthy := &TLSScanResult{Trusted: true, HostnameMatch: true, DaysLeft: 90, NotAfter: leafExpiry}
+	if problems := (CertificateRule{}).Evaluate(healthy); problems != nil {
+		t.Errorf("healthy certificate reported %v", problems)
+	}
+
+	expiring := *healthy
+	expiring.DaysLeft = 3
+	if problems := (CertificateRule{}).Evaluate(&expiring); len(problems) != 1 {
+		t.Errorf("expiring certificate reported %v", problems)
+	}
+	if problems := (CertificateRule{WarnDays: 2}).Evaluate(&expiring); problems != nil {
+		t.Errorf("WarnDays 2 reported %v", problems)
+	}
+
+	selfSigned := &TLSScanResult{SelfSigned: true, HostnameMatch: true, DaysLeft: 90}
+	if problems := (CertificateRule{}).Evaluate(selfSigned); len(problems) != 1 {
+		t.Errorf("self-signed certificate reported %v", problems)
+	}
+	if problems := (CertificateRule{AllowSelfSigned: true}).Evaluate(selfSigned); problems != nil {
+		t.Errorf("allowed self-signed certificate reported %v", problems)
+	}
+}
+
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+// issueCert 按模板签发证书，parent 为 nil 时自签名
+func issueCert(t *testing.T, template *x509.Certificate, parent *testCert) *testCert {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template.SerialNumber = big.NewInt(time.Now().UnixNano())
+	template.BasicConstraintsValid = true
+	if template.NotBefore.IsZero() {
+		template.NotBefore = time.Now().Add(-time.Hour)
+	}
+	if template.NotAfter.IsZero() {
+		template.NotAfter = time.Now().Add(90 * 24 * time.Hour)
+	}
+	if template.IsCA {
+		template.KeyUsage = x509.KeyUsageCertSign
+	}
+
+	issuer, signer := template, key
+	if parent != nil {
+		issuer, signer = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, issuer, &key.PublicKey, signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testCert{cert: cert, key: key}
+}
+
+// newCertServer 用给定的证书链（叶子在前）启动 TLS 服务
+func newCertServer(t *testing.T, chain ...*testCert) (host, port string) {
+	certificate := tls.Certificate{PrivateKey: chain[0].key, Leaf: chain[0].cert}
+	for _, c := range chain {
+		certificate.Certificate = append(certificate.Certificate, c.cert.Raw)
+	}
+	srv := httptest.NewUnstartedServer(http.NotFoundHandler())
+	srv.TLS = &tls.Config{Certificates: []tls.Certificate{certificate}}
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+	host, port, _ = net.SplitHostPort(srv.Listener.Addr().String())
+	return host, port
+}
+
+func writeCAFile(t *testing.T, ca *testCert) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.cert.Raw}), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// 不带 CA 标记的自签名叶子证书也要识别为自签名
+func TestScanSelfSignedLeaf(t *testing.T) {
+	leaf := issueCert(t, &x509.Certificate{Subject: pkix.Name{CommonName: "example.com"}, DNSNames: []string{"example.com"}}, nil)
+	if leaf.cert.IsCA {
+		t.Fatal("test leaf should not be a CA")
+	}
+	host, port := newCertServer(t, leaf)
+
+	data, err := TLSScanner{Target: host, Port: port, ServerName: "example.com", Timeout: time.Second}.Scan()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !data.SelfSigned || !data.Leaf.SelfSigned {
+		t.Error("self-signed non-CA leaf not detected")
+	}
+	if data.Trusted || !data.HostnameMatch || data.Expired {
+		t.Errorf("trusted %v, hostname match %v, expired %v", data.Trusted, data.HostnameMatch, data.Expired)
+	}
+	if problems := (CertificateRule{}).Evaluate(data); !slices.Equal(problems, []string{"self-signed certificate"}) {
+		t.Errorf("problems = %v", problems)
+	}
+}
+
+func TestScanCertificateChain(t *testing.T) {
+	root := issueCert(t, &x509.Certificate{Subject: pkix.Name{CommonName: "Test Root"}, IsCA: true}, nil)
+	caFile := writeCAFile(t, root)
+	expiredAt := time.Now().Add(-24 * time.Hour).Truncate(time.Second)
+	expired := issueCert(t, &x509.Certificate{
+		Subject:   pkix.Name{CommonName: "Expired Intermediate"},
+		IsCA:      true,
+		NotBefore: time.Now().Add(-48 * time.Hour),
+		NotAfter:  expiredAt,
+	}, root)
+	intermediate := issueCert(t, &x509.Certificate{Subject: pkix.Name{CommonName: "Test Intermediate"}, IsCA: true}, root)
+	leafTemplate := func() *x509.Certificate {
+		return &x509.Certificate{Subject: pkix.Name{CommonName: "example.com"}, DNSNames: []string{"example.com"}}
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		host, port := newCertServer(t, issueCert(t, leafTemplate(), intermediate), intermediate)
+		data, err := TLSScanner{Target: host, Port: port, ServerName: "example.com", CAFile: caFile, Timeout: time.Second}.Scan()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !data.Valid || data.SelfSigned || len(data.Chain) != 2 || data.Chain[1].SelfSigned {
+			t.Errorf("result = %+v", data)
+		}
+		if problems := (CertificateRule{}).Evaluate(data); problems != nil {
+			t.Errorf("problems = %v", problems)
+		}
+	})
+
+	t.Run("expired intermediate", func(t *testing.T) {
+		host, port := newCertServer(t, issueCert(t, leafTemplate(), expired), expired)
+		data, err := TLSScanner{Target: host, Port: port, ServerName: "example.com", CAFile: caFile, Timeout: time.Second}.Scan()
+		if err != nil {
+			t.Fatal(err)
+		}
+		// 叶子证书本身有效，但链上的中间证书已经过期
+		if data.Expired || data.Trusted || data.Valid || data.VerifyError == "" {
+			t.Errorf("expired %v, trusted %v, valid %v, verify error %q", data.Expired, data.Trusted, data.Valid, data.VerifyError)
+		}
+		if !data.NotAfter.Equal(expiredAt) || !data.Chain[1].NotAfter.Equal(expiredAt) {
+			t.Errorf("chain not after = %v, intermediate = %v, want %v", data.NotAfter, data.Chain[1].NotAfter, expiredAt)
+		}
+		problems := (CertificateRule{}).Evaluate(data)
+		if len(problems) != 2 || !strings.HasPrefix(problems[0], "certificate chain expires at") ||
+			!strings.HasPrefix(problems[1], "untrusted certificate chain") {
+			t.Errorf("problems = %v", problems)
+		}
+	})
+
+	t.Run("hostname mismatch", func(t *testing.T) {
+		host, port := newCertServer(t, issueCert(t, leafTemplate(), intermediate), intermediate)
+		data, err := TLSScanner{Target: host, Port: port, ServerName: "other.example.org", CAFile: caFile, Timeout: time.Second}.Scan()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if data.HostnameMatch || !data.Trusted || data.Valid {
+			t.Errorf("hostname match %v, trusted %v, valid %v", data.HostnameMatch, data.Trusted, data.Valid)
+		}
+		if problems := (CertificateRule{}).Evaluate(data); !slices.Equal(problems, []string{"hostname mismatch"}) {
+			t.Errorf("problems = %v", problems)
+		}
+	})
+}
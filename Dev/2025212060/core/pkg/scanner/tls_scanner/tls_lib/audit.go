@@ -0,0 +1,182 @@
+package tls_lib
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+)
+
+// AuditVersions 审计时逐个尝试的协议版本（crypto/tls 不支持 SSLv3，无法探测）
+var AuditVersions = []uint16{tls.VersionTLS10, tls.VersionTLS11, tls.VersionTLS12, tls.VersionTLS13}
+
+// AuditALPN 审计时探测的 ALPN 协议
+var AuditALPN = []string{"h2", "http/1.1"}
+
+// CipherInfo 密码套件信息
+type CipherInfo struct {
+	ID             uint16
+	Name           string
+	Insecure       bool // crypto/tls 标记为不安全（RC4、3DES、CBC-SHA256 等）
+	ForwardSecrecy bool // ECDHE 密钥交换或 TLS 1.3
+	AEAD           bool // GCM、CHACHA20-POLY1305 或 TLS 1.3
+}
+
+// VersionSupport 单个协议版本的支持情况
+type VersionSupport struct {
+	ID           uint16
+	Version      string
+	Supported    bool
+	CipherSuites []CipherInfo // 按服务端偏好排序；TLS 1.3 套件无法由客户端限定，只记录协商结果
+}
+
+// AuditResult TLS 配置审计结果
+type AuditResult struct {
+	Address    string
+	ServerName string
+	Versions   []VersionSupport
+	ALPN       []string // 服务端接受的 ALPN 协议
+	Error      error
+}
+
+// SupportedVersions 返回服务端支持的协议版本名称
+func (r *AuditResult) SupportedVersions() []string {
+	var versions []string
+	for _, v := range r.Versions {
+		if v.Supported {
+			versions = append(versions, v.Version)
+		}
+	}
+	return versions
+}
+
+// Audit 枚举服务端支持的协议版本、密码套件与 ALPN（审计接口）
+// 只能发现 crypto/tls 实现了的套件，DHE、CAMELLIA 等服务端独有的套件不会出现在结果中
+func (c *TLSChecker) Audit(host, port string) *AuditResult {
+	result := &AuditResult{
+		Address:    net.JoinHostPort(host, port),
+		ServerName: c.serverName,
+	}
+	if result.ServerName == "" {
+		result.ServerName = host
+	}
+
+	// 先确认端口可达，避免把网络错误当成协议不支持
+	conn, err := net.DialTimeout(c.network, result.Address, c.timeout)
+	if err != nil {
+		result.Error = fmt.Errorf("dial failed: %w", err)
+		return result
+	}
+	conn.Close()
+
+	for _, version := range AuditVersions {
+		support := VersionSupport{ID: version, Version: tls.VersionName(version)}
+		if version == tls.VersionTLS13 {
+			state, err := c.handshake(result.Address, &tls.Config{
+				ServerName: result.ServerName,
+				MinVersion: version,
+				MaxVersion: version,
+			})
+			if err == nil {
+				support.CipherSuites = []CipherInfo{newCipherInfo(state.CipherSuite)}
+			}
+		} else {
+			support.CipherSuites = c.enumerateCipherSuites(result.Address, result.ServerName, version)
+		}
+		support.Supported = len(support.CipherSuites) > 0
+		result.Versions = append(result.Versions, support)
+	}
+
+	for _, proto := range AuditALPN {
+		state, err := c.handshake(result.Address, &tls.Config{
+			ServerName: result.ServerName,
+			MinVersion: tls.VersionTLS10,
+			NextProtos: []string{proto},
+		})
+		if err == nil && state.NegotiatedProtocol == proto {
+			result.ALPN = append(result.ALPN, proto)
+		}
+	}
+
+	if len(result.SupportedVersions()) == 0 {
+		result.Error = fmt.Errorf("no tls version negotiated")
+	}
+	return result
+}
+
+// enumerateCipherSuites 每次握手去掉服务端选中的套件再重试，得到按服务端偏好排序的全部套件
+func (c *TLSChecker) enumerateCipherSuites(addr, serverName string, version uint16) []CipherInfo {
+	var candidates []uint16
+	for _, suite := range append(tls.CipherSuites(), tls.InsecureCipherSuites()...) {
+		if slices.Contains(suite.SupportedVersions, version) {
+			candidates = append(candidates, suite.ID)
+		}
+	}
+
+	var accepted []CipherInfo
+	for len(candidates) > 0 {
+		state, err := c.handshake(addr, &tls.Config{
+			ServerName:   serverName,
+			MinVersion:   version,
+			MaxVersion:   version,
+			CipherSuites: candidates,
+		})
+		if err != nil {
+			break
+		}
+		i := slices.Index(candidates, state.CipherSuite)
+		if i < 0 {
+			break
+		}
+		accepted = append(accepted, newCipherInfo(state.CipherSuite))
+		candidates = slices.Delete(candidates, i, i+1)
+	}
+	return accepted
+}
+
+// handshake 建立一次不校验证书的 TLS 握手并返回连接状态
+func (c *TLSChecker) handshake(addr string, config *tls.Config) (tls.ConnectionState, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	dialer := &net.Dialer{}
+	conn, err := dialer.DialContext(ctx, c.network, addr)
+	if err != nil {
+		return tls.ConnectionState{}, err
+	}
+	defer conn.Close()
+
+	if c.startTLS != "" {
+		if err := upgrade(conn, c.startTLS, c.timeout); err != nil {
+			return tls.ConnectionState{}, err
+		}
+	}
+
+	config.InsecureSkipVerify = true
+	tlsConn := tls.Client(conn, config)
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		return tls.ConnectionState{}, err
+	}
+	return tlsConn.ConnectionState(), nil
+}
+
+func newCipherInfo(id uint16) CipherInfo {
+	info := CipherInfo{ID: id, Name: tls.CipherSuiteName(id)}
+	for _, suite := range tls.InsecureCipherSuites() {
+		if suite.ID == id {
+			info.Insecure = true
+		}
+	}
+
+	tls13 := false
+	for _, suite := range tls.CipherSuites() {
+		if suite.ID == id && slices.Equal(suite.SupportedVersions, []uint16{tls.VersionTLS13}) {
+			tls13 = true
+		}
+	}
+	info.ForwardSecrecy = tls13 || strings.HasPrefix(info.Name, "TLS_ECDHE_")
+	info.AEAD = tls13 || strings.Contains(info.Name, "_GCM_") || strings.Contains(info.Name, "CHACHA20_POLY1305")
+	return info
+}
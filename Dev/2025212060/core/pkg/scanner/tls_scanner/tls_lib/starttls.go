@@ -0,0 +1,316 @@
+package tls_lib
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"time"
+)
+
+// 支持的 STARTTLS 协议
+const (
+	StartTLSSMTP     = "smtp"
+	StartTLSIMAP     = "imap"
+	StartTLSPOP3     = "pop3"
+	StartTLSFTP      = "ftp"
+	StartTLSLDAP     = "ldap"
+	StartTLSPostgres = "postgres"
+)
+
+// StartTLSPorts 各协议的默认端口
+var StartTLSPorts = map[string]string{
+	StartTLSSMTP:     "25",
+	StartTLSIMAP:     "143",
+	StartTLSPOP3:     "110",
+	StartTLSFTP:      "21",
+	StartTLSLDAP:     "389",
+	StartTLSPostgres: "5432",
+}
+
+// WithStartTLS 握手前先用明文协议对话升级到 TLS
+func WithStartTLS(protocol string) CheckerOption {
+	return func(c *TLSChecker) { c.startTLS = strings.ToLower(protocol) }
+}
+
+// ldapStartTLSRequest LDAPv3 扩展操作 StartTLS（OID 1.3.6.1.4.1.1466.20037），messageID 为 1
+var ldapStartTLSRequest = []byte{
+	0x30, 0x1d, // LDAPMessage SEQUENCE
+	0x02, 0x01, 0x01, // messageID
+	0x77, 0x18, // [APPLICATION 23] ExtendedRequest
+	0x80, 0x16, // [0] requestName
+	'1', '.', '3', '.', '6', '.', '1', '.', '4', '.', '1', '.',
+	'1', '4', '6', '6', '.', '2', '0', '0', '3', '7',
+}
+
+// postgresSSLRequest 长度 8 + 魔数 80877103
+var postgresSSLRequest = []byte{0x00, 0x00, 0x00, 0x08, 0x04, 0xd2, 0x16, 0x2f}
+
+// upgrade 在明文连接上完成 STARTTLS 对话，返回后即可开始 TLS 握手
+func upgrade(conn net.Conn, protocol string, timeout time.Duration) error {
+	if err := conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		return err
+	}
+	defer conn.SetDeadline(time.Time{})
+
+	r := bufio.NewReader(conn)
+	var err error
+	switch protocol {
+	case StartTLSSMTP:
+		err = startSMTP(conn, r)
+	case StartTLSIMAP:
+		err = startIMAP(conn, r)
+	case StartTLSPOP3:
+		err = startPOP3(conn, r)
+	case StartTLSFTP:
+		err = startFTP(conn, r)
+	case StartTLSLDAP:
+		err = startLDAP(conn, r)
+	case StartTLSPostgres:
+		err = startPostgres(conn, r)
+	default:
+		return fmt.Errorf("unsupported starttls protocol: %s", protocol)
+	}
+	if err != nil {
+		return fmt.Errorf("%s starttls failed: %w", protocol, err)
+	}
+	// 服务端在收到 ClientHello 前不应再发送数据，缓冲区里有残留说明对话不同步
+	if r.Buffered() > 0 {
+		return fmt.Errorf("%s starttls failed: unexpected data after upgrade", protocol)
+	}
+	return nil
+}
+
+// readReply 读取 SMTP/FTP 风格的应答（多行以 "250-" 续行，"250 " 结束），返回状态码与全部文本
+func readReply(r *bufio.Reader) (string, []string, error) {
+	var lines []string
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return "", lines, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		lines = append(lines, line)
+		if len(line) < 3 {
+			continue
+		}
+		if len(line) == 3 || line[3] == ' ' {
+			return line[:3], lines, nil
+		}
+	}
+}
+
+func expectReply(r *bufio.Reader, code string) ([]string, error) {
+	got, lines, err := readReply(r)
+	if err != nil {
+		return lines, err
+	}
+	if got != code {
+		return lines, fmt.Errorf("unexpected reply: %s", strings.Join(lines, " | "))
+	}
+	return lines, nil
+}
+
+func startSMTP(w io.Writer, r *bufio.Reader) error {
+	if _, err := expectReply(r, "220"); err != nil {
+		return err
+	}
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "localhost"
+	}
+	if _, err := fmt.Fprintf(w, "EHLO %s\r\n", hostname); err != nil {
+		return err
+	}
+	lines, err := expectReply(r, "250")
+	if err != nil {
+		return err
+	}
+	supported := false
+	for _, line := range lines {
+		if len(line) > 4 && strings.EqualFold(strings.TrimSpace(line[4:]), "STARTTLS") {
+			supported = true
+		}
+	}
+	if !supported {
+		return errors.New("server does not advertise STARTTLS")
+	}
+	if _, err := io.WriteString(w, "STARTTLS\r\n"); err != nil {
+		return err
+	}
+	_, err = expectReply(r, "220")
+	return err
+}
+
+func startFTP(w io.Writer, r *bufio.Reader) error {
+	if _, err := expectReply(r, "220"); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, "AUTH TLS\r\n"); err != nil {
+		return err
+	}
+	_, err := expectReply(r, "234")
+	return err
+}
+
+func startIMAP(w io.Writer, r *bufio.Reader) error {
+	greeting, err := r.ReadString('\n')
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(greeting, "* OK") {
+		return fmt.Errorf("unexpected greeting: %s", strings.TrimSpace(greeting))
+	}
+	if _, err := io.WriteString(w, "a001 STARTTLS\r\n"); err != nil {
+		return err
+	}
+	// 跳过未标记的应答，直到收到带标签的结果
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		if !strings.HasPrefix(line, "a001 ") {
+			continue
+		}
+		if !strings.HasPrefix(strings.ToUpper(line[5:]), "OK") {
+			return fmt.Errorf("unexpected reply: %s", strings.TrimSpace(line))
+		}
+		return nil
+	}
+}
+
+func startPOP3(w io.Writer, r *bufio.Reader) error {
+	greeting, err := r.ReadString('\n')
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(greeting, "+OK") {
+		return fmt.Errorf("unexpected greeting: %s", strings.TrimSpace(greeting))
+	}
+	if _, err := io.WriteString(w, "STLS\r\n"); err != nil {
+		return err
+	}
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(line, "+OK") {
+		return fmt.Errorf("unexpected reply: %s", strings.TrimSpace(line))
+	}
+	return nil
+}
+
+func startPostgres(w io.Writer, r *bufio.Reader) error {
+	if _, err := w.Write(postgresSSLRequest); err != nil {
+		return err
+	}
+	b, err := r.ReadByte()
+	if err != nil {
+		return err
+	}
+	if b != 'S' {
+		return errors.New("server refused ssl")
+	}
+	return nil
+}
+
+func startLDAP(w io.Writer, r *bufio.Reader) error {
+	if _, err := w.Write(ldapStartTLSRequest); err != nil {
+		return err
+	}
+
+	tag, msg, err := readBER(r)
+	if err != nil {
+		return err
+	}
+	if tag != 0x30 {
+		return fmt.Errorf("unexpected ldap message tag 0x%02x", tag)
+	}
+	// LDAPMessage: messageID, ExtendedResponse{resultCode, ...}
+	_, _, msg, err = splitBER(msg)
+	if err != nil {
+		return err
+	}
+	tag, resp, _, err := splitBER(msg)
+	if err != nil {
+		return err
+	}
+	if tag != 0x78 {
+		return fmt.Errorf("unexpected ldap response tag 0x%02x", tag)
+	}
+	tag, code, _, err := splitBER(resp)
+	if err != nil {
+		return err
+	}
+	if tag != 0x0a || len(code) != 1 {
+		return errors.New("malformed ldap result code")
+	}
+	if code[0] != 0 {
+		return fmt.Errorf("ldap result code %d", code[0])
+	}
+	return nil
+}
+
+// readBER 从流中读取一个完整的 BER TLV
+func readBER(r *bufio.Reader) (byte, []byte, error) {
+	tag, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	length, err := readBERLength(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	if length > 1<<16 {
+		return 0, nil, errors.New("ldap response too large")
+	}
+	value := make([]byte, length)
+	if _, err := io.ReadFull(r, value); err != nil {
+		return 0, nil, err
+	}
+	return tag, value, nil
+}
+
+func readBERLength(r io.ByteReader) (int, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	if b&0x80 == 0 {
+		return int(b), nil
+	}
+	n := int(b & 0x7f)
+	if n == 0 || n > 4 {
+		return 0, errors.New("unsupported ber length")
+	}
+	buf := make([]byte, 4)
+	for i := 4 - n; i < 4; i++ {
+		if buf[i], err = r.ReadByte(); err != nil {
+			return 0, err
+		}
+	}
+	return int(binary.BigEndian.Uint32(buf)), nil
+}
+
+// splitBER 拆出第一个 TLV，返回标签、值与剩余数据
+func splitBER(data []byte) (byte, []byte, []byte, error) {
+	if len(data) < 2 {
+		return 0, nil, nil, io.ErrUnexpectedEOF
+	}
+	r := bytes.NewReader(data[1:])
+	length, err := readBERLength(r)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	header := len(data) - r.Len()
+	if header+length > len(data) {
+		return 0, nil, nil, io.ErrUnexpectedEOF
+	}
+	return data[0], data[header : header+length], data[header+length:], nil
+}
@@ -0,0 +1,385 @@
+package tls_lib
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"io"
+	"net"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// exchange 脚本化服务端的一步：先读到 expect（为空则不读），再回复 reply
+// line 为真时 expect 只匹配一整行的前缀，用于带主机名的 EHLO
+type exchange struct {
+	expect string
+	line   bool
+	reply  string
+}
+
+// runServer 在 net.Pipe 的服务端按脚本对话，结束后可选地完成 TLS 握手
+func runServer(t *testing.T, conn net.Conn, script []exchange, tlsConfig *tls.Config) <-chan error {
+	t.Helper()
+	done := make(chan error, 1)
+	go func() {
+		defer close(done)
+		r := bufio.NewReader(conn)
+		for _, step := range script {
+			if step.expect != "" {
+				var got string
+				var err error
+				if step.line {
+					got, err = r.ReadString('\n')
+				} else {
+					buf := make([]byte, len(step.expect))
+					_, err = io.ReadFull(r, buf)
+					got = string(buf)
+				}
+				if err != nil {
+					done <- err
+					return
+				}
+				if !strings.HasPrefix(got, step.expect) || (!step.line && got != step.expect) {
+					done <- errors.New("unexpected client data: " + got)
+					return
+				}
+			}
+			if step.reply != "" {
+				if _, err := io.WriteString(conn, step.reply); err != nil {
+					done <- err
+					return
+				}
+			}
+		}
+		if tlsConfig != nil {
+			done <- tls.Server(conn, tlsConfig).Handshake()
+		}
+	}()
+	return done
+}
+
+// testServerTLSConfig 借用 httptest 的自签证书
+func testServerTLSConfig(t *testing.T) *tls.Config {
+	t.Helper()
+	ts := httptest.NewUnstartedServer(nil)
+	ts.StartTLS()
+	t.Cleanup(ts.Close)
+	return ts.TLS.Clone()
+}
+
+func TestUpgradeThenHandshake(t *testing.T) {
+	serverTLS := testServerTLSConfig(t)
+	tests := []struct {
+		protocol string
+		script   []exchange
+	}{
+		{StartTLSSMTP, []exchange{
+			{reply: "220 mail.example.com ESMTP\r\n"},
+			{expect: "EHLO ", line: true, reply: "250-mail.example.com\r\n250-PIPELINING\r\n250 STARTTLS\r\n"},
+			{expect: "STARTTLS\r\n", reply: "220 ready\r\n"},
+		}},
+		{StartTLSFTP, []exchange{
+			{reply: "220 ftp ready\r\n"},
+			{expect: "AUTH TLS\r\n", reply: "234 proceed\r\n"},
+		}},
+		{StartTLSIMAP, []exchange{
+			{reply: "* OK [CAPABILITY IMAP4rev1 STARTTLS] ready\r\n"},
+			{expect: "a001 STARTTLS\r\n", reply: "a001 OK begin TLS\r\n"},
+		}},
+		{StartTLSPOP3, []exchange{
+			{reply: "+OK POP3 ready\r\n"},
+			{expect: "STLS\r\n", reply: "+OK begin TLS\r\n"},
+		}},
+		{StartTLSPostgres, []exchange{
+			{expect: string(postgresSSLRequest), reply: "S"},
+		}},
+		{StartTLSLDAP, []exchange{
+			{expect: string(ldapStartTLSRequest), reply: ldapResponse(0)},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.protocol, func(t *testing.T) {
+			client, server := net.Pipe()
+			defer client.Close()
+			defer server.Close()
+
+			done := runServer(t, server, tt.script, serverTLS)
+			if err := upgrade(client, tt.protocol, 2*time.Second); err != nil {
+				t.Fatalf("upgrade: %v", err)
+			}
+			tc := tls.Client(client, &tls.Config{InsecureSkipVerify: true})
+			if err := tc.Handshake(); err != nil {
+				t.Fatalf("client handshake: %v", err)
+			}
+			if err := <-done; err != nil {
+				t.Fatalf("server: %v", err)
+			}
+		})
+	}
+}
+
+func TestUpgradeErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		protocol string
+		script   []exchange
+		want     string
+	}{
+		{"unsupported", "xmpp", nil, "unsupported starttls protocol"},
+		{"trailing data", StartTLSPOP3, []exchange{
+			{reply: "+OK ready\r\n"},
+			{expect: "STLS\r\n", reply: "+OK go ahead\r\n+OK extra\r\n"},
+		}, "unexpected data after upgrade"},
+		{"refused", StartTLSPostgres, []exchange{
+			{expect: string(postgresSSLRequest), reply: "N"},
+		}, "server refused ssl"},
+		{"timeout", StartTLSIMAP, nil, "i/o timeout"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, server := net.Pipe()
+			defer client.Close()
+			defer server.Close()
+			runServer(t, server, tt.script, nil)
+
+			err := upgrade(client, tt.protocol, 100*time.Millisecond)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("err = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadReply(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		wantCode  string
+		wantLines int
+		wantErr   bool
+	}{
+		{"single", "220 ready\r\n", "220", 1, false},
+		{"multiline", "250-a\r\n250-b\r\n250 c\r\n", "250", 3, false},
+		{"bare code", "250\r\n", "250", 1, false},
+		{"short line skipped", "x\r\n421 bye\r\n", "421", 2, false},
+		{"truncated", "250-a\r\n250-b", "", 1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, lines, err := readReply(bufio.NewReader(strings.NewReader(tt.input)))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if code != tt.wantCode || len(lines) != tt.wantLines {
+				t.Fatalf("code = %q lines = %q", code, lines)
+			}
+		})
+	}
+}
+
+func TestExpectReplyMismatch(t *testing.T) {
+	_, err := expectReply(bufio.NewReader(strings.NewReader("554-no\r\n554 service\r\n")), "220")
+	if err == nil || !strings.Contains(err.Error(), "554-no | 554 service") {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+// dialogue 用固定的服务端应答驱动一次对话，返回客户端写出的内容
+func dialogue(start func(io.Writer, *bufio.Reader) error, replies string) (string, error) {
+	var w bytes.Buffer
+	err := start(&w, bufio.NewReader(strings.NewReader(replies)))
+	return w.String(), err
+}
+
+func TestStartSMTP(t *testing.T) {
+	sent, err := dialogue(startSMTP, "220 hi\r\n250-mx\r\n250-starttls\r\n250 SIZE 1000\r\n220 go\r\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(sent, "EHLO ") || !strings.HasSuffix(sent, "\r\nSTARTTLS\r\n") {
+		t.Fatalf("sent %q", sent)
+	}
+
+	sent, err = dialogue(startSMTP, "220 hi\r\n250-mx\r\n250 AUTH PLAIN\r\n")
+	if err == nil || !strings.Contains(err.Error(), "does not advertise STARTTLS") {
+		t.Fatalf("err = %v", err)
+	}
+	if strings.Contains(sent, "STARTTLS") {
+		t.Fatalf("sent STARTTLS to a server without it: %q", sent)
+	}
+
+	if _, err := dialogue(startSMTP, "554 go away\r\n"); err == nil {
+		t.Fatal("expected error for 554 greeting")
+	}
+	if _, err := dialogue(startSMTP, "220 hi\r\n250 STARTTLS\r\n454 TLS not available\r\n"); err == nil {
+		t.Fatal("expected error for 454 reply")
+	}
+}
+
+func TestStartFTP(t *testing.T) {
+	sent, err := dialogue(startFTP, "220-welcome\r\n220 ready\r\n234 AUTH TLS OK\r\n")
+	if err != nil || sent != "AUTH TLS\r\n" {
+		t.Fatalf("sent %q err %v", sent, err)
+	}
+	if _, err := dialogue(startFTP, "220 ready\r\n530 not allowed\r\n"); err == nil {
+		t.Fatal("expected error for 530 reply")
+	}
+}
+
+func TestStartIMAP(t *testing.T) {
+	sent, err := dialogue(startIMAP, "* OK ready\r\n* CAPABILITY IMAP4rev1\r\na001 ok begin\r\n")
+	if err != nil || sent != "a001 STARTTLS\r\n" {
+		t.Fatalf("sent %q err %v", sent, err)
+	}
+	if _, err := dialogue(startIMAP, "* BYE busy\r\n"); err == nil {
+		t.Fatal("expected error for BYE greeting")
+	}
+	if _, err := dialogue(startIMAP, "* OK ready\r\na001 BAD unknown command\r\n"); err == nil {
+		t.Fatal("expected error for BAD reply")
+	}
+	if _, err := dialogue(startIMAP, "* OK ready\r\n* CAPABILITY IMAP4rev1\r\n"); !errors.Is(err, io.EOF) {
+		t.Fatalf("err = %v, want EOF", err)
+	}
+}
+
+func TestStartPOP3(t *testing.T) {
+	sent, err := dialogue(startPOP3, "+OK ready\r\n+OK begin TLS\r\n")
+	if err != nil || sent != "STLS\r\n" {
+		t.Fatalf("sent %q err %v", sent, err)
+	}
+	if _, err := dialogue(startPOP3, "-ERR busy\r\n"); err == nil {
+		t.Fatal("expected error for -ERR greeting")
+	}
+	if _, err := dialogue(startPOP3, "+OK ready\r\n-ERR command not supported\r\n"); err == nil {
+		t.Fatal("expected error for -ERR reply")
+	}
+}
+
+func TestStartPostgres(t *testing.T) {
+	sent, err := dialogue(startPostgres, "S")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Int32 长度 8 + Int32 魔数 80877103
+	want := []byte{0, 0, 0, 8, 0x04, 0xd2, 0x16, 0x2f}
+	if !bytes.Equal([]byte(sent), want) {
+		t.Fatalf("sent % x, want % x", sent, want)
+	}
+	if _, err := dialogue(startPostgres, "N"); err == nil {
+		t.Fatal("expected error for N")
+	}
+	if _, err := dialogue(startPostgres, ""); !errors.Is(err, io.EOF) {
+		t.Fatalf("err = %v, want EOF", err)
+	}
+}
+
+// ldapResponse 构造 messageID 为 1 的 ExtendedResponse
+func ldapResponse(code byte) string {
+	return string([]byte{0x30, 0x0c, 0x02, 0x01, 0x01, 0x78, 0x07, 0x0a, 0x01, code, 0x04, 0x00, 0x04, 0x00})
+}
+
+func TestStartLDAP(t *testing.T) {
+	sent, err := dialogue(startLDAP, ldapResponse(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// SEQUENCE 的长度与实际内容一致，requestName 是 StartTLS 的 OID
+	if len(sent) != 2+int(sent[1]) {
+		t.Fatalf("request length byte %d does not match %d bytes", sent[1], len(sent))
+	}
+	if !strings.HasSuffix(sent, "\x80\x161.3.6.1.4.1.1466.20037") {
+		t.Fatalf("sent % x", sent)
+	}
+
+	tests := []struct {
+		name  string
+		reply string
+		want  string
+	}{
+		{"unavailable", ldapResponse(52), "ldap result code 52"},
+		{"not a sequence", "\x31\x00", "unexpected ldap message tag 0x31"},
+		{"wrong op", "\x30\x08\x02\x01\x01\x65\x03\x0a\x01\x00", "unexpected ldap response tag 0x65"},
+		{"bad result code", "\x30\x09\x02\x01\x01\x78\x04\x0a\x02\x00\x00", "malformed ldap result code"},
+		{"truncated", "\x30\x0c\x02\x01\x01", "EOF"},
+		{"too large", "\x30\x83\x01\x00\x01", "too large"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := dialogue(startLDAP, tt.reply)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("err = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadBERLength(t *testing.T) {
+	tests := []struct {
+		input   []byte
+		want    int
+		wantErr bool
+	}{
+		{[]byte{0x05}, 5, false},
+		{[]byte{0x7f}, 127, false},
+		{[]byte{0x81, 0x80}, 128, false},
+		{[]byte{0x82, 0x01, 0x00}, 256, false},
+		{[]byte{0x84, 0x00, 0x01, 0x00, 0x00}, 65536, false},
+		{[]byte{0x80}, 0, true},                // 不定长格式
+		{[]byte{0x85, 1, 2, 3, 4, 5}, 0, true}, // 超过 4 字节
+		{[]byte{0x82, 0x01}, 0, true},          // 截断
+		{nil, 0, true},
+	}
+	for _, tt := range tests {
+		got, err := readBERLength(bytes.NewReader(tt.input))
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("readBERLength(% x) = %d, %v", tt.input, got, err)
+		}
+	}
+}
+
+func TestSplitBER(t *testing.T) {
+	tag, value, rest, err := splitBER([]byte{0x02, 0x01, 0x07, 0x04, 0x00})
+	if err != nil || tag != 0x02 || !bytes.Equal(value, []byte{0x07}) || !bytes.Equal(rest, []byte{0x04, 0x00}) {
+		t.Fatalf("tag 0x%02x value % x rest % x err %v", tag, value, rest, err)
+	}
+
+	long := append([]byte{0x04, 0x81, 0x80}, bytes.Repeat([]byte{'x'}, 0x80)...)
+	_, value, rest, err = splitBER(long)
+	if err != nil || len(value) != 0x80 || len(rest) != 0 {
+		t.Fatalf("long form: len %d rest %d err %v", len(value), len(rest), err)
+	}
+
+	for _, bad := range [][]byte{nil, {0x02}, {0x02, 0x05, 0x01}, {0x02, 0x84, 0xff, 0xff, 0xff, 0xff}} {
+		if _, _, _, err := splitBER(bad); err == nil {
+			t.Errorf("splitBER(% x) succeeded", bad)
+		}
+	}
+}
+
+func FuzzSplitBER(f *testing.F) {
+	f.Add([]byte{0x02, 0x01, 0x07})
+	f.Add([]byte(ldapResponse(0)))
+	f.Add([]byte{0x04, 0x84, 0x7f, 0xff, 0xff, 0xff})
+	f.Fuzz(func(t *testing.T, data []byte) {
+		_, value, rest, err := splitBER(data)
+		if err != nil {
+			return
+		}
+		if len(value)+len(rest) >= len(data) {
+			t.Fatalf("value %d + rest %d not shorter than input %d", len(value), len(rest), len(data))
+		}
+	})
+}
+
+func FuzzStartLDAP(f *testing.F) {
+	f.Add([]byte(ldapResponse(0)))
+	f.Add([]byte(ldapResponse(52)))
+	f.Add([]byte{0x30, 0x83, 0x01, 0x00, 0x01})
+	f.Fuzz(func(t *testing.T, reply []byte) {
+		dialogue(startLDAP, string(reply))
+	})
+}
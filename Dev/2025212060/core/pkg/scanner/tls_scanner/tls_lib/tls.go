@@ -0,0 +1,301 @@
+package tls_lib
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+)
+
+// OCSP 装订状态
+const (
+	OCSPNotStapled = "not-stapled"
+	OCSPGood       = "good"
+	OCSPRevoked    = "revoked"
+	OCSPUnknown    = "unknown"
+	OCSPInvalid    = "invalid" // 装订的响应无法解析或签名不对
+)
+
+// TLSChecker TLS 证书检测器结构体
+type TLSChecker struct {
+	timeout    time.Duration
+	serverName string // 为空时使用目标主机名
+	caFile     string // 自定义 CA 证书包（PEM），为空时使用系统根证书
+	network    string
+	startTLS   string // 非空时先进行对应协议的 STARTTLS 对话
+}
+
+// CertInfo 单张证书的信息
+type CertInfo struct {
+	Subject            string
+	CommonName         string
+	DNSNames           []string
+	IPAddresses        []string
+	Issuer             string
+	SerialNumber       string // 十六进制
+	NotBefore          time.Time
+	NotAfter           time.Time
+	KeyType            string // RSA、ECDSA、Ed25519
+	KeySize            int    // 位数，Ed25519 固定为 256
+	SignatureAlgorithm string
+	IsCA               bool
+	SelfSigned         bool
+	SHA256Fingerprint  string
+}
+
+// CheckResult 检测结果
+type CheckResult struct {
+	Address       string
+	ServerName    string
+	TLSVersion    string
+	CipherSuite   string
+	HandshakeTime time.Duration
+
+	Leaf  *CertInfo
+	Chain []CertInfo // 服务端发送的证书链，第一张为叶子证书
+
+	Trusted       bool   // 能否用系统或自定义 CA 验证到根
+	VerifyError   string // 验证失败原因
+	HostnameMatch bool
+	SelfSigned    bool
+	Expired       bool
+	DaysLeft      int       // 叶子证书剩余天数，过期为负数
+	ChainNotAfter time.Time // 链上最早过期的时间
+
+	OCSPStatus    string
+	OCSPRevokedAt time.Time
+
+	Error error
+}
+
+// CheckerOption 配置选项
+type CheckerOption func(*TLSChecker)
+
+// NewTLSChecker 创建检测器
+func NewTLSChecker(opts ...CheckerOption) *TLSChecker {
+	c := &TLSChecker{
+		timeout: 10 * time.Second,
+		network: "tcp",
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+func WithTimeout(d time.Duration) CheckerOption {
+	return func(c *TLSChecker) { c.timeout = d }
+}
+
+func WithServerName(name string) CheckerOption {
+	return func(c *TLSChecker) { c.serverName = name }
+}
+
+func WithCAFile(path string) CheckerOption {
+	return func(c *TLSChecker) { c.caFile = path }
+}
+
+func WithIPv4() CheckerOption {
+	return func(c *TLSChecker) { c.network = "tcp4" }
+}
+
+func WithIPv6() CheckerOption {
+	return func(c *TLSChecker) { c.network = "tcp6" }
+}
+
+// rootPool 读取自定义 CA，未配置时返回 nil 表示使用系统根证书
+func (c *TLSChecker) rootPool() (*x509.CertPool, error) {
+	if c.caFile == "" {
+		return nil, nil
+	}
+	pem, err := os.ReadFile(c.caFile)
+	if err != nil {
+		return nil, fmt.Errorf("read ca file failed: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificate found in %s", c.caFile)
+	}
+	return pool, nil
+}
+
+// Check 检测单个 host:port 的证书（唯一对外接口）
+func (c *TLSChecker) Check(host, port string) *CheckResult {
+	result := &CheckResult{
+		Address:    net.JoinHostPort(host, port),
+		ServerName: c.serverName,
+		OCSPStatus: OCSPNotStapled,
+	}
+	if result.ServerName == "" {
+		result.ServerName = host
+	}
+
+	roots, err := c.rootPool()
+	if err != nil {
+		result.Error = err
+		return result
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	dialer := &net.Dialer{}
+	conn, err := dialer.DialContext(ctx, c.network, result.Address)
+	if err != nil {
+		result.Error = fmt.Errorf("dial failed: %w", err)
+		return result
+	}
+	defer conn.Close()
+
+	if c.startTLS != "" {
+		if err := upgrade(conn, c.startTLS, c.timeout); err != nil {
+			result.Error = err
+			return result
+		}
+	}
+
+	// 先不校验，握手后再自行验证，这样无效证书也能拿到完整信息
+	tlsConn := tls.Client(conn, &tls.Config{
+		ServerName:         result.ServerName,
+		InsecureSkipVerify: true,
+	})
+
+	start := time.Now()
+	err = tlsConn.HandshakeContext(ctx)
+	result.HandshakeTime = time.Since(start)
+	if err != nil {
+		result.Error = fmt.Errorf("tls handshake failed: %w", err)
+		return result
+	}
+
+	state := tlsConn.ConnectionState()
+	result.TLSVersion = tls.VersionName(state.Version)
+	result.CipherSuite = tls.CipherSuiteName(state.CipherSuite)
+	inspect(result, state, roots)
+
+	return result
+}
+
+// inspect 根据握手状态填充证书、信任链、主机名与 OCSP 信息
+func inspect(result *CheckResult, state tls.ConnectionState, roots *x509.CertPool) {
+	certs := state.PeerCertificates
+	if len(certs) == 0 {
+		result.Error = fmt.Errorf("server sent no certificate")
+		return
+	}
+	leaf := certs[0]
+
+	for _, cert := range certs {
+		result.Chain = append(result.Chain, newCertInfo(cert))
+	}
+	result.Leaf = &result.Chain[0]
+	result.SelfSigned = result.Leaf.SelfSigned
+
+	now := time.Now()
+	result.Expired = now.After(leaf.NotAfter) || now.Before(leaf.NotBefore)
+	result.DaysLeft = int(leaf.NotAfter.Sub(now).Hours() / 24)
+	if leaf.NotAfter.Before(now) {
+		result.DaysLeft = -int(now.Sub(leaf.NotAfter).Hours()/24) - 1
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+	chains, err := leaf.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   now,
+	})
+	if err != nil {
+		result.VerifyError = err.Error()
+	} else {
+		result.Trusted = true
+	}
+
+	// 链上最早过期的证书决定整条链何时失效
+	chain := certs
+	if len(chains) > 0 {
+		chain = chains[0]
+	}
+	result.ChainNotAfter = leaf.NotAfter
+	for _, cert := range chain {
+		if cert.NotAfter.Before(result.ChainNotAfter) {
+			result.ChainNotAfter = cert.NotAfter
+		}
+	}
+
+	result.HostnameMatch = leaf.VerifyHostname(result.ServerName) == nil
+
+	if len(state.OCSPResponse) > 0 {
+		var issuer *x509.Certificate
+		if len(chain) > 1 {
+			issuer = chain[1]
+		}
+		result.OCSPStatus, result.OCSPRevokedAt = parseOCSP(state.OCSPResponse, leaf, issuer)
+	}
+}
+
+func parseOCSP(raw []byte, leaf, issuer *x509.Certificate) (string, time.Time) {
+	resp, err := ocsp.ParseResponseForCert(raw, leaf, issuer)
+	if err != nil {
+		return OCSPInvalid, time.Time{}
+	}
+	switch resp.Status {
+	case ocsp.Good:
+		return OCSPGood, time.Time{}
+	case ocsp.Revoked:
+		return OCSPRevoked, resp.RevokedAt
+	}
+	return OCSPUnknown, time.Time{}
+}
+
+func newCertInfo(cert *x509.Certificate) CertInfo {
+	info := CertInfo{
+		Subject:            cert.Subject.String(),
+		CommonName:         cert.Subject.CommonName,
+		DNSNames:           cert.DNSNames,
+		Issuer:             cert.Issuer.String(),
+		SerialNumber:       hex.EncodeToString(cert.SerialNumber.Bytes()),
+		NotBefore:          cert.NotBefore,
+		NotAfter:           cert.NotAfter,
+		SignatureAlgorithm: cert.SignatureAlgorithm.String(),
+		IsCA:               cert.IsCA,
+	}
+	for _, ip := range cert.IPAddresses {
+		info.IPAddresses = append(info.IPAddresses, ip.String())
+	}
+
+	switch key := cert.PublicKey.(type) {
+	case *rsa.PublicKey:
+		info.KeyType, info.KeySize = "RSA", key.N.BitLen()
+	case *ecdsa.PublicKey:
+		info.KeyType, info.KeySize = "ECDSA", key.Curve.Params().BitSize
+	case ed25519.PublicKey:
+		info.KeyType, info.KeySize = "Ed25519", 256
+	default:
+		info.KeyType = cert.PublicKeyAlgorithm.String()
+	}
+
+	// 自签名：颁发者与主体相同，且能用自身公钥验证签名。
+	// 不用 CheckSignatureFrom，它要求签发者是 CA，会漏掉不带 CA 标记的自签名叶子证书
+	if bytes.Equal(cert.RawSubject, cert.RawIssuer) &&
+		cert.CheckSignature(cert.SignatureAlgorithm, cert.RawTBSCertificate, cert.Signature) == nil {
+		info.SelfSigned = true
+	}
+
+	sum := sha256.Sum256(cert.Raw)
+	info.SHA256Fingerprint = hex.EncodeToString(sum[:])
+	return info
+}
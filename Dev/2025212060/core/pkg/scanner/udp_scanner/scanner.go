@@ -0,0 +1,53 @@
+package udp_scanner
+
+import (
+	"net"
+	vscan "redrock-dashboard/core/pkg/scanner/tcp_scanner/service_lib"
+	"time"
+)
+
+type UDPScanResult struct {
+	TimeDelay     time.Duration
+	State         string // open、closed、open|filtered
+	Open          bool   // 只有收到响应才算开放，open|filtered 不算
+	Service       string
+	ServiceBanner string
+}
+
+type UDPScanner struct {
+	Target           string
+	Port             string
+	Timeout          time.Duration // 整次探测耗时上限，为 0 时使用默认值
+	VersionIntensity int           // 探针强度 1-9，为 0 时使用默认值 7
+}
+
+func (r UDPScanner) Scan() (*UDPScanResult, error) {
+	opts := []vscan.ExploreOption{vscan.WithScanTimeout(10 * time.Second)}
+	if r.Timeout > 0 {
+		opts = append(opts, vscan.WithScanTimeout(r.Timeout))
+	}
+	if r.VersionIntensity > 0 {
+		opts = append(opts, vscan.WithIntensity(r.VersionIntensity))
+	}
+
+	probes, err := vscan.Shared()
+	if err != nil {
+		return nil, err
+	}
+	result, err := probes.ExploreUDP(net.JoinHostPort(r.Target, r.Port), opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	data := &UDPScanResult{
+		TimeDelay: result.RTT,
+		State:     result.State,
+		Open:      result.State == vscan.PortOpen,
+		Service:   result.Service.Name,
+	}
+	if data.Open {
+		data.ServiceBanner = result.Describe()
+	}
+
+	return data, nil
+}
@@ -0,0 +1,150 @@
+package web_scanner
+
+import (
+	"fmt"
+	"redrock-dashboard/core/pkg/scanner/web_scanner/playwright_lib"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 页面指标名称
+const (
+	MetricConsoleErrors  = "console_errors"
+	MetricExceptions     = "exceptions"
+	MetricFailedRequests = "failed_requests"
+	MetricRequests       = "requests"
+	MetricPageWeight     = "page_weight" // 字节
+	MetricLCP            = "lcp"
+	MetricCLS            = "cls"
+	MetricINP            = "inp"
+	MetricTBT            = "tbt"
+	MetricFCP            = "fcp"
+	MetricTTFB           = "ttfb"
+)
+
+// timeMetrics 时间类指标，规则的值可以写成 2.5s、300ms，纯数字按毫秒处理
+var timeMetrics = map[string]bool{
+	MetricLCP: true, MetricINP: true, MetricTBT: true, MetricFCP: true, MetricTTFB: true,
+}
+
+// MetricRule 页面指标告警规则，条件成立时告警，如 console_errors > 0、lcp > 2.5s
+type MetricRule struct {
+	Metric   string
+	Operator string // >、>=、<、<=、==、!=
+	Value    string
+}
+
+// MetricAlert 单条规则的结果
+type MetricAlert struct {
+	MetricRule
+	Triggered bool
+	Actual    string
+}
+
+// String 返回可读的规则描述
+func (r MetricRule) String() string {
+	return r.Metric + " " + r.Operator + " " + r.Value
+}
+
+// threshold 解析规则的阈值，时间类指标统一为毫秒
+func (r MetricRule) threshold() (float64, error) {
+	if timeMetrics[r.Metric] {
+		if d, err := time.ParseDuration(r.Value); err == nil {
+			return float64(d) / float64(time.Millisecond), nil
+		}
+	}
+	v, err := strconv.ParseFloat(strings.TrimSpace(r.Value), 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value in metric rule %q", r.String())
+	}
+	return v, nil
+}
+
+// validate 检查规则配置
+func (r MetricRule) validate() error {
+	if _, _, err := metricValue(&playwright_lib.PageMetrics{}, r.Metric); err != nil {
+		return err
+	}
+	switch r.Operator {
+	case ">", ">=", "<", "<=", "==", "!=":
+	default:
+		return fmt.Errorf("unsupported operator in metric rule %q", r.String())
+	}
+	_, err := r.threshold()
+	return err
+}
+
+// Evaluate 计算规则是否触发告警
+func (r MetricRule) Evaluate(m *playwright_lib.PageMetrics) MetricAlert {
+	alert := MetricAlert{MetricRule: r}
+	actual, display, err := metricValue(m, r.Metric)
+	if err != nil {
+		return alert
+	}
+	alert.Actual = display
+	threshold, err := r.threshold()
+	if err != nil {
+		return alert
+	}
+	switch r.Operator {
+	case ">":
+		alert.Triggered = actual > threshold
+	case ">=":
+		alert.Triggered = actual >= threshold
+	case "<":
+		alert.Triggered = actual < threshold
+	case "<=":
+		alert.Triggered = actual <= threshold
+	case "==":
+		alert.Triggered = actual == threshold
+	case "!=":
+		alert.Triggered = actual != threshold
+	}
+	return alert
+}
+
+// metricValue 取出指标的数值与展示值，时间类指标的数值为毫秒
+func metricValue(m *playwright_lib.PageMetrics, metric string) (float64, string, error) {
+	var d time.Duration
+	var n float64
+	switch metric {
+	case MetricLCP:
+		d = m.LCP
+	case MetricINP:
+		d = m.INP
+	case MetricTBT:
+		d = m.TBT
+	case MetricFCP:
+		d = m.FCP
+	case MetricTTFB:
+		d = m.TTFB
+	case MetricConsoleErrors:
+		n = float64(m.ConsoleErrorCount)
+	case MetricExceptions:
+		n = float64(m.ExceptionCount)
+	case MetricFailedRequests:
+		n = float64(m.FailedRequestCount)
+	case MetricRequests:
+		n = float64(m.Requests)
+	case MetricPageWeight:
+		n = float64(m.PageWeight)
+	case MetricCLS:
+		return m.CLS, strconv.FormatFloat(m.CLS, 'f', 3, 64), nil
+	default:
+		return 0, "", fmt.Errorf("unsupported metric: %s", metric)
+	}
+	if timeMetrics[metric] {
+		return float64(d) / float64(time.Millisecond), d.Round(time.Millisecond).String(), nil
+	}
+	return n, strconv.FormatFloat(n, 'f', -1, 64), nil
+}
+
+// evaluateMetricRules 计算所有规则
+func evaluateMetricRules(rules []MetricRule, m *playwright_lib.PageMetrics) []MetricAlert {
+	alerts := make([]MetricAlert, 0, len(rules))
+	for _, rule := range rules {
+		alerts = append(alerts, rule.Evaluate(m))
+	}
+	return alerts
+}
@@ -0,0 +1,105 @@
+package web_scanner
+
+import (
+	"redrock-dashboard/core/pkg/scanner/web_scanner/playwright_lib"
+	"testing"
+	"time"
+)
+
+func TestMetricRuleThreshold(t *testing.T) {
+	tests := []struct {
+		rule    MetricRule
+		want    float64
+		wantErr bool
+	}{
+		{MetricRule{Metric: MetricLCP, Value: "2.5s"}, 2500, false},
+		{MetricRule{Metric: MetricTBT, Value: "300ms"}, 300, false},
+		{MetricRule{Metric: MetricFCP, Value: "1800"}, 1800, false},
+		{MetricRule{Metric: MetricConsoleErrors, Value: " 3 "}, 3, false},
+		{MetricRule{Metric: MetricCLS, Value: "0.1"}, 0.1, false},
+		{MetricRule{Metric: MetricRequests, Value: "2s"}, 0, true},
+		{MetricRule{Metric: MetricTTFB, Value: "fast"}, 0, true},
+	}
+	for _, tt := range tests {
+		got, err := tt.rule.threshold()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: err = %v, wantErr %v", tt.rule, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("%s: threshold = %v, want %v", tt.rule, got, tt.want)
+		}
+	}
+}
+
+func TestMetricRuleValidate(t *testing.T) {
+	tests := []struct {
+		rule    MetricRule
+		wantErr bool
+	}{
+		{MetricRule{Metric: MetricLCP, Operator: ">", Value: "2.5s"}, false},
+		{MetricRule{Metric: MetricPageWeight, Operator: "!=", Value: "0"}, false},
+		{MetricRule{Metric: MetricLCP, Operator: "=>", Value: "2.5s"}, true},
+		{MetricRule{Metric: MetricLCP, Operator: "", Value: "2.5s"}, true},
+		{MetricRule{Metric: "memory", Operator: ">", Value: "1"}, true},
+		{MetricRule{Metric: MetricExceptions, Operator: ">", Value: "many"}, true},
+	}
+	for _, tt := range tests {
+		if err := tt.rule.validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%q: err = %v, wantErr %v", tt.rule.String(), err, tt.wantErr)
+		}
+	}
+}
+
+func TestMetricRuleEvaluate(t *testing.T) {
+	m := &playwright_lib.PageMetrics{
+		ConsoleErrorCount:  2,
+		FailedRequestCount: 0,
+		Requests:           40,
+		PageWeight:         1 << 20,
+		LCP:                2600 * time.Millisecond,
+		TTFB:               180 * time.Millisecond,
+		CLS:                0.05,
+	}
+	tests := []struct {
+		rule       MetricRule
+		triggered  bool
+		wantActual string
+	}{
+		{MetricRule{Metric: MetricLCP, Operator: ">", Value: "2.5s"}, true, "2.6s"},
+		{MetricRule{Metric: MetricLCP, Operator: ">", Value: "3000"}, false, "2.6s"},
+		{MetricRule{Metric: MetricTTFB, Operator: "<=", Value: "180ms"}, true, "180ms"},
+		{MetricRule{Metric: MetricConsoleErrors, Operator: ">", Value: "0"}, true, "2"},
+		{MetricRule{Metric: MetricFailedRequests, Operator: ">", Value: "0"}, false, "0"},
+		{MetricRule{Metric: MetricRequests, Operator: ">=", Value: "40"}, true, "40"},
+		{MetricRule{Metric: MetricPageWeight, Operator: "<", Value: "1048576"}, false, "1048576"},
+		{MetricRule{Metric: MetricCLS, Operator: ">", Value: "0.1"}, false, "0.050"},
+		{MetricRule{Metric: MetricCLS, Operator: "!=", Value: "0"}, true, "0.050"},
+		{MetricRule{Metric: MetricRequests, Operator: "==", Value: "40"}, true, "40"},
+		// 配置错误的规则不告警
+		{MetricRule{Metric: "memory", Operator: ">", Value: "0"}, false, ""},
+		{MetricRule{Metric: MetricRequests, Operator: ">", Value: "x"}, false, "40"},
+		{MetricRule{Metric: MetricRequests, Operator: "=>", Value: "0"}, false, "40"},
+	}
+	for _, tt := range tests {
+		alert := tt.rule.Evaluate(m)
+		if alert.Triggered != tt.triggered || alert.Actual != tt.wantActual {
+			t.Errorf("%s: triggered = %v actual = %q, want %v %q",
+				tt.rule, alert.Triggered, alert.Actual, tt.triggered, tt.wantActual)
+		}
+		if alert.MetricRule != tt.rule {
+			t.Errorf("%s: alert carries rule %s", tt.rule, alert.MetricRule)
+		}
+	}
+}
+
+func TestEvaluateMetricRules(t *testing.T) {
+	rules := []MetricRule{
+		{Metric: MetricExceptions, Operator: ">", Value: "0"},
+		{Metric: MetricINP, Operator: ">", Value: "200ms"},
+	}
+	alerts := evaluateMetricRules(rules, &playwright_lib.PageMetrics{ExceptionCount: 1})
+	if len(alerts) != 2 || !alerts[0].Triggered || alerts[1].Triggered {
+		t.Fatalf("alerts = %+v", alerts)
+	}
+}
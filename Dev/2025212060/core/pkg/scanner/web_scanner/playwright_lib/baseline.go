@@ -0,0 +1,77 @@
+package playwright_lib
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"path"
+	"redrock-dashboard/core/pkg/blob"
+	"strings"
+)
+
+// baselinePrefix 每个监控固定一张基线截图，不参与保留策略清理
+const baselinePrefix = "baselines/"
+
+func baselineKey(monitorID string) string {
+	return baselinePrefix + strings.TrimPrefix(monitorPrefix(monitorID), screenshotPrefix) + "baseline"
+}
+
+// PinBaseline 把已保存的截图设为监控的视觉基线
+func (s *ScreenshotStore) PinBaseline(ctx context.Context, monitorID, screenshotID string) error {
+	r, err := s.Open(ctx, screenshotID)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	return s.store.Put(ctx, baselineKey(monitorID), r, "application/octet-stream")
+}
+
+// Baseline 读取监控的视觉基线，未设置时返回 blob.ErrNotFound
+func (s *ScreenshotStore) Baseline(ctx context.Context, monitorID string) (image.Image, error) {
+	r, err := s.store.Get(ctx, baselineKey(monitorID))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	img, _, err := image.Decode(r)
+	if err != nil {
+		return nil, fmt.Errorf("decode baseline failed: %w", err)
+	}
+	return img, nil
+}
+
+// ClearBaseline 删除监控的视觉基线，下次截图会重新设为基线
+func (s *ScreenshotStore) ClearBaseline(ctx context.Context, monitorID string) error {
+	return s.store.Delete(ctx, baselineKey(monitorID))
+}
+
+// SaveDiff 保存差异图，与截图同名以便一起被清理
+func (s *ScreenshotStore) SaveDiff(ctx context.Context, screenshotID string, diff image.Image) (string, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, diff); err != nil {
+		return "", fmt.Errorf("encode diff failed: %w", err)
+	}
+	id := strings.TrimSuffix(screenshotID, path.Ext(screenshotID)) + ".diff.png"
+	if err := s.store.Put(ctx, id, &buf, "image/png"); err != nil {
+		return "", fmt.Errorf("store diff failed: %w", err)
+	}
+	return id, nil
+}
+
+// CompareWithBaseline 与基线比较并保存差异图；没有基线时把本次截图设为基线，返回 nil
+func (s *ScreenshotStore) CompareWithBaseline(ctx context.Context, monitorID string, stored *StoredScreenshot, opts DiffOptions) (*DiffResult, string, error) {
+	baseline, err := s.Baseline(ctx, monitorID)
+	if errors.Is(err, blob.ErrNotFound) {
+		return nil, "", s.PinBaseline(ctx, monitorID, stored.ID)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	result := Compare(baseline, stored.image, opts)
+	diffID, err := s.SaveDiff(ctx, stored.ID, result.DiffImage)
+	return result, diffID, err
+}
@@ -0,0 +1,56 @@
+package playwright_lib
+
+import (
+	"encoding/base64"
+	"fmt"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// 截图格式
+const (
+	FormatPNG  = "png"
+	FormatJPEG = "jpeg"
+	FormatWebP = "webp"
+)
+
+var contentTypes = map[string]string{
+	FormatPNG:  "image/png",
+	FormatJPEG: "image/jpeg",
+	FormatWebP: "image/webp",
+}
+
+// webpScript 在浏览器里用 canvas 把 png 转成 webp，Go 标准库与 x/image 都没有 webp 编码器
+const webpScript = `async ([data, quality]) => {
+	const img = new Image();
+	img.src = 'data:image/png;base64,' + data;
+	await img.decode();
+	const canvas = document.createElement('canvas');
+	canvas.width = img.naturalWidth;
+	canvas.height = img.naturalHeight;
+	canvas.getContext('2d').drawImage(img, 0, 0);
+	const url = canvas.toDataURL('image/webp', quality);
+	if (!url.startsWith('data:image/webp')) {
+		throw new Error('webp encoding not supported');
+	}
+	return url.slice(url.indexOf(',') + 1);
+}`
+
+// encodeWebP 在同一上下文的空白页中转码，避免目标页面的 CSP 拦截 data: 图片
+func encodeWebP(browserCtx playwright.BrowserContext, png []byte, quality int) ([]byte, error) {
+	page, err := browserCtx.NewPage()
+	if err != nil {
+		return nil, fmt.Errorf("new page failed: %w", err)
+	}
+	defer page.Close()
+
+	encoded, err := page.Evaluate(webpScript, []any{base64.StdEncoding.EncodeToString(png), float64(quality) / 100})
+	if err != nil {
+		return nil, fmt.Errorf("encode webp failed: %w", err)
+	}
+	data, ok := encoded.(string)
+	if !ok {
+		return nil, fmt.Errorf("encode webp failed: unexpected result %T", encoded)
+	}
+	return base64.StdEncoding.DecodeString(data)
+}
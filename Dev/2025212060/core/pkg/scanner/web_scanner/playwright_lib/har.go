@@ -0,0 +1,43 @@
+package playwright_lib
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"redrock-dashboard/core/pkg/har"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// harRecording 浏览器上下文关闭时 Playwright 才写出 HAR，先记下临时文件位置
+type harRecording struct {
+	dir  string
+	path string
+}
+
+// recordHAR 在创建上下文的参数中开启 HAR 记录，只记录请求与耗时，不保存响应体
+func recordHAR(options *playwright.BrowserNewContextOptions) (*harRecording, error) {
+	dir, err := os.MkdirTemp("", "redrock-har-")
+	if err != nil {
+		return nil, fmt.Errorf("create har dir failed: %w", err)
+	}
+	h := &harRecording{dir: dir, path: filepath.Join(dir, "record.har")}
+	options.RecordHarPath = &h.path
+	options.RecordHarContent = playwright.HarContentPolicyOmit
+	options.RecordHarMode = playwright.HarModeFull
+	return h, nil
+}
+
+// read 在上下文关闭后读取脱敏的 HAR 并删除临时文件
+func (h *harRecording) read(secrets ...string) ([]byte, error) {
+	defer h.remove()
+	data, err := os.ReadFile(h.path)
+	if err != nil {
+		return nil, fmt.Errorf("read har failed: %w", err)
+	}
+	return har.Redact(data, secrets...)
+}
+
+func (h *harRecording) remove() {
+	_ = os.RemoveAll(h.dir)
+}
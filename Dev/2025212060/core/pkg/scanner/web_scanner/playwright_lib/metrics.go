@@ -0,0 +1,203 @@
+package playwright_lib
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// 每类明细最多保留的条数，计数不受影响
+const maxMetricEntries = 50
+
+// 计算页面大小时最多查询的请求数
+const maxSizedRequests = 300
+
+// FailedRequest 失败的子资源请求，Status 为 0 表示被拦截或网络错误
+type FailedRequest struct {
+	URL          string
+	Method       string
+	ResourceType string
+	Status       int
+	Error        string
+}
+
+// PageMetrics 页面加载期间采集的指标
+type PageMetrics struct {
+	ConsoleErrors      []string // console.error 输出，带脚本位置
+	ConsoleErrorCount  int
+	Exceptions         []string // 未捕获的异常
+	ExceptionCount     int
+	FailedRequests     []FailedRequest // 4xx、5xx 与被拦截的请求
+	FailedRequestCount int
+
+	Requests         int
+	PageWeight       int64 // 传输的字节数（响应头与编码后的响应体）
+	PageWeightCapped bool  // 请求过多，PageWeight 只统计了前 maxSizedRequests 个
+
+	// Core Web Vitals，由 PerformanceObserver 采集
+	// 合成检测没有用户交互，INP 通常为 0，以 TBT 作为交互延迟的参考
+	LCP  time.Duration
+	CLS  float64
+	INP  time.Duration
+	TBT  time.Duration // 长任务超出 50ms 部分之和（近似值）
+	FCP  time.Duration
+	TTFB time.Duration
+}
+
+// vitalsScript 在页面脚本之前注入，只在顶层页面采集
+const vitalsScript = `(() => {
+	if (window.top !== window) return;
+	const v = window.__redrockVitals = { lcp: 0, cls: 0, inp: 0, tbt: 0, fcp: 0, ttfb: 0 };
+	const observe = (type, cb, opts) => {
+		try {
+			new PerformanceObserver(list => list.getEntries().forEach(cb)).observe({ type, buffered: true, ...opts });
+		} catch (e) {}
+	};
+	observe('largest-contentful-paint', e => { v.lcp = e.renderTime || e.startTime; });
+	let session = 0, first = 0, last = 0;
+	observe('layout-shift', e => {
+		if (e.hadRecentInput) return;
+		if (session && e.startTime - last < 1000 && e.startTime - first < 5000) {
+			session += e.value;
+		} else {
+			session = e.value;
+			first = e.startTime;
+		}
+		last = e.startTime;
+		v.cls = Math.max(v.cls, session);
+	});
+	observe('paint', e => { if (e.name === 'first-contentful-paint') v.fcp = e.startTime; });
+	observe('longtask', e => { v.tbt += Math.max(0, e.duration - 50); });
+	observe('event', e => { if (e.interactionId) v.inp = Math.max(v.inp, e.duration); }, { durationThreshold: 40 });
+	observe('navigation', e => { v.ttfb = e.responseStart; });
+})()`
+
+// metricsCollector 监听页面事件，事件回调中不能再调用 playwright，否则会阻塞事件分发
+// 请求数只在 OnRequest 中统计；失败请求按请求去重，4xx、5xx 与网络错误各只记一次
+type metricsCollector struct {
+	mu       sync.Mutex
+	metrics  PageMetrics
+	finished []playwright.Request
+	failed   map[playwright.Request]bool
+}
+
+// collectMetrics 在导航前调用，注入脚本并开始监听
+func collectMetrics(page playwright.Page) (*metricsCollector, error) {
+	if err := page.AddInitScript(playwright.Script{Content: playwright.String(vitalsScript)}); err != nil {
+		return nil, fmt.Errorf("inject vitals script failed: %w", err)
+	}
+
+	c := &metricsCollector{failed: make(map[playwright.Request]bool)}
+	page.OnConsole(func(msg playwright.ConsoleMessage) {
+		if msg.Type() != "error" {
+			return
+		}
+		text := msg.Text()
+		if loc := msg.Location(); loc != nil && loc.URL != "" {
+			text = fmt.Sprintf("%s (%s:%d)", text, loc.URL, loc.LineNumber+1)
+		}
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.metrics.ConsoleErrorCount++
+		if len(c.metrics.ConsoleErrors) < maxMetricEntries {
+			c.metrics.ConsoleErrors = append(c.metrics.ConsoleErrors, text)
+		}
+	})
+	page.OnPageError(func(err error) {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.metrics.ExceptionCount++
+		if len(c.metrics.Exceptions) < maxMetricEntries {
+			c.metrics.Exceptions = append(c.metrics.Exceptions, err.Error())
+		}
+	})
+	page.OnResponse(func(resp playwright.Response) {
+		if resp.Status() < 400 {
+			return
+		}
+		req := resp.Request()
+		c.addFailed(req, FailedRequest{URL: req.URL(), Method: req.Method(), ResourceType: req.ResourceType(), Status: resp.Status()})
+	})
+	page.OnRequestFailed(func(req playwright.Request) {
+		failed := FailedRequest{URL: req.URL(), Method: req.Method(), ResourceType: req.ResourceType()}
+		if err := req.Failure(); err != nil {
+			failed.Error = err.Error()
+		}
+		c.addFailed(req, failed)
+	})
+	page.OnRequest(func(playwright.Request) {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.metrics.Requests++
+	})
+	page.OnRequestFinished(func(req playwright.Request) {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.finished = append(c.finished, req)
+	})
+	return c, nil
+}
+
+// addFailed 记录失败请求，同一请求只记一次
+func (c *metricsCollector) addFailed(req playwright.Request, failed FailedRequest) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.failed[req] {
+		return
+	}
+	c.failed[req] = true
+	c.metrics.FailedRequestCount++
+	if len(c.metrics.FailedRequests) < maxMetricEntries {
+		c.metrics.FailedRequests = append(c.metrics.FailedRequests, failed)
+	}
+}
+
+// finish 读取 Web Vitals 并统计页面大小，在页面加载完成后调用
+func (c *metricsCollector) finish(page playwright.Page) *PageMetrics {
+	c.mu.Lock()
+	metrics := c.metrics
+	finished := c.finished
+	c.mu.Unlock()
+
+	if len(finished) > maxSizedRequests {
+		finished = finished[:maxSizedRequests]
+		metrics.PageWeightCapped = true
+	}
+	for _, req := range finished {
+		sizes, err := req.Sizes()
+		if err != nil {
+			continue
+		}
+		metrics.PageWeight += int64(sizes.ResponseHeadersSize + sizes.ResponseBodySize)
+	}
+
+	vitals, err := page.Evaluate("() => window.__redrockVitals || null")
+	if v, ok := vitals.(map[string]interface{}); err == nil && ok {
+		metrics.LCP = millis(v["lcp"])
+		metrics.CLS = number(v["cls"])
+		metrics.INP = millis(v["inp"])
+		metrics.TBT = millis(v["tbt"])
+		metrics.FCP = millis(v["fcp"])
+		metrics.TTFB = millis(v["ttfb"])
+	}
+	return &metrics
+}
+
+// number 转换 Evaluate 返回的数字，整数值会解码为 int
+func number(v interface{}) float64 {
+	switch n := v.(type) {
+	case float64:
+		return n
+	case int:
+		return float64(n)
+	case int64:
+		return float64(n)
+	}
+	return 0
+}
+
+func millis(v interface{}) time.Duration {
+	return time.Duration(number(v) * float64(time.Millisecond))
+}
@@ -0,0 +1,42 @@
+package playwright_lib
+
+import (
+	"testing"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// fakeRequest 只用作失败请求去重的键
+type fakeRequest struct {
+	playwright.Request
+	id int
+}
+
+func TestAddFailedCountsOnce(t *testing.T) {
+	c := &metricsCollector{failed: make(map[playwright.Request]bool)}
+	a, b := &fakeRequest{id: 1}, &fakeRequest{id: 2}
+
+	c.addFailed(a, FailedRequest{URL: "https://example.com/a", Status: 404})
+	c.addFailed(a, FailedRequest{URL: "https://example.com/a", Error: "net::ERR_ABORTED"})
+	c.addFailed(b, FailedRequest{URL: "https://example.com/b", Error: "net::ERR_BLOCKED_BY_CLIENT"})
+
+	if c.metrics.FailedRequestCount != 2 {
+		t.Fatalf("FailedRequestCount = %d, want 2", c.metrics.FailedRequestCount)
+	}
+	if len(c.metrics.FailedRequests) != 2 || c.metrics.FailedRequests[0].Status != 404 {
+		t.Fatalf("FailedRequests = %+v", c.metrics.FailedRequests)
+	}
+}
+
+func TestAddFailedCapsEntries(t *testing.T) {
+	c := &metricsCollector{failed: make(map[playwright.Request]bool)}
+	for i := 0; i < maxMetricEntries+5; i++ {
+		c.addFailed(&fakeRequest{id: i}, FailedRequest{Status: 500})
+	}
+	if c.metrics.FailedRequestCount != maxMetricEntries+5 {
+		t.Fatalf("FailedRequestCount = %d", c.metrics.FailedRequestCount)
+	}
+	if len(c.metrics.FailedRequests) != maxMetricEntries {
+		t.Fatalf("kept %d entries, want %d", len(c.metrics.FailedRequests), maxMetricEntries)
+	}
+}
@@ -16,12 +16,21 @@ type Screenshotter struct {
 	height    int
 	fullPage  bool
 	waitUntil string // load, domcontentloaded, networkidle
+	pool      *BrowserPool
+	format    string // png、jpeg、webp
+	quality   int    // jpeg 与 webp 的压缩质量 1-100
+	metrics   bool
+	har       bool
 }
 
 // ScreenshotResult 截图结果
 type ScreenshotResult struct {
-	Base64 string // data:image/png;base64,xxx
-	Error  error
+	Base64      string       // data:image/png;base64,xxx
+	Image       []byte       // 原始图片数据，写入对象存储时使用
+	ContentType string       // image/png、image/jpeg、image/webp
+	Metrics     *PageMetrics // 开启指标采集时的控制台错误、失败请求、页面大小与 Web Vitals
+	HAR         []byte       // 开启 HAR 记录时页面加载的 HAR，已脱敏
+	Error       error
 }
 
 // ScreenshotterOption 配置选项
@@ -35,6 +44,9 @@ func NewScreenshotter(opts ...ScreenshotterOption) *Screenshotter {
 		height:    1080,
 		fullPage:  true,
 		waitUntil: "networkidle",
+		pool:      SharedPool(),
+		format:    FormatPNG,
+		quality:   80,
 	}
 	for _, opt := range opts {
 		opt(s)
@@ -61,44 +73,93 @@ func WithWaitUntil(state string) ScreenshotterOption {
 	return func(s *Screenshotter) { s.waitUntil = state }
 }
 
+// WithFormat 设置图片格式与压缩质量，png 时忽略 quality
+func WithFormat(format string, quality int) ScreenshotterOption {
+	return func(s *Screenshotter) {
+		s.format = format
+		if quality > 0 && quality <= 100 {
+			s.quality = quality
+		}
+	}
+}
+
+// WithPool 使用指定的浏览器池，默认使用 SharedPool
+func WithPool(pool *BrowserPool) ScreenshotterOption {
+	return func(s *Screenshotter) { s.pool = pool }
+}
+
+// WithMetrics 截图时同时采集页面指标
+func WithMetrics() ScreenshotterOption {
+	return func(s *Screenshotter) { s.metrics = true }
+}
+
+// WithHAR 截图时同时记录页面加载的 HAR
+func WithHAR() ScreenshotterOption {
+	return func(s *Screenshotter) { s.har = true }
+}
+
 // Screenshot 截图单个 URL（唯一对外接口）
 func (s *Screenshotter) Screenshot(url string) *ScreenshotResult {
 	result := &ScreenshotResult{}
+	if _, ok := contentTypes[s.format]; !ok {
+		result.Error = fmt.Errorf("unsupported screenshot format: %s", s.format)
+		return result
+	}
 
 	// 创建 context 控制超时
 	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
 	defer cancel()
 
-	// 启动 Playwright
-	pw, err := playwright.Run()
-	if err != nil {
-		result.Error = fmt.Errorf("start playwright failed: %w", err)
-		return result
+	contextOptions := playwright.BrowserNewContextOptions{
+		Viewport: &playwright.Size{
+			Width:  s.width,
+			Height: s.height,
+		},
+	}
+	var recording *harRecording
+	if s.har {
+		var err error
+		if recording, err = recordHAR(&contextOptions); err != nil {
+			result.Error = err
+			return result
+		}
 	}
-	defer pw.Stop()
 
-	// 启动浏览器
-	browser, err := pw.Chromium.Launch(playwright.BrowserTypeLaunchOptions{
-		Headless: playwright.Bool(true),
-	})
+	// 从浏览器池取一个隔离的上下文
+	browserCtx, release, err := s.pool.Acquire(ctx, contextOptions)
 	if err != nil {
-		result.Error = fmt.Errorf("launch browser failed: %w", err)
+		if recording != nil {
+			recording.remove()
+		}
+		result.Error = err
 		return result
 	}
-	defer browser.Close()
+	// 上下文关闭后 HAR 才写入文件，截图失败时同样保留
+	defer func() {
+		release()
+		if recording != nil {
+			if data, err := recording.read(); err == nil {
+				result.HAR = data
+			}
+		}
+	}()
 
 	// 创建页面
-	page, err := browser.NewPage(playwright.BrowserNewPageOptions{
-		Viewport: &playwright.Size{
-			Width:  s.width,
-			Height: s.height,
-		},
-	})
+	page, err := browserCtx.NewPage()
 	if err != nil {
 		result.Error = fmt.Errorf("new page failed: %w", err)
 		return result
 	}
 
+	var collector *metricsCollector
+	if s.metrics {
+		collector, err = collectMetrics(page)
+		if err != nil {
+			result.Error = err
+			return result
+		}
+	}
+
 	// 导航到目标页面
 	_, err = page.Goto(url, playwright.PageGotoOptions{
 		Timeout: playwright.Float(float64(s.timeout.Milliseconds())),
@@ -116,10 +177,19 @@ func (s *Screenshotter) Screenshot(url string) *ScreenshotResult {
 	case <-time.After(500 * time.Millisecond):
 	}
 
-	// 截图
+	// 在截图前读取指标，整页截图会调整视口并产生额外的布局偏移
+	if collector != nil {
+		result.Metrics = collector.finish(page)
+	}
+
+	// 截图，jpeg 由浏览器直接压缩，webp 先截 png 再转码
 	screenshotOptions := playwright.PageScreenshotOptions{
 		Type: playwright.ScreenshotTypePng,
 	}
+	if s.format == FormatJPEG {
+		screenshotOptions.Type = playwright.ScreenshotTypeJpeg
+		screenshotOptions.Quality = playwright.Int(s.quality)
+	}
 	if s.fullPage {
 		screenshotOptions.FullPage = playwright.Bool(true)
 	}
@@ -129,10 +199,19 @@ func (s *Screenshotter) Screenshot(url string) *ScreenshotResult {
 		result.Error = fmt.Errorf("screenshot failed: %w", err)
 		return result
 	}
+	result.ContentType = contentTypes[s.format]
+	if s.format == FormatWebP {
+		imageBytes, err = encodeWebP(browserCtx, imageBytes, s.quality)
+		if err != nil {
+			result.Error = err
+			return result
+		}
+	}
+	result.Image = imageBytes
 
 	// 编码为 base64 并拼接前缀
 	base64Str := base64.StdEncoding.EncodeToString(imageBytes)
-	result.Base64 = "data:image/png;base64," + base64Str
+	result.Base64 = "data:" + result.ContentType + ";base64," + base64Str
 
 	return result
 }
@@ -0,0 +1,226 @@
+package playwright_lib
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// ErrPoolClosed 浏览器池已关闭
+var ErrPoolClosed = errors.New("browser pool closed")
+
+// BrowserPool 常驻浏览器与有上限的隔离上下文池，浏览器崩溃或断开时自动重启
+type BrowserPool struct {
+	maxContexts    int
+	healthInterval time.Duration
+	launchOptions  playwright.BrowserTypeLaunchOptions
+	launch         launcher
+
+	slots chan struct{} // 空闲上下文名额
+
+	mu      sync.Mutex
+	browser playwright.Browser
+	stopPW  func() error // 停止浏览器所属的 playwright 驱动
+	closed  bool
+	stop    chan struct{}
+}
+
+// launcher 启动浏览器，同时返回停止驱动的函数
+type launcher func(options playwright.BrowserTypeLaunchOptions) (playwright.Browser, func() error, error)
+
+// launchChromium 启动 playwright 驱动与 Chromium
+func launchChromium(options playwright.BrowserTypeLaunchOptions) (playwright.Browser, func() error, error) {
+	pw, err := playwright.Run()
+	if err != nil {
+		return nil, nil, fmt.Errorf("start playwright failed: %w", err)
+	}
+	browser, err := pw.Chromium.Launch(options)
+	if err != nil {
+		_ = pw.Stop()
+		return nil, nil, fmt.Errorf("launch browser failed: %w", err)
+	}
+	return browser, pw.Stop, nil
+}
+
+// PoolOption 配置选项
+type PoolOption func(*BrowserPool)
+
+// NewBrowserPool 创建浏览器池，浏览器在第一次使用时才启动
+func NewBrowserPool(opts ...PoolOption) *BrowserPool {
+	p := &BrowserPool{
+		maxContexts:    4,
+		healthInterval: 30 * time.Second,
+		launchOptions: playwright.BrowserTypeLaunchOptions{
+			Headless: playwright.Bool(true),
+		},
+		launch: launchChromium,
+		stop:   make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	p.slots = make(chan struct{}, p.maxContexts)
+	for i := 0; i < p.maxContexts; i++ {
+		p.slots <- struct{}{}
+	}
+	if p.healthInterval > 0 {
+		go p.healthLoop()
+	}
+	return p
+}
+
+// WithMaxContexts 同时打开的上下文上限，超出时等待
+func WithMaxContexts(n int) PoolOption {
+	return func(p *BrowserPool) {
+		if n > 0 {
+			p.maxContexts = n
+		}
+	}
+}
+
+// WithHealthInterval 健康检查间隔，为 0 时只在使用时检查
+func WithHealthInterval(d time.Duration) PoolOption {
+	return func(p *BrowserPool) { p.healthInterval = d }
+}
+
+func WithLaunchOptions(options playwright.BrowserTypeLaunchOptions) PoolOption {
+	return func(p *BrowserPool) { p.launchOptions = options }
+}
+
+// withLauncher 替换浏览器的启动方式，测试中注入假的浏览器
+func withLauncher(l launcher) PoolOption {
+	return func(p *BrowserPool) { p.launch = l }
+}
+
+var (
+	sharedPool     *BrowserPool
+	sharedPoolOnce sync.Once
+)
+
+// SharedPool 返回进程内共用的浏览器池
+func SharedPool() *BrowserPool {
+	sharedPoolOnce.Do(func() {
+		sharedPool = NewBrowserPool()
+	})
+	return sharedPool
+}
+
+// Acquire 取一个隔离的浏览器上下文，用完必须调用 release
+func (p *BrowserPool) Acquire(ctx context.Context, options playwright.BrowserNewContextOptions) (playwright.BrowserContext, func(), error) {
+	select {
+	case <-p.slots:
+	case <-ctx.Done():
+		return nil, nil, fmt.Errorf("wait for browser context: %w", ctx.Err())
+	}
+	giveBack := func() { p.slots <- struct{}{} }
+
+	browserCtx, err := p.newContext(options)
+	if err != nil {
+		giveBack()
+		return nil, nil, err
+	}
+	return browserCtx, func() {
+		_ = browserCtx.Close()
+		giveBack()
+	}, nil
+}
+
+// newContext 创建上下文，失败时认为浏览器已损坏，重启后再试一次
+func (p *BrowserPool) newContext(options playwright.BrowserNewContextOptions) (playwright.BrowserContext, error) {
+	for attempt := 0; ; attempt++ {
+		browser, err := p.ensureBrowser()
+		if err != nil {
+			return nil, err
+		}
+		browserCtx, err := browser.NewContext(options)
+		if err == nil {
+			return browserCtx, nil
+		}
+		if attempt > 0 {
+			return nil, fmt.Errorf("new browser context failed: %w", err)
+		}
+		p.restart(browser)
+	}
+}
+
+// ensureBrowser 返回可用的浏览器，未启动或已断开时重新启动
+func (p *BrowserPool) ensureBrowser() (playwright.Browser, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return nil, ErrPoolClosed
+	}
+	if p.browser != nil && p.browser.IsConnected() {
+		return p.browser, nil
+	}
+	p.shutdownLocked()
+
+	browser, stopPW, err := p.launch(p.launchOptions)
+	if err != nil {
+		return nil, err
+	}
+	p.browser, p.stopPW = browser, stopPW
+	return browser, nil
+}
+
+// restart 关闭指定的浏览器实例，下次使用时重新启动；已被其他调用者替换时不处理
+func (p *BrowserPool) restart(browser playwright.Browser) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.browser == browser {
+		p.shutdownLocked()
+	}
+}
+
+func (p *BrowserPool) shutdownLocked() {
+	if p.browser != nil {
+		_ = p.browser.Close()
+		p.browser = nil
+	}
+	if p.stopPW != nil {
+		_ = p.stopPW()
+		p.stopPW = nil
+	}
+}
+
+// Healthy 浏览器是否在运行
+func (p *BrowserPool) Healthy() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.browser != nil && p.browser.IsConnected()
+}
+
+// healthLoop 定期检查浏览器，断开的实例及时回收，避免残留进程
+func (p *BrowserPool) healthLoop() {
+	ticker := time.NewTicker(p.healthInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+			p.mu.Lock()
+			if p.browser != nil && !p.browser.IsConnected() {
+				p.shutdownLocked()
+			}
+			p.mu.Unlock()
+		}
+	}
+}
+
+// Close 关闭浏览器并停止健康检查，之后的 Acquire 返回 ErrPoolClosed
+func (p *BrowserPool) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return
+	}
+	p.closed = true
+	close(p.stop)
+	p.shutdownLocked()
+}
@@ -0,0 +1,244 @@
+package playwright_lib
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// fakeBrowser 只实现浏览器池用到的方法
+type fakeBrowser struct {
+	playwright.Browser
+	mu          sync.Mutex
+	connected   bool
+	closed      bool
+	failContext bool // NewContext 返回错误
+	open        int  // 未关闭的上下文数
+}
+
+func (b *fakeBrowser) IsConnected() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.connected
+}
+
+func (b *fakeBrowser) disconnect() {
+	b.mu.Lock()
+	b.connected = false
+	b.mu.Unlock()
+}
+
+func (b *fakeBrowser) NewContext(options ...playwright.BrowserNewContextOptions) (playwright.BrowserContext, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.failContext {
+		return nil, errors.New("target closed")
+	}
+	b.open++
+	return &fakeContext{browser: b}, nil
+}
+
+func (b *fakeBrowser) Close(options ...playwright.BrowserCloseOptions) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.connected, b.closed = false, true
+	return nil
+}
+
+type fakeContext struct {
+	playwright.BrowserContext
+	browser *fakeBrowser
+}
+
+func (c *fakeContext) Close(options ...playwright.BrowserContextCloseOptions) error {
+	c.browser.mu.Lock()
+	c.browser.open--
+	c.browser.mu.Unlock()
+	return nil
+}
+
+// fakeLauncher 记录启动过的浏览器与驱动停止次数
+type fakeLauncher struct {
+	mu       sync.Mutex
+	browsers []*fakeBrowser
+	stops    int
+	err      error
+	setup    func(n int, b *fakeBrowser) // 配置第 n 个启动的浏览器（从 0 开始）
+}
+
+func (l *fakeLauncher) launch(playwright.BrowserTypeLaunchOptions) (playwright.Browser, func() error, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.err != nil {
+		return nil, nil, l.err
+	}
+	b := &fakeBrowser{connected: true}
+	if l.setup != nil {
+		l.setup(len(l.browsers), b)
+	}
+	l.browsers = append(l.browsers, b)
+	return b, func() error {
+		l.mu.Lock()
+		l.stops++
+		l.mu.Unlock()
+		return nil
+	}, nil
+}
+
+func (l *fakeLauncher) launched() (int, int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.browsers), l.stops
+}
+
+func newFakePool(t *testing.T, l *fakeLauncher, opts ...PoolOption) *BrowserPool {
+	p := NewBrowserPool(append([]PoolOption{WithHealthInterval(0), withLauncher(l.launch)}, opts...)...)
+	t.Cleanup(p.Close)
+	return p
+}
+
+func TestBrowserPoolSlots(t *testing.T) {
+	l := &fakeLauncher{}
+	p := newFakePool(t, l, WithMaxContexts(2))
+
+	var releases []func()
+	for i := 0; i < 2; i++ {
+		_, release, err := p.Acquire(context.Background(), playwright.BrowserNewContextOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		releases = append(releases, release)
+	}
+
+	// 名额用完时按 ctx 超时返回
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, _, err := p.Acquire(ctx, playwright.BrowserNewContextOptions{}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("third Acquire error = %v, want deadline exceeded", err)
+	}
+
+	acquired := make(chan func())
+	go func() {
+		_, release, err := p.Acquire(context.Background(), playwright.BrowserNewContextOptions{})
+		if err != nil {
+			t.Error(err)
+			release = func() {}
+		}
+		acquired <- release
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("Acquire did not wait for a free slot")
+	case <-time.After(20 * time.Millisecond):
+	}
+	releases[0]()
+	select {
+	case release := <-acquired:
+		releases[0] = release
+	case <-time.After(time.Second):
+		t.Fatal("Acquire still blocked after a release")
+	}
+
+	for _, release := range releases {
+		release()
+	}
+	if launched, _ := l.launched(); launched != 1 {
+		t.Errorf("launched %d browsers, want 1", launched)
+	}
+	if b := l.browsers[0]; b.open != 0 {
+		t.Errorf("%d contexts left open", b.open)
+	}
+	if !p.Healthy() {
+		t.Error("pool should be healthy")
+	}
+}
+
+func TestBrowserPoolRelaunch(t *testing.T) {
+	// 第一个浏览器已损坏，NewContext 失败后重启再试
+	l := &fakeLauncher{setup: func(n int, b *fakeBrowser) { b.failContext = n == 0 }}
+	p := newFakePool(t, l)
+
+	_, release, err := p.Acquire(context.Background(), playwright.BrowserNewContextOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	release()
+	if launched, stops := l.launched(); launched != 2 || stops != 1 || !l.browsers[0].closed {
+		t.Errorf("launched %d, stopped %d, first closed %v", launched, stops, l.browsers[0].closed)
+	}
+
+	// 浏览器断开后下次使用时重新启动
+	l.browsers[1].disconnect()
+	if p.Healthy() {
+		t.Error("disconnected browser reported healthy")
+	}
+	if _, release, err = p.Acquire(context.Background(), playwright.BrowserNewContextOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	release()
+	if launched, stops := l.launched(); launched != 3 || stops != 2 {
+		t.Errorf("launched %d, stopped %d after disconnect", launched, stops)
+	}
+
+	// 重启后仍然失败时返回错误并归还名额
+	broken := &fakeLauncher{setup: func(n int, b *fakeBrowser) { b.failContext = true }}
+	p = newFakePool(t, broken, WithMaxContexts(1))
+	for i := 0; i < 2; i++ {
+		if _, _, err := p.Acquire(context.Background(), playwright.BrowserNewContextOptions{}); err == nil {
+			t.Fatal("Acquire succeeded with a broken browser")
+		}
+	}
+}
+
+func TestBrowserPoolHealthLoop(t *testing.T) {
+	l := &fakeLauncher{}
+	p := newFakePool(t, l, WithHealthInterval(5*time.Millisecond))
+
+	_, release, err := p.Acquire(context.Background(), playwright.BrowserNewContextOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	release()
+	l.browsers[0].disconnect()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		if _, stops := l.launched(); stops == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("health loop did not reclaim the disconnected browser")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestBrowserPoolClosed(t *testing.T) {
+	l := &fakeLauncher{}
+	p := newFakePool(t, l)
+	if _, release, err := p.Acquire(context.Background(), playwright.BrowserNewContextOptions{}); err != nil {
+		t.Fatal(err)
+	} else {
+		release()
+	}
+
+	p.Close()
+	p.Close()
+	if _, _, err := p.Acquire(context.Background(), playwright.BrowserNewContextOptions{}); !errors.Is(err, ErrPoolClosed) {
+		t.Errorf("Acquire after Close error = %v, want ErrPoolClosed", err)
+	}
+	if launched, stops := l.launched(); launched != 1 || stops != 1 || !l.browsers[0].closed {
+		t.Errorf("launched %d, stopped %d, closed %v", launched, stops, l.browsers[0].closed)
+	}
+
+	// 启动失败的错误原样返回
+	failing := &fakeLauncher{err: errors.New("no chromium")}
+	p = newFakePool(t, failing)
+	if _, _, err := p.Acquire(context.Background(), playwright.BrowserNewContextOptions{}); err == nil || err.Error() != "no chromium" {
+		t.Errorf("Acquire error = %v", err)
+	}
+}
@@ -0,0 +1,125 @@
+package playwright_lib
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// Renderer 渲染页面后读取文本或 HTML，用于监控由脚本生成内容的页面
+type Renderer struct {
+	timeout   time.Duration
+	waitUntil string // load, domcontentloaded, networkidle
+	pool      *BrowserPool
+}
+
+// RenderResult 渲染结果，选择器匹配多个元素时按顺序以换行连接
+type RenderResult struct {
+	Text  string // 渲染后的可见文本（innerText）
+	HTML  string // 元素的 outerHTML
+	Error error
+}
+
+// RendererOption 配置选项
+type RendererOption func(*Renderer)
+
+// NewRenderer 创建渲染器
+func NewRenderer(opts ...RendererOption) *Renderer {
+	r := &Renderer{
+		timeout:   30 * time.Second,
+		waitUntil: "networkidle",
+		pool:      SharedPool(),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+func WithRenderTimeout(d time.Duration) RendererOption {
+	return func(r *Renderer) { r.timeout = d }
+}
+
+// WithRenderWaitUntil 导航完成的判断条件，默认 networkidle
+func WithRenderWaitUntil(state string) RendererOption {
+	return func(r *Renderer) { r.waitUntil = state }
+}
+
+// WithRenderPool 使用指定的浏览器池，默认使用 SharedPool
+func WithRenderPool(pool *BrowserPool) RendererOption {
+	return func(r *Renderer) { r.pool = pool }
+}
+
+// Render 渲染 URL 并读取 selector 匹配的内容，selector 为空时读取整个 body（唯一对外接口）
+func (r *Renderer) Render(url, selector string) *RenderResult {
+	result := &RenderResult{}
+	waitUntil, ok := waitUntilStates[r.waitUntil]
+	if !ok {
+		result.Error = fmt.Errorf("unsupported wait until state: %s", r.waitUntil)
+		return result
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.timeout)
+	defer cancel()
+
+	browserCtx, release, err := r.pool.Acquire(ctx, playwright.BrowserNewContextOptions{})
+	if err != nil {
+		result.Error = err
+		return result
+	}
+	defer release()
+
+	page, err := browserCtx.NewPage()
+	if err != nil {
+		result.Error = fmt.Errorf("new page failed: %w", err)
+		return result
+	}
+	page.SetDefaultTimeout(float64(r.timeout.Milliseconds()))
+
+	if _, err := page.Goto(url, playwright.PageGotoOptions{
+		Timeout:   playwright.Float(float64(r.timeout.Milliseconds())),
+		WaitUntil: waitUntil,
+	}); err != nil {
+		result.Error = fmt.Errorf("goto url failed: %w", err)
+		return result
+	}
+
+	if selector == "" {
+		selector = "body"
+	}
+	locator := page.Locator(selector)
+	// 先确认有匹配，否则读取文本会一直等到超时
+	count, err := locator.Count()
+	if err != nil {
+		result.Error = fmt.Errorf("query selector failed: %w", err)
+		return result
+	}
+	if count == 0 {
+		result.Error = fmt.Errorf("selector %q matched nothing", selector)
+		return result
+	}
+
+	texts, err := locator.AllInnerTexts()
+	if err != nil {
+		result.Error = fmt.Errorf("read text failed: %w", err)
+		return result
+	}
+	result.Text = strings.Join(texts, "\n")
+
+	html, err := locator.EvaluateAll("els => els.map(e => e.outerHTML).join('\\n')")
+	if err != nil {
+		result.Error = fmt.Errorf("read html failed: %w", err)
+		return result
+	}
+	result.HTML, _ = html.(string)
+	return result
+}
+
+var waitUntilStates = map[string]*playwright.WaitUntilState{
+	"load":             playwright.WaitUntilStateLoad,
+	"domcontentloaded": playwright.WaitUntilStateDomcontentloaded,
+	"networkidle":      playwright.WaitUntilStateNetworkidle,
+}
@@ -0,0 +1,203 @@
+package playwright_lib
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"path"
+	"redrock-dashboard/core/pkg/blob"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+const (
+	// screenshotPrefix 截图在对象存储中的前缀
+	screenshotPrefix = "screenshots/"
+	// timestampLayout 文件名中的时间戳，定长且按字典序即时间顺序
+	timestampLayout = "20060102T150405.000000000Z"
+)
+
+// ScreenshotStore 把截图与缩略图写入对象存储，结果中只保存 ID，并按保留策略清理旧截图
+type ScreenshotStore struct {
+	store        blob.Store
+	thumbWidth   int // 为 0 时不生成缩略图
+	thumbQuality int
+	keep         int           // 每个监控保留的截图数量，为 0 时不限制
+	maxAge       time.Duration // 超过该时长的截图被删除，为 0 时不限制
+}
+
+// StoredScreenshot 写入存储后的截图信息
+type StoredScreenshot struct {
+	ID          string
+	ThumbnailID string // 未生成缩略图时为空
+	ContentType string
+	Size        int64
+	Width       int
+	Height      int
+
+	image image.Image // 解码后的截图，用于与基线比较
+}
+
+// StoreOption 配置选项
+type StoreOption func(*ScreenshotStore)
+
+// NewScreenshotStore 创建截图存储
+func NewScreenshotStore(store blob.Store, opts ...StoreOption) *ScreenshotStore {
+	s := &ScreenshotStore{
+		store:        store,
+		thumbWidth:   320,
+		thumbQuality: 75,
+		keep:         50,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// WithThumbnail 设置缩略图宽度与 jpeg 质量，宽度为 0 时不生成
+func WithThumbnail(width, quality int) StoreOption {
+	return func(s *ScreenshotStore) {
+		s.thumbWidth = width
+		if quality > 0 && quality <= 100 {
+			s.thumbQuality = quality
+		}
+	}
+}
+
+// WithRetention 设置每个监控保留的截图数量与最长保留时间，为 0 表示不限制
+func WithRetention(keep int, maxAge time.Duration) StoreOption {
+	return func(s *ScreenshotStore) {
+		s.keep = keep
+		s.maxAge = maxAge
+	}
+}
+
+// Save 保存截图与缩略图，然后清理该监控超出保留策略的旧截图
+func (s *ScreenshotStore) Save(ctx context.Context, monitorID string, data []byte, contentType string) (*StoredScreenshot, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("decode screenshot failed: %w", err)
+	}
+
+	base := monitorPrefix(monitorID) + time.Now().UTC().Format(timestampLayout)
+	stored := &StoredScreenshot{
+		ID:          base + extension(contentType),
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		Width:       img.Bounds().Dx(),
+		Height:      img.Bounds().Dy(),
+		image:       img,
+	}
+	// 缩略图先编码好，避免原图写入后才发现无法生成
+	var thumb bytes.Buffer
+	if s.thumbWidth > 0 {
+		if err := jpeg.Encode(&thumb, thumbnail(img, s.thumbWidth), &jpeg.Options{Quality: s.thumbQuality}); err != nil {
+			return nil, fmt.Errorf("encode thumbnail failed: %w", err)
+		}
+		stored.ThumbnailID = base + ".thumb.jpg"
+	}
+
+	if err := s.store.Put(ctx, stored.ID, bytes.NewReader(data), contentType); err != nil {
+		return nil, fmt.Errorf("store screenshot failed: %w", err)
+	}
+	if stored.ThumbnailID != "" {
+		if err := s.store.Put(ctx, stored.ThumbnailID, &thumb, "image/jpeg"); err != nil {
+			// 删除已写入的原图，不留下无人引用的对象；ctx 可能已取消，清理不受其影响
+			_ = s.store.Delete(context.WithoutCancel(ctx), stored.ID)
+			return nil, fmt.Errorf("store thumbnail failed: %w", err)
+		}
+	}
+
+	if err := s.Prune(ctx, monitorID); err != nil {
+		return stored, fmt.Errorf("prune screenshots failed: %w", err)
+	}
+	return stored, nil
+}
+
+// Open 按 ID 读取截图或缩略图
+func (s *ScreenshotStore) Open(ctx context.Context, id string) (io.ReadCloser, error) {
+	if !strings.HasPrefix(id, screenshotPrefix) {
+		return nil, blob.ErrNotFound
+	}
+	return s.store.Get(ctx, id)
+}
+
+// Prune 按保留数量与时长删除监控的旧截图及其缩略图
+func (s *ScreenshotStore) Prune(ctx context.Context, monitorID string) error {
+	if s.keep <= 0 && s.maxAge <= 0 {
+		return nil
+	}
+	objects, err := s.store.List(ctx, monitorPrefix(monitorID))
+	if err != nil {
+		return err
+	}
+
+	// 按时间戳分组，缩略图跟随原图删除
+	groups := make(map[string][]blob.Object)
+	var stamps []string
+	for _, obj := range objects {
+		name := path.Base(obj.Key)
+		if len(name) < len(timestampLayout) {
+			continue
+		}
+		stamp := name[:len(timestampLayout)]
+		if _, ok := groups[stamp]; !ok {
+			stamps = append(stamps, stamp)
+		}
+		groups[stamp] = append(groups[stamp], obj)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(stamps)))
+
+	cutoff := time.Now().Add(-s.maxAge)
+	for i, stamp := range stamps {
+		expired := s.maxAge > 0 && groups[stamp][0].ModTime.Before(cutoff)
+		if (s.keep > 0 && i >= s.keep) || expired {
+			for _, obj := range groups[stamp] {
+				if err := s.store.Delete(ctx, obj.Key); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// monitorPrefix 监控在存储中的目录
+func monitorPrefix(monitorID string) string {
+	return screenshotPrefix + blob.MonitorSegment(monitorID) + "/"
+}
+
+func extension(contentType string) string {
+	for format, ct := range contentTypes {
+		if ct == contentType {
+			return "." + format
+		}
+	}
+	return ".bin"
+}
+
+// thumbnail 取页面顶部 16:10 的区域缩放到指定宽度，整页截图通常很长，只看首屏更有意义
+func thumbnail(src image.Image, width int) image.Image {
+	bounds := src.Bounds()
+	crop := bounds
+	if maxHeight := bounds.Dx() * 10 / 16; crop.Dy() > maxHeight {
+		crop.Max.Y = crop.Min.Y + maxHeight
+	}
+	if width > crop.Dx() {
+		width = crop.Dx()
+	}
+	height := max(crop.Dy()*width/max(crop.Dx(), 1), 1)
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.ApproxBiLinear.Scale(dst, dst.Bounds(), src, crop, draw.Src, nil)
+	return dst
+}
@@ -0,0 +1,127 @@
+package playwright_lib
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"redrock-dashboard/core/pkg/blob"
+	"strings"
+	"testing"
+)
+
+// failingThumbStore 写缩略图时失败，其余操作交给本地存储
+type failingThumbStore struct {
+	blob.Store
+}
+
+func (s failingThumbStore) Put(ctx context.Context, key string, r io.Reader, contentType string) error {
+	if strings.HasSuffix(key, ".thumb.jpg") {
+		return errors.New("disk full")
+	}
+	return s.Store.Put(ctx, key, r, contentType)
+}
+
+func newLocalStore(t *testing.T) *blob.LocalStore {
+	store, err := blob.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+func pngScreenshot(t *testing.T, width, height int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{uint8(x), uint8(y), 0x80, 0xff})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestScreenshotStoreSave(t *testing.T) {
+	ctx := context.Background()
+	store := NewScreenshotStore(newLocalStore(t), WithThumbnail(64, 80))
+
+	stored, err := store.Save(ctx, "monitor/1", pngScreenshot(t, 200, 600), contentTypes[FormatPNG])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Width != 200 || stored.Height != 600 || stored.ThumbnailID == "" {
+		t.Fatalf("unexpected stored screenshot %+v", stored)
+	}
+	if !strings.HasPrefix(stored.ID, "screenshots/monitor%2F1/") {
+		t.Errorf("monitor ID not escaped: %s", stored.ID)
+	}
+
+	r, err := store.Open(ctx, stored.ThumbnailID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	thumb, _, err := image.Decode(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 整页截图只取顶部 16:10 区域
+	if b := thumb.Bounds(); b.Dx() != 64 || b.Dy() != 40 {
+		t.Errorf("thumbnail is %dx%d, want 64x40", b.Dx(), b.Dy())
+	}
+
+	if _, err := store.Open(ctx, "har/other"); !errors.Is(err, blob.ErrNotFound) {
+		t.Errorf("Open outside the screenshot prefix = %v, want ErrNotFound", err)
+	}
+}
+
+// 缩略图写入失败时不留下孤立的原图
+func TestScreenshotStoreSaveThumbnailFailure(t *testing.T) {
+	ctx := context.Background()
+	local := newLocalStore(t)
+	store := NewScreenshotStore(failingThumbStore{local})
+
+	if _, err := store.Save(ctx, "m", pngScreenshot(t, 32, 32), contentTypes[FormatPNG]); err == nil {
+		t.Fatal("expected thumbnail error")
+	}
+	objects, err := local.List(ctx, screenshotPrefix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objects) != 0 {
+		t.Errorf("orphaned objects left behind: %+v", objects)
+	}
+}
+
+func TestScreenshotStoreRetention(t *testing.T) {
+	ctx := context.Background()
+	local := newLocalStore(t)
+	store := NewScreenshotStore(local, WithRetention(2, 0))
+
+	var ids []string
+	for i := 0; i < 3; i++ {
+		stored, err := store.Save(ctx, "m", pngScreenshot(t, 32, 32), contentTypes[FormatPNG])
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, stored.ID)
+	}
+
+	objects, err := local.List(ctx, monitorPrefix("m"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 保留最近两张截图及其缩略图
+	if len(objects) != 4 {
+		t.Fatalf("got %d objects, want 4: %+v", len(objects), objects)
+	}
+	if _, err := store.Open(ctx, ids[0]); !errors.Is(err, blob.ErrNotFound) {
+		t.Errorf("oldest screenshot not pruned: %v", err)
+	}
+}
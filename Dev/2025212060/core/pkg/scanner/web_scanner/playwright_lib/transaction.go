@@ -0,0 +1,342 @@
+package playwright_lib
+
+import (
+	"context"
+	"fmt"
+	"redrock-dashboard/core/pkg/secret"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// 事务步骤动作
+const (
+	ActionGoto       = "goto"        // 打开 URL
+	ActionFill       = "fill"        // 向 Selector 输入 Value
+	ActionClick      = "click"       // 点击 Selector
+	ActionWaitFor    = "wait_for"    // 等待 Selector 可见
+	ActionAssertText = "assert_text" // Selector（为空时为整个页面）的文本包含 Value
+	ActionExtract    = "extract"     // 读取 Selector 的文本或 Attribute，保存到 Variable
+)
+
+// Step 事务中的一步
+// URL、Selector、Value 中的 ${name} 会替换为之前 extract 得到的变量；Value 可以是 env:/file: 密钥引用
+type Step struct {
+	Name      string
+	Action    string
+	URL       string
+	Selector  string
+	Value     string
+	Variable  string        // extract 保存的变量名
+	Attribute string        // extract 读取的属性，为空时读取文本
+	Timeout   time.Duration // 为 0 时使用事务的默认步骤超时
+}
+
+// StepResult 单步结果
+type StepResult struct {
+	Name     string
+	Action   string
+	Duration time.Duration
+	Passed   bool
+	Value    string // extract 读到的值
+	Error    string
+}
+
+// TransactionResult 事务执行结果
+type TransactionResult struct {
+	Passed      bool
+	TotalTime   time.Duration
+	Steps       []StepResult // 只包含执行过的步骤
+	FailedStep  int          // 失败步骤的下标，全部通过时为 -1
+	Variables   map[string]string
+	Screenshot  []byte // 失败时页面的截图
+	ContentType string
+	HAR         []byte // 开启 HAR 记录时整个事务的 HAR，已替换密钥
+	Error       error  // 浏览器不可用等与步骤无关的错误
+}
+
+// Transaction 浏览器事务执行器
+type Transaction struct {
+	timeout     time.Duration // 整个事务的超时
+	stepTimeout time.Duration
+	width       int
+	height      int
+	pool        *BrowserPool
+	har         bool
+}
+
+// TransactionOption 配置选项
+type TransactionOption func(*Transaction)
+
+// NewTransaction 创建事务执行器
+func NewTransaction(opts ...TransactionOption) *Transaction {
+	t := &Transaction{
+		timeout:     60 * time.Second,
+		stepTimeout: 10 * time.Second,
+		width:       1920,
+		height:      1080,
+		pool:        SharedPool(),
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// WithTransactionTimeout 整个事务的超时，默认 60 秒
+func WithTransactionTimeout(d time.Duration) TransactionOption {
+	return func(t *Transaction) { t.timeout = d }
+}
+
+// WithStepTimeout 未设置 Timeout 的步骤使用的超时，默认 10 秒
+func WithStepTimeout(d time.Duration) TransactionOption {
+	return func(t *Transaction) { t.stepTimeout = d }
+}
+
+func WithTransactionViewport(width, height int) TransactionOption {
+	return func(t *Transaction) {
+		t.width = width
+		t.height = height
+	}
+}
+
+// WithTransactionPool 使用指定的浏览器池，默认使用 SharedPool
+func WithTransactionPool(pool *BrowserPool) TransactionOption {
+	return func(t *Transaction) { t.pool = pool }
+}
+
+// WithTransactionHAR 记录整个事务的 HAR，fill 中使用的密钥会被替换
+func WithTransactionHAR() TransactionOption {
+	return func(t *Transaction) { t.har = true }
+}
+
+// ValidateSteps 检查步骤配置，保存监控时调用
+func ValidateSteps(steps []Step) error {
+	if len(steps) == 0 {
+		return fmt.Errorf("transaction has no steps")
+	}
+	for i, step := range steps {
+		var missing string
+		switch step.Action {
+		case ActionGoto:
+			if step.URL == "" {
+				missing = "url"
+			}
+		case ActionFill, ActionClick, ActionWaitFor:
+			if step.Selector == "" {
+				missing = "selector"
+			}
+		case ActionAssertText:
+			if step.Value == "" {
+				missing = "value"
+			}
+		case ActionExtract:
+			if step.Selector == "" || step.Variable == "" {
+				missing = "selector and variable"
+			}
+		default:
+			return fmt.Errorf("step %d: unknown action %q", i+1, step.Action)
+		}
+		if missing != "" {
+			return fmt.Errorf("step %d (%s): %s required", i+1, step.Action, missing)
+		}
+	}
+	return nil
+}
+
+// Run 在隔离的浏览器上下文中依次执行步骤，遇到失败立即停止（唯一对外接口）
+func (t *Transaction) Run(steps []Step) *TransactionResult {
+	result := &TransactionResult{FailedStep: -1, Variables: make(map[string]string)}
+	if err := ValidateSteps(steps); err != nil {
+		result.Error = err
+		return result
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), t.timeout)
+	defer cancel()
+
+	contextOptions := playwright.BrowserNewContextOptions{
+		Viewport: &playwright.Size{Width: t.width, Height: t.height},
+	}
+	var recording *harRecording
+	if t.har {
+		var err error
+		if recording, err = recordHAR(&contextOptions); err != nil {
+			result.Error = err
+			return result
+		}
+	}
+
+	browserCtx, release, err := t.pool.Acquire(ctx, contextOptions)
+	if err != nil {
+		if recording != nil {
+			recording.remove()
+		}
+		result.Error = err
+		return result
+	}
+	var secrets []string
+	defer func() {
+		release()
+		if recording != nil {
+			if data, err := recording.read(secrets...); err == nil {
+				result.HAR = data
+			}
+		}
+	}()
+
+	page, err := browserCtx.NewPage()
+	if err != nil {
+		result.Error = fmt.Errorf("new page failed: %w", err)
+		return result
+	}
+
+	start := time.Now()
+	for i, step := range steps {
+		if ctx.Err() != nil {
+			result.Steps = append(result.Steps, StepResult{Name: step.Name, Action: step.Action, Error: "transaction timeout"})
+			result.FailedStep = i
+			break
+		}
+
+		stepResult := t.runStep(ctx, page, step, result.Variables, &secrets)
+		result.Steps = append(result.Steps, stepResult)
+		if !stepResult.Passed {
+			result.FailedStep = i
+			break
+		}
+	}
+	result.TotalTime = time.Since(start)
+	result.Passed = result.FailedStep < 0
+
+	// 失败截图同样受总超时约束，超时后不再截图
+	if remaining := stepBudget(ctx, t.stepTimeout); !result.Passed && remaining > 0 {
+		if shot, err := page.Screenshot(playwright.PageScreenshotOptions{
+			Type:     playwright.ScreenshotTypePng,
+			FullPage: playwright.Bool(true),
+			Timeout:  playwright.Float(float64(remaining.Milliseconds())),
+		}); err == nil {
+			result.Screenshot = shot
+			result.ContentType = contentTypes[FormatPNG]
+		}
+	}
+	return result
+}
+
+// stepBudget 返回单步可用的时长，不超过 ctx 截止前的剩余时间；
+// 不足 1ms 时返回 0（playwright 把 0 当作不限时，调用方需自行拦截）
+func stepBudget(ctx context.Context, timeout time.Duration) time.Duration {
+	if deadline, ok := ctx.Deadline(); ok {
+		if remaining := time.Until(deadline); remaining < timeout {
+			timeout = remaining
+		}
+	}
+	if timeout < time.Millisecond {
+		return 0
+	}
+	return timeout
+}
+
+// fillValue 计算填写的值：密钥引用只按配置原文解析，不做变量替换，
+// 避免提取到的页面内容拼出 env:/file: 引用读取本地密钥；返回值是否为密钥
+func fillValue(raw string, vars map[string]string) (string, bool, error) {
+	if !secret.IsRef(raw) {
+		return expandVars(raw, vars), false, nil
+	}
+	value, err := secret.Resolve(raw)
+	if err != nil {
+		return "", false, err
+	}
+	return value, true, nil
+}
+
+// runStep 执行单步并计时，错误信息中不包含填写的值，避免泄露密钥；解析出的密钥追加到 secrets
+func (t *Transaction) runStep(ctx context.Context, page playwright.Page, step Step, vars map[string]string, secrets *[]string) StepResult {
+	result := StepResult{Name: step.Name, Action: step.Action}
+	timeout := step.Timeout
+	if timeout <= 0 {
+		timeout = t.stepTimeout
+	}
+	if timeout = stepBudget(ctx, timeout); timeout <= 0 {
+		result.Error = "transaction timeout"
+		return result
+	}
+	page.SetDefaultTimeout(float64(timeout.Milliseconds()))
+	page.SetDefaultNavigationTimeout(float64(timeout.Milliseconds()))
+
+	selector := expandVars(step.Selector, vars)
+	start := time.Now()
+	err := func() error {
+		switch step.Action {
+		case ActionGoto:
+			_, err := page.Goto(expandVars(step.URL, vars), playwright.PageGotoOptions{
+				WaitUntil: playwright.WaitUntilStateLoad,
+			})
+			return err
+		case ActionFill:
+			value, isSecret, err := fillValue(step.Value, vars)
+			if err != nil {
+				return err
+			}
+			if isSecret {
+				*secrets = append(*secrets, value)
+			}
+			return page.Locator(selector).First().Fill(value)
+		case ActionClick:
+			return page.Locator(selector).First().Click()
+		case ActionWaitFor:
+			return page.Locator(selector).First().WaitFor(playwright.LocatorWaitForOptions{
+				State: playwright.WaitForSelectorStateVisible,
+			})
+		case ActionAssertText:
+			if selector == "" {
+				selector = "body"
+			}
+			text, err := page.Locator(selector).First().InnerText()
+			if err != nil {
+				return err
+			}
+			if expected := expandVars(step.Value, vars); !strings.Contains(text, expected) {
+				return fmt.Errorf("text %q not found in %s", expected, selector)
+			}
+			return nil
+		case ActionExtract:
+			locator := page.Locator(selector).First()
+			var value string
+			var err error
+			if step.Attribute != "" {
+				value, err = locator.GetAttribute(step.Attribute)
+			} else {
+				value, err = locator.InnerText()
+			}
+			if err != nil {
+				return err
+			}
+			result.Value = strings.TrimSpace(value)
+			vars[step.Variable] = result.Value
+			return nil
+		}
+		return fmt.Errorf("unknown action %q", step.Action)
+	}()
+	result.Duration = time.Since(start)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Passed = true
+	return result
+}
+
+var varPattern = regexp.MustCompile(`\$\{(\w+)\}`)
+
+// expandVars 替换 ${name} 为已提取的变量，未定义的保持原样
+func expandVars(s string, vars map[string]string) string {
+	return varPattern.ReplaceAllStringFunc(s, func(m string) string {
+		if v, ok := vars[m[2:len(m)-1]]; ok {
+			return v
+		}
+		return m
+	})
+}
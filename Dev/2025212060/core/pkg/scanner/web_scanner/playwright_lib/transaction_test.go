@@ -0,0 +1,98 @@
+package playwright_lib
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestFillValue(t *testing.T) {
+	t.Setenv("TX_PASSWORD", "hunter2")
+	vars := map[string]string{"user": "alice", "ref": "env:TX_PASSWORD"}
+
+	tests := []struct {
+		raw      string
+		want     string
+		isSecret bool
+		wantErr  bool
+	}{
+		{raw: "plain", want: "plain"},
+		{raw: "${user}@example.com", want: "alice@example.com"},
+		// 变量展开出的引用不会被当作密钥解析
+		{raw: "${ref}", want: "env:TX_PASSWORD"},
+		{raw: "env:TX_PASSWORD", want: "hunter2", isSecret: true},
+		// 密钥引用不做变量替换
+		{raw: "env:${user}", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, isSecret, err := fillValue(tt.raw, vars)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("fillValue(%q) expected error, got %q", tt.raw, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("fillValue(%q) error: %v", tt.raw, err)
+			continue
+		}
+		if got != tt.want || isSecret != tt.isSecret {
+			t.Errorf("fillValue(%q) = %q, %v; want %q, %v", tt.raw, got, isSecret, tt.want, tt.isSecret)
+		}
+	}
+}
+
+func TestStepBudget(t *testing.T) {
+	if got := stepBudget(context.Background(), 5*time.Second); got != 5*time.Second {
+		t.Errorf("without deadline = %v, want 5s", got)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if got := stepBudget(ctx, 10*time.Second); got <= 0 || got > time.Second {
+		t.Errorf("with 1s deadline = %v, want (0, 1s]", got)
+	}
+	if got := stepBudget(ctx, 100*time.Millisecond); got != 100*time.Millisecond {
+		t.Errorf("step shorter than deadline = %v, want 100ms", got)
+	}
+
+	expired, cancelExpired := context.WithTimeout(context.Background(), -time.Second)
+	defer cancelExpired()
+	if got := stepBudget(expired, 10*time.Second); got != 0 {
+		t.Errorf("expired deadline = %v, want 0", got)
+	}
+}
+
+func TestExpandVars(t *testing.T) {
+	vars := map[string]string{"order": "A-17"}
+	if got := expandVars("/orders/${order}?x=${missing}", vars); got != "/orders/A-17?x=${missing}" {
+		t.Errorf("expandVars = %q", got)
+	}
+}
+
+func TestValidateSteps(t *testing.T) {
+	valid := []Step{
+		{Action: ActionGoto, URL: "https://example.com/login"},
+		{Action: ActionFill, Selector: "#user", Value: "alice"},
+		{Action: ActionClick, Selector: "button"},
+		{Action: ActionExtract, Selector: "#order", Variable: "order"},
+		{Action: ActionAssertText, Value: "${order}"},
+	}
+	if err := ValidateSteps(valid); err != nil {
+		t.Fatal(err)
+	}
+
+	invalid := [][]Step{
+		nil,
+		{{Action: ActionGoto}},
+		{{Action: ActionFill, Value: "x"}},
+		{{Action: ActionAssertText}},
+		{{Action: ActionExtract, Selector: "#x"}},
+		{{Action: "hover", Selector: "#x"}},
+	}
+	for _, steps := range invalid {
+		if err := ValidateSteps(steps); err == nil {
+			t.Errorf("ValidateSteps(%+v) expected error", steps)
+		}
+	}
+}
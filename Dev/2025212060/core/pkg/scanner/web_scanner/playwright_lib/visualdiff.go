@@ -0,0 +1,171 @@
+package playwright_lib
+
+import (
+	"image"
+	"image/draw"
+)
+
+// Region 比较时忽略的矩形区域（截图像素坐标），如轮播图、时间、广告位
+type Region struct {
+	X      int
+	Y      int
+	Width  int
+	Height int
+}
+
+func (r Region) rect() image.Rectangle {
+	return image.Rect(r.X, r.Y, r.X+r.Width, r.Y+r.Height)
+}
+
+// DiffOptions 视觉比较配置
+type DiffOptions struct {
+	Threshold      float64 // 变化像素占比超过该值视为页面变化，为 0 时默认 0.01
+	PixelTolerance float64 // 单像素的感知颜色差容忍度 0-1，为 0 时默认 0.1
+	IgnoreRegions  []Region
+}
+
+// DiffResult 视觉比较结果
+type DiffResult struct {
+	ChangedPixels int
+	TotalPixels   int
+	Ratio         float64 // 变化像素占比，忽略区域不计入
+	SizeChanged   bool    // 截图尺寸不同，多出或缺少的部分按变化计算
+	Blank         bool    // 当前截图几乎是纯色，常见于白屏或样式丢失
+	Changed       bool    // Ratio 超过阈值或出现白屏
+	DiffImage     image.Image
+}
+
+// maxYIQDelta YIQ 空间中两种颜色的最大差值
+const maxYIQDelta = 35215.0
+
+// blankRatio 同一种颜色占比超过该值视为白屏
+const blankRatio = 0.995
+
+// Compare 按感知颜色差逐像素比较基线与当前截图，生成差异图：未变化部分淡化为灰度，变化像素标红，忽略区域标黄
+func Compare(baseline, current image.Image, opts DiffOptions) *DiffResult {
+	threshold := opts.Threshold
+	if threshold <= 0 {
+		threshold = 0.01
+	}
+	tolerance := opts.PixelTolerance
+	if tolerance <= 0 {
+		tolerance = 0.1
+	}
+	maxDelta := maxYIQDelta * tolerance * tolerance
+
+	base, cur := toRGBA(baseline), toRGBA(current)
+	bw, bh, cw, ch := base.Rect.Dx(), base.Rect.Dy(), cur.Rect.Dx(), cur.Rect.Dy()
+	width, height := max(bw, cw), max(bh, ch)
+	result := &DiffResult{SizeChanged: bw != cw || bh != ch}
+	diff := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	ignore := make([]image.Rectangle, 0, len(opts.IgnoreRegions))
+	for _, r := range opts.IgnoreRegions {
+		ignore = append(ignore, r.rect())
+	}
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			out := diff.PixOffset(x, y)
+			if inRegions(image.Pt(x, y), ignore) {
+				setPixel(diff.Pix[out:], 255, 255, 0)
+				continue
+			}
+			result.TotalPixels++
+
+			if x >= bw || y >= bh || x >= cw || y >= ch {
+				result.ChangedPixels++
+				setPixel(diff.Pix[out:], 255, 0, 0)
+				continue
+			}
+
+			r1, g1, b1 := blendWhite(base.Pix[base.PixOffset(x, y):])
+			r2, g2, b2 := blendWhite(cur.Pix[cur.PixOffset(x, y):])
+			if colorDelta(r1, g1, b1, r2, g2, b2) > maxDelta {
+				result.ChangedPixels++
+				setPixel(diff.Pix[out:], 255, 0, 0)
+				continue
+			}
+			// 未变化的像素转为浅灰度，突出差异
+			v := uint8(255 + (rgb2y(r2, g2, b2)-255)*0.1)
+			setPixel(diff.Pix[out:], v, v, v)
+		}
+	}
+
+	if result.TotalPixels > 0 {
+		result.Ratio = float64(result.ChangedPixels) / float64(result.TotalPixels)
+	}
+	result.Blank = isBlank(cur, ignore)
+	result.Changed = result.Ratio > threshold || (result.Blank && !isBlank(base, ignore))
+	result.DiffImage = diff
+	return result
+}
+
+// toRGBA 转成原点为 (0,0) 的 RGBA，便于直接按下标读取像素
+func toRGBA(img image.Image) *image.RGBA {
+	if rgba, ok := img.(*image.RGBA); ok && rgba.Rect.Min == (image.Point{}) {
+		return rgba
+	}
+	b := img.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(rgba, rgba.Rect, img, b.Min, draw.Src)
+	return rgba
+}
+
+func setPixel(pix []uint8, r, g, b uint8) {
+	pix[0], pix[1], pix[2], pix[3] = r, g, b, 255
+}
+
+func inRegions(p image.Point, regions []image.Rectangle) bool {
+	for _, r := range regions {
+		if p.In(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// blendWhite 把半透明像素与白色背景混合，RGBA 的像素已预乘 alpha
+func blendWhite(pix []uint8) (float64, float64, float64) {
+	white := 255 - float64(pix[3])
+	return float64(pix[0]) + white, float64(pix[1]) + white, float64(pix[2]) + white
+}
+
+// colorDelta 两个颜色在 YIQ 空间的感知差值（Kotsarenko & Ramos），与 pixelmatch 相同
+func colorDelta(r1, g1, b1, r2, g2, b2 float64) float64 {
+	y := rgb2y(r1, g1, b1) - rgb2y(r2, g2, b2)
+	i := rgb2i(r1, g1, b1) - rgb2i(r2, g2, b2)
+	q := rgb2q(r1, g1, b1) - rgb2q(r2, g2, b2)
+	return 0.5053*y*y + 0.299*i*i + 0.1957*q*q
+}
+
+func rgb2y(r, g, b float64) float64 { return r*0.29889531 + g*0.58662247 + b*0.11448223 }
+func rgb2i(r, g, b float64) float64 { return r*0.59597799 - g*0.27417610 - b*0.32180189 }
+func rgb2q(r, g, b float64) float64 { return r*0.21147017 - g*0.52261711 + b*0.31114694 }
+
+// isBlank 判断截图是否几乎全是同一种颜色
+func isBlank(img *image.RGBA, ignore []image.Rectangle) bool {
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	counts := make(map[[3]uint8]int)
+	total := 0
+	// 按步长抽样，整页截图像素很多
+	step := max(1, w*h/200000)
+	for i := 0; i < w*h; i += step {
+		x, y := i%w, i/w
+		if inRegions(image.Pt(x, y), ignore) {
+			continue
+		}
+		r, g, b := blendWhite(img.Pix[img.PixOffset(x, y):])
+		counts[[3]uint8{uint8(r), uint8(g), uint8(b)}]++
+		total++
+	}
+	if total == 0 {
+		return false
+	}
+	for _, n := range counts {
+		if float64(n)/float64(total) >= blankRatio {
+			return true
+		}
+	}
+	return false
+}
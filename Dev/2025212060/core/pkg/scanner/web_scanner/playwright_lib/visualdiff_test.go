@@ -0,0 +1,134 @@
+package playwright_lib
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+)
+
+func gradient(width, height int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{uint8(x * 2), uint8(y * 2), 0x80, 0xff})
+		}
+	}
+	return img
+}
+
+func filled(width, height int, c color.Color) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Rect, image.NewUniform(c), image.Point{}, draw.Src)
+	return img
+}
+
+// paint 复制图片并把指定区域涂成纯色
+func paint(img *image.RGBA, r image.Rectangle, c color.Color) *image.RGBA {
+	out := image.NewRGBA(img.Rect)
+	draw.Draw(out, out.Rect, img, image.Point{}, draw.Src)
+	draw.Draw(out, r, image.NewUniform(c), image.Point{}, draw.Src)
+	return out
+}
+
+func TestCompareIdentical(t *testing.T) {
+	base := gradient(100, 100)
+	// 轻微的颜色偏差在容忍度之内
+	shifted := gradient(100, 100)
+	for i := 2; i < len(shifted.Pix); i += 4 {
+		shifted.Pix[i]++
+	}
+
+	for _, cur := range []*image.RGBA{base, shifted} {
+		result := Compare(base, cur, DiffOptions{})
+		if result.Ratio != 0 || result.ChangedPixels != 0 || result.TotalPixels != 10000 {
+			t.Errorf("ratio %v, changed %d of %d", result.Ratio, result.ChangedPixels, result.TotalPixels)
+		}
+		if result.Changed || result.Blank || result.SizeChanged {
+			t.Errorf("result = %+v", result)
+		}
+		if result.DiffImage.Bounds() != base.Rect {
+			t.Errorf("diff bounds = %v", result.DiffImage.Bounds())
+		}
+	}
+}
+
+func TestCompareIgnoreRegion(t *testing.T) {
+	base := gradient(100, 100)
+	block := image.Rect(10, 10, 30, 30)
+	cur := paint(base, block, color.Black)
+
+	result := Compare(base, cur, DiffOptions{})
+	if result.ChangedPixels != 400 || result.Ratio != 0.04 || !result.Changed {
+		t.Errorf("without ignore: changed %d, ratio %v, changed %v", result.ChangedPixels, result.Ratio, result.Changed)
+	}
+	if got := result.DiffImage.At(15, 15); got != (color.RGBA{255, 0, 0, 255}) {
+		t.Errorf("changed pixel drawn as %v, want red", got)
+	}
+
+	// 阈值高于变化占比时不算变化
+	if result := Compare(base, cur, DiffOptions{Threshold: 0.05}); result.Changed {
+		t.Errorf("threshold 0.05 reported change at ratio %v", result.Ratio)
+	}
+
+	result = Compare(base, cur, DiffOptions{IgnoreRegions: []Region{{X: 5, Y: 5, Width: 30, Height: 30}}})
+	if result.ChangedPixels != 0 || result.TotalPixels != 10000-900 || result.Changed {
+		t.Errorf("with ignore: changed %d of %d, changed %v", result.ChangedPixels, result.TotalPixels, result.Changed)
+	}
+	if got := result.DiffImage.At(15, 15); got != (color.RGBA{255, 255, 0, 255}) {
+		t.Errorf("ignored pixel drawn as %v, want yellow", got)
+	}
+}
+
+func TestCompareSizeChange(t *testing.T) {
+	base := gradient(100, 100)
+	taller := gradient(100, 120)
+
+	for _, tt := range []struct {
+		name      string
+		base, cur *image.RGBA
+	}{
+		{"grown", base, taller},
+		{"shrunk", taller, base},
+	} {
+		result := Compare(tt.base, tt.cur, DiffOptions{})
+		// 多出或缺少的 20 行按变化计算
+		if !result.SizeChanged || result.ChangedPixels != 2000 || result.TotalPixels != 12000 || !result.Changed {
+			t.Errorf("%s: %+v", tt.name, result)
+		}
+		if result.DiffImage.Bounds() != taller.Rect {
+			t.Errorf("%s: diff bounds = %v", tt.name, result.DiffImage.Bounds())
+		}
+	}
+}
+
+func TestCompareBlank(t *testing.T) {
+	// 白底页面上只有一个小图标，变化占比低于阈值，但整页变白仍算变化
+	base := paint(filled(100, 100, color.White), image.Rect(0, 0, 8, 8), color.Black)
+	white := filled(100, 100, color.White)
+
+	result := Compare(base, white, DiffOptions{})
+	if !result.Blank || result.Ratio > 0.01 || !result.Changed {
+		t.Errorf("blank against content: blank %v, ratio %v, changed %v", result.Blank, result.Ratio, result.Changed)
+	}
+
+	// 基线本身就是白屏时不重复告警
+	result = Compare(white, white, DiffOptions{})
+	if !result.Blank || result.Changed {
+		t.Errorf("blank against blank: blank %v, changed %v", result.Blank, result.Changed)
+	}
+
+	// 忽略掉图标后基线也视为白屏
+	result = Compare(base, white, DiffOptions{IgnoreRegions: []Region{{Width: 8, Height: 8}}})
+	if !result.Blank || result.Changed {
+		t.Errorf("blank with ignored icon: blank %v, changed %v", result.Blank, result.Changed)
+	}
+
+	if isBlank(gradient(100, 100), nil) {
+		t.Error("gradient reported blank")
+	}
+	// 全部被忽略时没有可判断的像素
+	if isBlank(white, []image.Rectangle{white.Rect}) {
+		t.Error("fully ignored image reported blank")
+	}
+}
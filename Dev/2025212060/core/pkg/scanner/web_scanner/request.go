@@ -0,0 +1,121 @@
+package web_scanner
+
+import (
+	"fmt"
+	"net/url"
+	"redrock-dashboard/core/pkg/scanner/web_scanner/web_lib"
+	"redrock-dashboard/core/pkg/secret"
+	"strings"
+)
+
+// Request 监控的请求配置，标注“密钥”的字段可以填 env:NAME 或 file:/path 引用，扫描时才解析，配置中不保存明文
+type Request struct {
+	Method        string            // 为空时使用 GET
+	Headers       map[string]string // 值可以是密钥引用
+	Body          string
+	BodyType      string // raw、json、form；form 时 Body 为 a=1&b=2 形式
+	ContentType   string // raw 时的 Content-Type
+	Auth          Auth
+	ClientCert    string // mTLS 客户端证书 PEM，可以是 file: 引用
+	ClientKey     string // mTLS 客户端私钥 PEM，密钥
+	CACert        string // 自定义 CA 证书包 PEM，可以是 file: 引用
+	Proxy         string // http://、socks5:// 代理，可能带账号密码，密钥
+	SourceAddr    string // 绑定的源 IP
+	SkipTLSVerify bool
+	MaxRedirects  int    // 为 0 时最多跟随 10 次，为负数时不跟随跳转
+	Protocol      string // http/1.1、h2、h2c、h3，为空时自动协商
+}
+
+// Auth 认证配置
+type Auth struct {
+	Type         string // basic、bearer、digest、oauth2，为空时不认证
+	Username     string
+	Password     string // 密钥
+	Token        string // bearer 令牌，密钥
+	TokenURL     string // oauth2 令牌地址
+	ClientID     string
+	ClientSecret string // 密钥
+	Scopes       []string
+}
+
+// options 解析密钥引用并转换为检测器配置，同时返回认证信息与请求头中的密钥值，用于 HAR 脱敏
+func (r Request) options() ([]web_lib.CheckerOption, []string, error) {
+	auth := r.Auth
+	if err := secret.ResolveAll(&auth.Password, &auth.Token, &auth.ClientSecret,
+		&r.ClientCert, &r.ClientKey, &r.CACert, &r.Proxy); err != nil {
+		return nil, nil, err
+	}
+	secrets := []string{auth.Password, auth.Token, auth.ClientSecret}
+
+	var opts []web_lib.CheckerOption
+	if r.Method != "" {
+		opts = append(opts, web_lib.WithMethod(strings.ToUpper(r.Method)))
+	}
+	for k, v := range r.Headers {
+		value, err := secret.Resolve(v)
+		if err != nil {
+			return nil, nil, err
+		}
+		if secret.IsRef(v) {
+			secrets = append(secrets, value)
+		}
+		opts = append(opts, web_lib.WithHeader(k, value))
+	}
+
+	if r.Body != "" {
+		switch r.BodyType {
+		case "", web_lib.BodyRaw:
+			opts = append(opts, web_lib.WithBody(r.ContentType, []byte(r.Body)))
+		case web_lib.BodyJSON:
+			opts = append(opts, web_lib.WithJSONBody(r.Body))
+		case web_lib.BodyForm:
+			values, err := url.ParseQuery(r.Body)
+			if err != nil {
+				return nil, nil, fmt.Errorf("invalid form body: %w", err)
+			}
+			opts = append(opts, web_lib.WithFormBody(values))
+		default:
+			return nil, nil, fmt.Errorf("unsupported body type: %s", r.BodyType)
+		}
+	}
+
+	switch auth.Type {
+	case "":
+	case web_lib.AuthBasic:
+		opts = append(opts, web_lib.WithBasicAuth(auth.Username, auth.Password))
+	case web_lib.AuthBearer:
+		opts = append(opts, web_lib.WithBearerToken(auth.Token))
+	case web_lib.AuthDigest:
+		opts = append(opts, web_lib.WithDigestAuth(auth.Username, auth.Password))
+	case web_lib.AuthOAuth2:
+		opts = append(opts, web_lib.WithOAuth2ClientCredentials(auth.TokenURL, auth.ClientID, auth.ClientSecret, auth.Scopes...))
+	default:
+		return nil, nil, fmt.Errorf("unsupported auth type: %s", auth.Type)
+	}
+
+	if r.ClientCert != "" || r.ClientKey != "" {
+		opts = append(opts, web_lib.WithClientCertificate([]byte(r.ClientCert), []byte(r.ClientKey)))
+	}
+	if r.CACert != "" {
+		opts = append(opts, web_lib.WithCACert([]byte(r.CACert)))
+	}
+	if r.Proxy != "" {
+		opts = append(opts, web_lib.WithProxy(r.Proxy))
+	}
+	if r.SourceAddr != "" {
+		opts = append(opts, web_lib.WithSourceAddr(r.SourceAddr))
+	}
+	if r.SkipTLSVerify {
+		opts = append(opts, web_lib.SkipTLSVerify())
+	}
+	if r.Protocol != "" {
+		opts = append(opts, web_lib.WithProtocol(r.Protocol))
+	}
+	switch {
+	case r.MaxRedirects < 0:
+		opts = append(opts, web_lib.NoRedirect())
+	case r.MaxRedirects > 0:
+		opts = append(opts, web_lib.WithMaxRedirects(r.MaxRedirects))
+	}
+	return opts, secrets, nil
+}
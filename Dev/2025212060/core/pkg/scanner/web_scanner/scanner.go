@@ -1,6 +1,8 @@
 package web_scanner
 
 import (
+	"context"
+	"redrock-dashboard/core/pkg/har"
 	"redrock-dashboard/core/pkg/scanner/web_scanner/playwright_lib"
 	"redrock-dashboard/core/pkg/scanner/web_scanner/web_lib"
 	"time"
@@ -9,33 +11,184 @@ import (
 type TCPScanResult struct {
 	TimeDelay  time.Duration
 	Accessible bool
-	Screenshot string
+	Protocol   string
+	Timing     web_lib.Timing
+	Assertions []web_lib.AssertionResult
+	Redirects  []web_lib.RedirectHop
+	FinalURL   string
+
+	ScreenshotID string // 配置了截图存储时写入的截图与缩略图 ID
+	ThumbnailID  string
+	Screenshot   string // 未配置截图存储时的 base64 data URL
+
+	VisualChange  float64 // 与基线相比变化像素的占比
+	VisualChanged bool    // 超过阈值或出现白屏，需要告警
+	BlankPage     bool
+	DiffID        string // 差异图 ID
+
+	Metrics      *playwright_lib.PageMetrics // 截图时采集的控制台错误、失败请求、页面大小与 Web Vitals
+	MetricAlerts []MetricAlert
+
+	HARID string // 保存的 HAR，截图时记录的是浏览器加载过程，否则是 HTTP 请求过程
 }
 
 type WebScanner struct {
-	Dest string
+	ID             string // 监控 ID，keep-alive 模式下用于复用连接，删除监控时传给 Release
+	Dest           string
+	Assertions     []web_lib.Assertion // 为空时只检查状态码在 200-399
+	Request        Request
+	ConnectionMode string // keep-alive 或 fresh，为空时为 keep-alive
+
+	ScreenshotMode  string // always、never、on_change、every_n，为空时为 always
+	ScreenshotEvery int    // every_n 模式下每多少次检测截一次图
+
+	ScreenshotStore   *playwright_lib.ScreenshotStore // 为空时截图以 base64 放在结果中
+	ScreenshotFormat  string                          // png、jpeg、webp，为空时为 png
+	ScreenshotQuality int                             // jpeg 与 webp 的压缩质量
+	VisualDiff        *playwright_lib.DiffOptions     // 与基线截图比较，需要配置截图存储；第一次截图自动设为基线
+
+	CollectMetrics bool         // 截图时采集页面指标，只在本次检测截图时采集
+	MetricRules    []MetricRule // 配置规则时自动采集指标
+
+	HARMode  string     // never、on_failure、always，为空时不记录
+	HARStore *har.Store // 为空时不记录 HAR
+}
+
+// Release 释放监控占用的连接与截图状态，删除或停用监控时调用
+func Release(id string) {
+	web_lib.Release(id)
+	releaseScreenshotState(id)
 }
 
 func (r WebScanner) Scan() (*TCPScanResult, error) {
-	requester := web_lib.NewHTTPChecker(web_lib.WithTimeout(5 * time.Second))
-	screenShotter := playwright_lib.NewScreenshotter(playwright_lib.WithTimeout(15 * time.Second))
+	if err := validScreenshotMode(r.ScreenshotMode); err != nil {
+		return nil, err
+	}
+	if err := har.ValidMode(r.HARMode); err != nil {
+		return nil, err
+	}
+	for _, rule := range r.MetricRules {
+		if err := rule.validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	opts, secrets, err := r.Request.options()
+	if err != nil {
+		return nil, err
+	}
+	recordHAR := r.HARStore != nil && har.Enabled(r.HARMode)
+	if recordHAR {
+		opts = append(opts, web_lib.WithHAR(secrets...))
+	}
+	opts = append(opts,
+		web_lib.WithTimeout(5*time.Second),
+		web_lib.WithAssertion(r.Assertions...),
+		web_lib.WithMonitorID(r.ID),
+	)
+	if r.ConnectionMode != "" {
+		opts = append(opts, web_lib.WithConnectionMode(r.ConnectionMode))
+	}
+	requester := web_lib.NewHTTPChecker(opts...)
+	shotOpts := []playwright_lib.ScreenshotterOption{playwright_lib.WithTimeout(15 * time.Second)}
+	if r.ScreenshotFormat != "" {
+		shotOpts = append(shotOpts, playwright_lib.WithFormat(r.ScreenshotFormat, r.ScreenshotQuality))
+	}
+	if recordHAR {
+		shotOpts = append(shotOpts, playwright_lib.WithHAR())
+	}
+	if r.CollectMetrics || len(r.MetricRules) > 0 {
+		shotOpts = append(shotOpts, playwright_lib.WithMetrics())
+	}
+	screenShotter := playwright_lib.NewScreenshotter(shotOpts...)
 
 	result := requester.Check(r.Dest)
 	if result.Error != nil {
+		// 请求失败时仍然返回错误，保存了 HAR 时额外带上结果以便取得 HARID
+		if recordHAR && result.HAR != nil {
+			if id := r.saveHAR(result.HAR); id != "" {
+				return &TCPScanResult{TimeDelay: result.TotalTime, Redirects: result.Redirects, HARID: id}, result.Error
+			}
+		}
 		return nil, result.Error
 	}
 
 	data := &TCPScanResult{
 		TimeDelay:  result.TotalTime,
 		Accessible: result.Available,
+		Protocol:   result.Protocol,
+		Timing:     result.Timing,
+		Assertions: result.Assertions,
+		Redirects:  result.Redirects,
+		FinalURL:   result.FinalURL,
+	}
+
+	// 截图时记录了浏览器 HAR 则优先保存，没有截图或截图失败时保存 HTTP 请求的 HAR
+	harData := result.HAR
+	defer func() {
+		if recordHAR && harData != nil && har.ShouldSave(r.HARMode, !data.Accessible) {
+			data.HARID = r.saveHAR(harData)
+		}
+	}()
+
+	if !r.shouldScreenshot(data.Accessible) {
+		return data, nil
 	}
 
 	screen := screenShotter.Screenshot(r.Dest)
+	if screen.HAR != nil {
+		harData = screen.HAR
+	}
 	if screen.Error != nil {
 		// TODO Log
 		return data, nil
 	}
-	data.Screenshot = screen.Base64
+	if screen.Metrics != nil {
+		data.Metrics = screen.Metrics
+		data.MetricAlerts = evaluateMetricRules(r.MetricRules, screen.Metrics)
+	}
+	if r.ScreenshotStore == nil {
+		data.Screenshot = screen.Base64
+		return data, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	stored, err := r.ScreenshotStore.Save(ctx, r.ID, screen.Image, screen.ContentType)
+	if err != nil {
+		// TODO Log
+		// 只是清理旧截图失败时 stored 仍然有效
+		if stored == nil {
+			return data, nil
+		}
+	}
+	data.ScreenshotID = stored.ID
+	data.ThumbnailID = stored.ThumbnailID
+
+	if r.VisualDiff != nil {
+		diff, diffID, err := r.ScreenshotStore.CompareWithBaseline(ctx, r.ID, stored, *r.VisualDiff)
+		if err != nil {
+			// TODO Log
+		}
+		if diff != nil {
+			data.VisualChange = diff.Ratio
+			data.VisualChanged = diff.Changed
+			data.BlankPage = diff.Blank
+			data.DiffID = diffID
+		}
+	}
 
 	return data, nil
 }
+
+// saveHAR 保存 HAR，失败时返回空 ID
+func (r WebScanner) saveHAR(data []byte) string {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	id, err := r.HARStore.Save(ctx, r.ID, data)
+	if err != nil {
+		// TODO Log
+		// 只是清理旧文件失败时 id 仍然有效
+	}
+	return id
+}
@@ -0,0 +1,74 @@
+package web_scanner
+
+import (
+	"fmt"
+	"sync"
+)
+
+// 截图频率
+const (
+	ScreenshotAlways   = "always"    // 每次检测都截图
+	ScreenshotNever    = "never"     // 不截图
+	ScreenshotOnChange = "on_change" // 首次检测以及可用状态变化时截图
+	ScreenshotEveryN   = "every_n"   // 每 N 次检测截一次，状态变化时也截图
+)
+
+// screenshotState 单个监控的截图计数与上一次的可用状态
+type screenshotState struct {
+	checks     int
+	accessible bool
+}
+
+var (
+	screenshotMu     sync.Mutex
+	screenshotStates = make(map[string]*screenshotState)
+)
+
+// validScreenshotMode 检查截图频率配置
+func validScreenshotMode(mode string) error {
+	switch mode {
+	case "", ScreenshotAlways, ScreenshotNever, ScreenshotOnChange, ScreenshotEveryN:
+		return nil
+	}
+	return fmt.Errorf("unsupported screenshot mode: %s", mode)
+}
+
+// shouldScreenshot 根据频率与监控历史判断本次是否截图，没有监控 ID 时每次都截
+func (r WebScanner) shouldScreenshot(accessible bool) bool {
+	mode := r.ScreenshotMode
+	if mode == "" {
+		mode = ScreenshotAlways
+	}
+	switch mode {
+	case ScreenshotAlways:
+		return true
+	case ScreenshotNever:
+		return false
+	}
+	if r.ID == "" {
+		return true
+	}
+
+	screenshotMu.Lock()
+	defer screenshotMu.Unlock()
+	state, seen := screenshotStates[r.ID]
+	if !seen {
+		state = &screenshotState{}
+		screenshotStates[r.ID] = state
+	}
+	changed := !seen || state.accessible != accessible
+	state.accessible = accessible
+	state.checks++
+
+	if mode == ScreenshotEveryN && r.ScreenshotEvery > 0 {
+		return changed || (state.checks-1)%r.ScreenshotEvery == 0
+	}
+	return changed
+}
+
+// releaseScreenshotState 删除监控的截图状态
+func releaseScreenshotState(id string) {
+	screenshotMu.Lock()
+	delete(screenshotStates, id)
+	screenshotMu.Unlock()
+}
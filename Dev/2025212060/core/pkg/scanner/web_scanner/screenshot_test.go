@@ -0,0 +1,74 @@
+package web_scanner
+
+import "testing"
+
+func TestShouldScreenshot(t *testing.T) {
+	up, down := true, false
+	tests := []struct {
+		name    string
+		scanner WebScanner
+		checks  []bool // 每次检测的可用状态
+		want    []bool
+	}{
+		{"default always", WebScanner{ID: "default"}, []bool{up, up, down}, []bool{true, true, true}},
+		{"never", WebScanner{ID: "never", ScreenshotMode: ScreenshotNever}, []bool{up, down}, []bool{false, false}},
+		{
+			"on_change",
+			WebScanner{ID: "on-change", ScreenshotMode: ScreenshotOnChange},
+			[]bool{up, up, down, down, up},
+			[]bool{true, false, true, false, true},
+		},
+		{
+			"on_change without id",
+			WebScanner{ScreenshotMode: ScreenshotOnChange},
+			[]bool{up, up},
+			[]bool{true, true},
+		},
+		{
+			"every_n",
+			WebScanner{ID: "every-n", ScreenshotMode: ScreenshotEveryN, ScreenshotEvery: 3},
+			[]bool{up, up, up, up, down, down, down},
+			[]bool{true, false, false, true, true, false, true},
+		},
+		{
+			"every_n without n behaves as on_change",
+			WebScanner{ID: "every-0", ScreenshotMode: ScreenshotEveryN},
+			[]bool{up, up, down},
+			[]bool{true, false, true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Cleanup(func() { releaseScreenshotState(tt.scanner.ID) })
+			for i, accessible := range tt.checks {
+				if got := tt.scanner.shouldScreenshot(accessible); got != tt.want[i] {
+					t.Errorf("check %d (accessible %v) = %v, want %v", i+1, accessible, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+// 释放后的监控重新按首次检测处理
+func TestReleaseScreenshotState(t *testing.T) {
+	r := WebScanner{ID: "released", ScreenshotMode: ScreenshotOnChange}
+	if !r.shouldScreenshot(true) || r.shouldScreenshot(true) {
+		t.Fatal("unexpected on_change decisions")
+	}
+	Release(r.ID)
+	if !r.shouldScreenshot(true) {
+		t.Error("first check after Release should take a screenshot")
+	}
+	Release(r.ID)
+}
+
+func TestValidScreenshotMode(t *testing.T) {
+	for _, mode := range []string{"", ScreenshotAlways, ScreenshotNever, ScreenshotOnChange, ScreenshotEveryN} {
+		if err := validScreenshotMode(mode); err != nil {
+			t.Errorf("mode %q rejected: %v", mode, err)
+		}
+	}
+	if err := validScreenshotMode("hourly"); err == nil {
+		t.Error("unknown mode accepted")
+	}
+}
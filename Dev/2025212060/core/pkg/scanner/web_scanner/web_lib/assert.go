@@ -0,0 +1,327 @@
+package web_lib
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"redrock-dashboard/core/pkg/charset"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// 断言类型
+const (
+	AssertStatus        = "status"         // Value 为状态码或范围，逗号分隔，如 "200-299,301"
+	AssertBody          = "body"           // 响应体，支持 contains、not_contains、matches
+	AssertJSONPath      = "json_path"      // Target 为 JSONPath，如 $.data.items[0].status
+	AssertHeader        = "header"         // Target 为响应头名称
+	AssertResponseSize  = "response_size"  // 响应体完整大小（字节），支持数值比较
+	AssertRedirects     = "redirects"      // 跳转次数，支持数值比较
+	AssertFinalURL      = "final_url"      // 最终地址
+	AssertFinalHost     = "final_host"     // 最终主机名，常与 in_domain 搭配
+	AssertHTTPSRedirect = "https_redirect" // http 请求必须跳转到 https
+	AssertProtocol      = "protocol"       // 实际使用的协议，如 h2、h3
+)
+
+// 比较运算符
+const (
+	OpEqual        = "=="
+	OpNotEqual     = "!="
+	OpGreater      = ">"
+	OpGreaterEqual = ">="
+	OpLess         = "<"
+	OpLessEqual    = "<="
+	OpContains     = "contains"
+	OpNotContains  = "not_contains"
+	OpMatches      = "matches" // 正则匹配
+	OpExists       = "exists"
+	OpNotExists    = "not_exists"
+	OpInDomain     = "in_domain" // 主机名为该域名或其子域名
+)
+
+// DefaultStatusCodes 未配置状态码断言时接受的范围
+const DefaultStatusCodes = "200-399"
+
+// Assertion 单条响应断言
+type Assertion struct {
+	Type     string
+	Target   string
+	Operator string // 为空时：body 为 contains，json_path 与 header 为 exists，response_size 为 <=
+	Value    string
+}
+
+// AssertionResult 单条断言的结果
+type AssertionResult struct {
+	Assertion
+	Passed  bool
+	Actual  string // 实际值，过长时截断
+	Message string // 失败原因
+}
+
+// String 返回可读的断言描述，如 header Content-Type contains json
+func (a Assertion) String() string {
+	parts := []string{a.Type}
+	for _, s := range []string{a.Target, a.Operator, a.Value} {
+		if s != "" {
+			parts = append(parts, s)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// response 断言需要的响应数据
+type response struct {
+	statusCode int
+	header     http.Header
+	body       []byte // 解码为 UTF-8 的响应体，最多 maxBodySize 字节
+	truncated  bool   // 响应体超过 maxBodySize，内容类断言只检查了前面一部分
+	size       int64  // 响应体完整大小
+	startURL   string
+	hops       []RedirectHop
+	stopReason string // 跳转因循环或超过上限被中止的原因
+	protocol   string
+}
+
+// evaluate 执行断言，未配置状态码断言时按 DefaultStatusCodes 检查
+func evaluate(assertions []Assertion, resp *response) []AssertionResult {
+	hasStatus := false
+	for _, a := range assertions {
+		if a.Type == AssertStatus {
+			hasStatus = true
+		}
+	}
+	if !hasStatus {
+		assertions = append([]Assertion{{Type: AssertStatus, Value: DefaultStatusCodes}}, assertions...)
+	}
+
+	results := make([]AssertionResult, 0, len(assertions)+1)
+	for _, a := range assertions {
+		results = append(results, a.check(resp))
+	}
+	// 跳转循环或次数超限总是算作失败
+	if resp.stopReason != "" {
+		results = append(results, AssertionResult{
+			Assertion: Assertion{Type: AssertRedirects},
+			Actual:    strconv.Itoa(len(resp.hops) - 1),
+			Message:   resp.stopReason,
+		})
+	}
+	return results
+}
+
+func (a Assertion) check(resp *response) AssertionResult {
+	switch a.Type {
+	case AssertBody:
+		a.Operator = orDefault(a.Operator, OpContains)
+	case AssertJSONPath, AssertHeader:
+		a.Operator = orDefault(a.Operator, OpExists)
+	case AssertResponseSize, AssertRedirects:
+		a.Operator = orDefault(a.Operator, OpLessEqual)
+	case AssertFinalURL, AssertProtocol:
+		a.Operator = orDefault(a.Operator, OpEqual)
+	case AssertFinalHost:
+		a.Operator = orDefault(a.Operator, OpInDomain)
+	}
+	result := AssertionResult{Assertion: a}
+	var err error
+	switch a.Type {
+	case AssertStatus:
+		result.Actual = strconv.Itoa(resp.statusCode)
+		result.Passed, err = statusInRanges(resp.statusCode, a.Value)
+	case AssertBody:
+		result.Actual = charset.Truncate(string(resp.body), 200)
+		result.Passed, err = compare(string(resp.body), true, a.Operator, a.Value)
+	case AssertJSONPath:
+		if !gjson.ValidBytes(resp.body) {
+			err = fmt.Errorf("response body is not valid json")
+			break
+		}
+		value := gjson.GetBytes(resp.body, jsonPathToGJSON(a.Target))
+		result.Actual = charset.Truncate(value.String(), 200)
+		result.Passed, err = compare(value.String(), value.Exists(), a.Operator, a.Value)
+	case AssertHeader:
+		values, ok := resp.header[http.CanonicalHeaderKey(a.Target)]
+		result.Actual = charset.Truncate(strings.Join(values, ", "), 200)
+		result.Passed, err = compare(result.Actual, ok, a.Operator, a.Value)
+	case AssertResponseSize:
+		result.Actual = strconv.FormatInt(resp.size, 10)
+		result.Passed, err = compare(result.Actual, true, a.Operator, a.Value)
+	case AssertRedirects:
+		result.Actual = strconv.Itoa(max(len(resp.hops)-1, 0))
+		result.Passed, err = compare(result.Actual, true, a.Operator, a.Value)
+	case AssertFinalURL:
+		result.Actual = resp.finalURL()
+		result.Passed, err = compare(result.Actual, true, a.Operator, a.Value)
+	case AssertFinalHost:
+		u, perr := url.Parse(resp.finalURL())
+		if perr != nil {
+			err = perr
+			break
+		}
+		result.Actual = u.Hostname()
+		if a.Operator == OpInDomain {
+			result.Passed = inDomain(result.Actual, a.Value)
+		} else {
+			result.Passed, err = compare(result.Actual, true, a.Operator, a.Value)
+		}
+	case AssertProtocol:
+		result.Actual = resp.protocol
+		result.Passed, err = compare(result.Actual, true, a.Operator, a.Value)
+	case AssertHTTPSRedirect:
+		result.Actual = resp.finalURL()
+		var reason string
+		if result.Passed, reason = checkHTTPSRedirect(resp.startURL, resp.hops); !result.Passed {
+			err = fmt.Errorf("%s", reason)
+		}
+	default:
+		err = fmt.Errorf("unknown assertion type: %s", a.Type)
+	}
+
+	switch {
+	case err != nil:
+		result.Passed = false
+		result.Message = err.Error()
+	case !result.Passed:
+		result.Message = fmt.Sprintf("assertion failed: %s (actual: %s)", a, result.Actual)
+	}
+	if !result.Passed && resp.truncated && (a.Type == AssertBody || a.Type == AssertJSONPath) {
+		result.Message = strings.TrimSpace(result.Message + " (body truncated)")
+	}
+	return result
+}
+
+// finalURL 跳转链最后一跳的地址
+func (r *response) finalURL() string {
+	if len(r.hops) == 0 {
+		return r.startURL
+	}
+	return r.hops[len(r.hops)-1].URL
+}
+
+// statusInRanges 检查状态码是否落在 "200-299,301" 形式的范围内
+func statusInRanges(code int, spec string) (bool, error) {
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		low, high, isRange := strings.Cut(part, "-")
+		if !isRange {
+			high = low
+		}
+		lo, err1 := strconv.Atoi(strings.TrimSpace(low))
+		hi, err2 := strconv.Atoi(strings.TrimSpace(high))
+		if err1 != nil || err2 != nil || lo > hi {
+			return false, fmt.Errorf("invalid status code range: %s", part)
+		}
+		if code >= lo && code <= hi {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// compare 按运算符比较实际值与期望值，两边都是数字时按数值比较
+func compare(actual string, exists bool, op, expected string) (bool, error) {
+	switch op {
+	case OpExists:
+		return exists, nil
+	case OpNotExists:
+		return !exists, nil
+	}
+	if !exists {
+		return false, nil
+	}
+
+	switch op {
+	case OpContains:
+		return strings.Contains(actual, expected), nil
+	case OpNotContains:
+		return !strings.Contains(actual, expected), nil
+	case OpMatches:
+		re, err := regexp.Compile(expected)
+		if err != nil {
+			return false, fmt.Errorf("invalid regex %q: %w", expected, err)
+		}
+		return re.MatchString(actual), nil
+	}
+
+	a, errA := strconv.ParseFloat(actual, 64)
+	b, errB := strconv.ParseFloat(expected, 64)
+	numeric := errA == nil && errB == nil
+	switch op {
+	case OpEqual:
+		if numeric {
+			return a == b, nil
+		}
+		return actual == expected, nil
+	case OpNotEqual:
+		if numeric {
+			return a != b, nil
+		}
+		return actual != expected, nil
+	case OpGreater, OpGreaterEqual, OpLess, OpLessEqual:
+		if !numeric {
+			return false, fmt.Errorf("operator %s needs numeric values", op)
+		}
+		switch op {
+		case OpGreater:
+			return a > b, nil
+		case OpGreaterEqual:
+			return a >= b, nil
+		case OpLess:
+			return a < b, nil
+		}
+		return a <= b, nil
+	}
+	return false, fmt.Errorf("unknown operator: %s", op)
+}
+
+// jsonPathToGJSON 把 $.a.b[0].c 形式的 JSONPath 转成 gjson 路径 a.b.0.c
+func jsonPathToGJSON(path string) string {
+	path = strings.TrimPrefix(strings.TrimSpace(path), "$")
+	path = strings.TrimPrefix(path, ".")
+
+	var b strings.Builder
+	for i := 0; i < len(path); i++ {
+		switch c := path[i]; c {
+		case '[':
+			end := strings.IndexByte(path[i:], ']')
+			if end < 0 {
+				b.WriteString(path[i:])
+				return b.String()
+			}
+			key := strings.Trim(path[i+1:i+end], `'"`)
+			if b.Len() > 0 {
+				b.WriteByte('.')
+			}
+			b.WriteString(escapeGJSON(key))
+			i += end
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// escapeGJSON 转义 gjson 路径中的特殊字符
+func escapeGJSON(key string) string {
+	var b strings.Builder
+	for _, r := range key {
+		if strings.ContainsRune(`.*?|#@\!=<>%`, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func orDefault(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
@@ -0,0 +1,192 @@
+package web_lib
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestStatusInRanges(t *testing.T) {
+	tests := []struct {
+		code    int
+		spec    string
+		want    bool
+		wantErr bool
+	}{
+		{200, "200-299", true, false},
+		{299, "200-299", true, false},
+		{301, "200-299,301", true, false},
+		{302, "200-299, 301", false, false},
+		{404, " 400 - 499 ", true, false},
+		{204, "200,,204", true, false},
+		{200, "", false, false},
+		{200, "abc", false, true},
+		{200, "299-200", false, true},
+		{200, "200-", false, true},
+		// 前面的范围命中时不再检查后面的非法部分
+		{200, "200,oops", true, false},
+	}
+	for _, tt := range tests {
+		got, err := statusInRanges(tt.code, tt.spec)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("statusInRanges(%d, %q) = %v, %v", tt.code, tt.spec, got, err)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		actual   string
+		exists   bool
+		op       string
+		expected string
+		want     bool
+		wantErr  bool
+	}{
+		{"", true, OpExists, "", true, false},
+		{"", false, OpExists, "", false, false},
+		{"", false, OpNotExists, "", true, false},
+		{"x", false, OpEqual, "x", false, false}, // 不存在时其余运算符都不成立
+		{"hello world", true, OpContains, "world", true, false},
+		{"hello world", true, OpNotContains, "world", false, false},
+		{"v1.2.3", true, OpMatches, `^v\d+\.\d+`, true, false},
+		{"v1.2.3", true, OpMatches, `(`, false, true},
+		{"1.0", true, OpEqual, "1", true, false}, // 数值比较
+		{"abc", true, OpEqual, "abc", true, false},
+		{"abc", true, OpNotEqual, "abd", true, false},
+		{"2", true, OpNotEqual, "2.0", false, false},
+		{"10", true, OpGreater, "9", true, false}, // 不是按字符串比较
+		{"10", true, OpGreaterEqual, "10", true, false},
+		{"10", true, OpLess, "9", false, false},
+		{"10", true, OpLessEqual, "10", true, false},
+		{"ten", true, OpGreater, "9", false, true},
+		{"1", true, "~=", "1", false, true},
+	}
+	for _, tt := range tests {
+		got, err := compare(tt.actual, tt.exists, tt.op, tt.expected)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("compare(%q, %v, %q, %q) = %v, %v", tt.actual, tt.exists, tt.op, tt.expected, got, err)
+		}
+	}
+}
+
+func TestJSONPathToGJSON(t *testing.T) {
+	tests := []struct {
+		path, want string
+	}{
+		{"$.data.items[0].status", "data.items.0.status"},
+		{"data.items.0", "data.items.0"},
+		{"$[0]", "0"},
+		{"$", ""},
+		{" $.a ", "a"},
+		{"$['a.b'].c", `a\.b.c`},
+		{`$.a["x*y"]`, `a.x\*y`},
+		{"$.a[", "a["},
+	}
+	for _, tt := range tests {
+		if got := jsonPathToGJSON(tt.path); got != tt.want {
+			t.Errorf("jsonPathToGJSON(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+
+	body := `{"a.b": {"c": 1}, "list": [{"x*y": "star"}]}`
+	for path, want := range map[string]string{"$['a.b'].c": "1", "$.list[0]['x*y']": "star"} {
+		if got := gjson.Get(body, jsonPathToGJSON(path)).String(); got != want {
+			t.Errorf("%s resolved to %q, want %q", path, got, want)
+		}
+	}
+}
+
+func FuzzJSONPathToGJSON(f *testing.F) {
+	for _, seed := range []string{"$.data.items[0].status", "$['a.b'].c", "$.a[", "[]]", "$..[''"} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, path string) {
+		gjson.Get(`{"a":{"b":[1,2]}}`, jsonPathToGJSON(path))
+	})
+}
+
+func TestEvaluate(t *testing.T) {
+	resp := &response{
+		statusCode: 200,
+		header:     http.Header{"Content-Type": {"application/json; charset=utf-8"}},
+		body:       []byte(`{"data":{"status":"up","count":3}}`),
+		size:       35,
+		startURL:   "http://example.com",
+		hops: []RedirectHop{
+			{URL: "http://example.com", StatusCode: 301},
+			{URL: "https://www.example.com/", StatusCode: 200},
+		},
+		protocol: ProtoHTTP2,
+	}
+	tests := []struct {
+		assertion Assertion
+		passed    bool
+		actual    string
+		message   string // 失败原因需包含的内容
+	}{
+		{Assertion{Type: AssertStatus, Value: "200"}, true, "200", ""},
+		{Assertion{Type: AssertBody, Value: `"up"`}, true, "", ""},
+		{Assertion{Type: AssertBody, Operator: OpMatches, Value: `"count":\d+`}, true, "", ""},
+		{Assertion{Type: AssertJSONPath, Target: "$.data.status", Operator: OpEqual, Value: "up"}, true, "up", ""},
+		{Assertion{Type: AssertJSONPath, Target: "$.data.count", Operator: OpGreater, Value: "5"}, false, "3", "assertion failed"},
+		{Assertion{Type: AssertJSONPath, Target: "$.data.missing"}, false, "", "assertion failed"},
+		{Assertion{Type: AssertHeader, Target: "content-type", Operator: OpContains, Value: "json"}, true, "application/json; charset=utf-8", ""},
+		{Assertion{Type: AssertHeader, Target: "X-Missing", Operator: OpNotExists}, true, "", ""},
+		{Assertion{Type: AssertResponseSize, Value: "35"}, true, "35", ""},
+		{Assertion{Type: AssertRedirects, Value: "0"}, false, "1", "assertion failed"},
+		{Assertion{Type: AssertFinalURL, Value: "https://www.example.com/"}, true, "https://www.example.com/", ""},
+		{Assertion{Type: AssertFinalHost, Value: "example.com"}, true, "www.example.com", ""},
+		{Assertion{Type: AssertFinalHost, Operator: OpEqual, Value: "example.com"}, false, "www.example.com", "assertion failed"},
+		{Assertion{Type: AssertHTTPSRedirect}, true, "https://www.example.com/", ""},
+		{Assertion{Type: AssertProtocol, Value: ProtoHTTP3}, false, ProtoHTTP2, "assertion failed"},
+		{Assertion{Type: "latency"}, false, "", "unknown assertion type: latency"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.assertion.String(), func(t *testing.T) {
+			results := evaluate([]Assertion{{Type: AssertStatus, Value: "200-299"}, tt.assertion}, resp)
+			if len(results) != 2 {
+				t.Fatalf("results = %+v", results)
+			}
+			got := results[1]
+			if got.Passed != tt.passed || !strings.Contains(got.Message, tt.message) {
+				t.Fatalf("passed %v message %q, want %v %q", got.Passed, got.Message, tt.passed, tt.message)
+			}
+			if tt.actual != "" && got.Actual != tt.actual {
+				t.Fatalf("actual %q, want %q", got.Actual, tt.actual)
+			}
+		})
+	}
+}
+
+func TestEvaluateDefaults(t *testing.T) {
+	resp := &response{statusCode: 404, body: []byte("<html>not json"), truncated: true, startURL: "https://example.com"}
+
+	results := evaluate([]Assertion{{Type: AssertJSONPath, Target: "$.a"}, {Type: AssertBody, Value: "found"}}, resp)
+	if len(results) != 3 || results[0].Type != AssertStatus || results[0].Value != DefaultStatusCodes || results[0].Passed {
+		t.Fatalf("default status assertion: %+v", results)
+	}
+	if results[1].Passed || results[1].Message != "response body is not valid json (body truncated)" {
+		t.Errorf("json_path on invalid body: %+v", results[1])
+	}
+	if results[2].Operator != OpContains || !strings.HasSuffix(results[2].Message, "(body truncated)") {
+		t.Errorf("body default operator: %+v", results[2])
+	}
+
+	// 配置了状态码断言时不再追加默认断言
+	results = evaluate([]Assertion{{Type: AssertStatus, Value: "404"}}, resp)
+	if len(results) != 1 || !results[0].Passed {
+		t.Errorf("explicit status assertion: %+v", results)
+	}
+
+	resp.statusCode = 302
+	resp.hops = []RedirectHop{{URL: "https://example.com/a"}, {URL: "https://example.com/b"}}
+	resp.stopReason = "redirect loop detected at https://example.com/a"
+	results = evaluate(nil, resp)
+	last := results[len(results)-1]
+	if len(results) != 2 || last.Passed || last.Type != AssertRedirects || last.Actual != "1" || last.Message != resp.stopReason {
+		t.Errorf("stop reason: %+v", results)
+	}
+}
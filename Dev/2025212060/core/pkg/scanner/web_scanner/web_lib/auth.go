@@ -0,0 +1,307 @@
+package web_lib
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+// 认证方式
+const (
+	AuthBasic  = "basic"
+	AuthBearer = "bearer"
+	AuthDigest = "digest"
+	AuthOAuth2 = "oauth2" // client credentials 模式
+)
+
+// auth 认证配置
+type auth struct {
+	kind     string
+	username string
+	password string
+	token    string
+	oauth2   *clientcredentials.Config
+}
+
+func WithBasicAuth(username, password string) CheckerOption {
+	return func(c *HTTPChecker) { c.auth = &auth{kind: AuthBasic, username: username, password: password} }
+}
+
+func WithBearerToken(token string) CheckerOption {
+	return func(c *HTTPChecker) { c.auth = &auth{kind: AuthBearer, token: token} }
+}
+
+// WithDigestAuth 使用 HTTP Digest 认证，收到 401 质询后自动重发
+func WithDigestAuth(username, password string) CheckerOption {
+	return func(c *HTTPChecker) { c.auth = &auth{kind: AuthDigest, username: username, password: password} }
+}
+
+// WithOAuth2ClientCredentials 先用 client credentials 获取令牌，再以 Bearer 方式请求
+func WithOAuth2ClientCredentials(tokenURL, clientID, clientSecret string, scopes ...string) CheckerOption {
+	return func(c *HTTPChecker) {
+		c.auth = &auth{kind: AuthOAuth2, oauth2: &clientcredentials.Config{
+			ClientID:     clientID,
+			ClientSecret: clientSecret,
+			TokenURL:     tokenURL,
+			Scopes:       scopes,
+		}}
+	}
+}
+
+// cachedToken 监控的 OAuth2 令牌源，key 为令牌配置与 Transport 配置的指纹，变化时重建
+type cachedToken struct {
+	key       string
+	source    oauth2.TokenSource
+	transport *http.Transport
+}
+
+var (
+	tokenMu      sync.Mutex
+	tokenSources = make(map[string]*cachedToken)
+)
+
+// applyAuth 给请求加上认证信息，digest 需要在 Transport 层处理
+func (c *HTTPChecker) applyAuth(req *http.Request) error {
+	a := c.auth
+	if a == nil {
+		return nil
+	}
+	switch a.kind {
+	case AuthBasic:
+		req.SetBasicAuth(a.username, a.password)
+	case AuthBearer:
+		req.Header.Set("Authorization", "Bearer "+a.token)
+	case AuthOAuth2:
+		source, release, err := c.tokenSource()
+		if err != nil {
+			return err
+		}
+		token, err := source.Token()
+		release()
+		if err != nil {
+			return fmt.Errorf("fetch oauth2 token failed: %w", err)
+		}
+		token.SetAuthHeader(req)
+	case AuthDigest:
+	default:
+		return fmt.Errorf("unsupported auth type: %s", a.kind)
+	}
+	return nil
+}
+
+// tokenSource 返回监控的令牌源，未过期前各次检测共用；没有监控 ID 时不缓存，用完调用 release 关闭连接
+func (c *HTTPChecker) tokenSource() (oauth2.TokenSource, func(), error) {
+	// 令牌请求使用单独的 Transport：沿用代理、源地址、CA 与客户端证书，
+	// 但不受检测强制的 h2c/h3 协议影响，也不经过 HAR 记录与 digest 处理
+	tlsConfig, err := c.tlsConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+	newCached := func(key string) (*cachedToken, error) {
+		transport, err := c.newHTTPTransport(tlsConfig, nil)
+		if err != nil {
+			return nil, err
+		}
+		client := &http.Client{Transport: transport, Timeout: c.timeout}
+		ctx := context.WithValue(context.Background(), oauth2.HTTPClient, client)
+		return &cachedToken{key: key, source: c.auth.oauth2.TokenSource(ctx), transport: transport}, nil
+	}
+
+	if c.monitorID == "" {
+		cached, err := newCached("")
+		if err != nil {
+			return nil, nil, err
+		}
+		return cached.source, cached.transport.CloseIdleConnections, nil
+	}
+
+	key := c.tokenKey()
+	tokenMu.Lock()
+	defer tokenMu.Unlock()
+	if cached, ok := tokenSources[c.monitorID]; ok {
+		if cached.key == key {
+			return cached.source, func() {}, nil
+		}
+		cached.transport.CloseIdleConnections()
+		delete(tokenSources, c.monitorID)
+	}
+	cached, err := newCached(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	tokenSources[c.monitorID] = cached
+	return cached.source, func() {}, nil
+}
+
+// tokenKey 令牌配置与令牌请求 Transport 配置的指纹，密钥只参与哈希
+func (c *HTTPChecker) tokenKey() string {
+	cfg := c.auth.oauth2
+	sum := sha256.New()
+	for _, s := range append([]string{c.transportKey(), cfg.TokenURL, cfg.ClientID, cfg.ClientSecret}, cfg.Scopes...) {
+		sum.Write([]byte(s))
+		sum.Write([]byte{0})
+	}
+	return hex.EncodeToString(sum.Sum(nil))
+}
+
+// releaseToken 移除监控缓存的令牌源
+func releaseToken(id string) {
+	tokenMu.Lock()
+	cached, ok := tokenSources[id]
+	delete(tokenSources, id)
+	tokenMu.Unlock()
+	if ok {
+		cached.transport.CloseIdleConnections()
+	}
+}
+
+// releaseAllTokens 移除所有缓存的令牌源
+func releaseAllTokens() {
+	tokenMu.Lock()
+	old := tokenSources
+	tokenSources = make(map[string]*cachedToken)
+	tokenMu.Unlock()
+	for _, cached := range old {
+		cached.transport.CloseIdleConnections()
+	}
+}
+
+// digestTransport 处理 Digest 认证质询
+type digestTransport struct {
+	username string
+	password string
+	next     http.RoundTripper
+}
+
+func (t *digestTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+
+	var challenge map[string]string
+	for _, h := range resp.Header.Values("WWW-Authenticate") {
+		if scheme, params, ok := strings.Cut(h, " "); ok && strings.EqualFold(scheme, "Digest") {
+			challenge = parseAuthParams(params)
+			break
+		}
+	}
+	if challenge == nil || (req.Body != nil && req.GetBody == nil) {
+		return resp, nil
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	authorization, err := digestAuthorization(challenge, req.Method, req.URL.RequestURI(), t.username, t.password)
+	if err != nil {
+		return nil, err
+	}
+	retry := req.Clone(req.Context())
+	if req.GetBody != nil {
+		if retry.Body, err = req.GetBody(); err != nil {
+			return nil, err
+		}
+	}
+	retry.Header.Set("Authorization", authorization)
+	return t.next.RoundTrip(retry)
+}
+
+// parseAuthParams 解析 realm="x", nonce="y", qop="auth" 形式的参数
+func parseAuthParams(s string) map[string]string {
+	params := make(map[string]string)
+	for s = strings.TrimSpace(s); s != ""; s = strings.TrimLeft(s, " ,") {
+		key, rest, ok := strings.Cut(s, "=")
+		if !ok {
+			break
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			var b strings.Builder
+			i := 1
+			for ; i < len(rest) && rest[i] != '"'; i++ {
+				if rest[i] == '\\' && i+1 < len(rest) {
+					i++
+				}
+				b.WriteByte(rest[i])
+			}
+			value, s = b.String(), rest[min(i+1, len(rest)):]
+		} else {
+			value, s, _ = strings.Cut(rest, ",")
+			value = strings.TrimSpace(value)
+		}
+		params[key] = value
+	}
+	return params
+}
+
+// digestAuthorization 按 RFC 7616 计算 Authorization 头，支持 MD5、SHA-256 及其 -sess 变体
+func digestAuthorization(challenge map[string]string, method, uri, username, password string) (string, error) {
+	algorithm := challenge["algorithm"]
+	if algorithm == "" {
+		algorithm = "MD5"
+	}
+	var newHash func() hash.Hash
+	switch strings.ToUpper(strings.TrimSuffix(strings.ToLower(algorithm), "-sess")) {
+	case "MD5":
+		newHash = md5.New
+	case "SHA-256":
+		newHash = sha256.New
+	default:
+		return "", fmt.Errorf("unsupported digest algorithm: %s", algorithm)
+	}
+	h := func(s string) string {
+		sum := newHash()
+		sum.Write([]byte(s))
+		return hex.EncodeToString(sum.Sum(nil))
+	}
+
+	realm, nonce := challenge["realm"], challenge["nonce"]
+	cnonce := make([]byte, 8)
+	if _, err := rand.Read(cnonce); err != nil {
+		return "", err
+	}
+	cnonceHex := hex.EncodeToString(cnonce)
+	const nc = "00000001"
+
+	ha1 := h(username + ":" + realm + ":" + password)
+	if strings.HasSuffix(strings.ToLower(algorithm), "-sess") {
+		ha1 = h(ha1 + ":" + nonce + ":" + cnonceHex)
+	}
+	ha2 := h(method + ":" + uri)
+
+	qop := ""
+	for _, q := range strings.Split(challenge["qop"], ",") {
+		if strings.TrimSpace(q) == "auth" {
+			qop = "auth"
+		}
+	}
+
+	var response string
+	if qop != "" {
+		response = h(strings.Join([]string{ha1, nonce, nc, cnonceHex, qop, ha2}, ":"))
+	} else {
+		response = h(ha1 + ":" + nonce + ":" + ha2)
+	}
+
+	header := fmt.Sprintf(`Digest username="%s", realm="%s", nonce="%s", uri="%s", algorithm=%s, response="%s"`,
+		username, realm, nonce, uri, algorithm, response)
+	if qop != "" {
+		header += fmt.Sprintf(`, qop=%s, nc=%s, cnonce="%s"`, qop, nc, cnonceHex)
+	}
+	if opaque, ok := challenge["opaque"]; ok {
+		header += fmt.Sprintf(`, opaque="%s"`, opaque)
+	}
+	return header, nil
+}
@@ -0,0 +1,128 @@
+package web_lib
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+// newTokenServer 只支持 HTTP/1.1 的令牌端点，返回签发次数计数
+func newTokenServer(t *testing.T) (*httptest.Server, *atomic.Int32) {
+	var issued atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor != 1 {
+			http.Error(w, "http/1.1 only", http.StatusHTTPVersionNotSupported)
+			return
+		}
+		if err := r.ParseForm(); err != nil || r.Form.Get("grant_type") != "client_credentials" {
+			http.Error(w, "bad grant", http.StatusBadRequest)
+			return
+		}
+		n := issued.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token":"token-%d","token_type":"Bearer","expires_in":3600}`, n)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &issued
+}
+
+// newH2CServer 同时支持 HTTP/1.1 与明文 HTTP/2 的资源服务，回显 Authorization
+func newH2CServer(t *testing.T) *httptest.Server {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Proto", r.Proto)
+		fmt.Fprint(w, r.Header.Get("Authorization"))
+	}))
+	srv.Config.Protocols = new(http.Protocols)
+	srv.Config.Protocols.SetHTTP1(true)
+	srv.Config.Protocols.SetUnencryptedHTTP2(true)
+	srv.Start()
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestOAuth2TokenClientIgnoresForcedProtocol(t *testing.T) {
+	tokenSrv, issued := newTokenServer(t)
+	resource := newH2CServer(t)
+
+	checker := NewHTTPChecker(
+		WithProtocol(ProtoH2C),
+		WithOAuth2ClientCredentials(tokenSrv.URL, "client", "secret"),
+	)
+	result := checker.Check(resource.URL)
+	if result.Error != nil {
+		t.Fatal(result.Error)
+	}
+	if result.Protocol != ProtoH2C {
+		t.Errorf("protocol = %s, want %s", result.Protocol, ProtoH2C)
+	}
+	if result.Text != "Bearer token-1" || issued.Load() != 1 {
+		t.Errorf("Authorization = %q after %d token requests", result.Text, issued.Load())
+	}
+}
+
+func TestOAuth2TokenCachePerMonitor(t *testing.T) {
+	tokenSrv, issued := newTokenServer(t)
+	resource := newH2CServer(t)
+	t.Cleanup(ReleaseAll)
+
+	check := func(id, secret string) string {
+		t.Helper()
+		result := NewHTTPChecker(
+			WithMonitorID(id),
+			WithOAuth2ClientCredentials(tokenSrv.URL, "client", secret),
+		).Check(resource.URL)
+		if result.Error != nil {
+			t.Fatal(result.Error)
+		}
+		return result.Text
+	}
+
+	first := check("monitor-a", "secret")
+	if again := check("monitor-a", "secret"); again != first || issued.Load() != 1 {
+		t.Errorf("token not reused: %q then %q, %d issued", first, again, issued.Load())
+	}
+
+	// 其他监控不共用令牌
+	if other := check("monitor-b", "secret"); other == first || issued.Load() != 2 {
+		t.Errorf("monitor-b reused %q, %d issued", other, issued.Load())
+	}
+
+	// 配置变化时重新获取
+	if rotated := check("monitor-a", "rotated"); rotated == first || issued.Load() != 3 {
+		t.Errorf("secret change reused %q, %d issued", rotated, issued.Load())
+	}
+
+	// Release 后缓存被删除
+	Release("monitor-a")
+	tokenMu.Lock()
+	_, cached := tokenSources["monitor-a"]
+	tokenMu.Unlock()
+	if cached {
+		t.Error("Release did not remove the token source")
+	}
+	check("monitor-a", "rotated")
+	if issued.Load() != 4 {
+		t.Errorf("expected a new token after Release, %d issued", issued.Load())
+	}
+}
+
+func TestParseAuthParams(t *testing.T) {
+	got := parseAuthParams(`realm="api@example.com", qop="auth,auth-int", nonce="a\"b", algorithm=SHA-256, stale=false`)
+	want := map[string]string{
+		"realm":     "api@example.com",
+		"qop":       "auth,auth-int",
+		"nonce":     `a"b`,
+		"algorithm": "SHA-256",
+		"stale":     "false",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("parseAuthParams = %v", got)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s = %q, want %q", k, got[k], v)
+		}
+	}
+}
@@ -0,0 +1,265 @@
+package web_lib
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"redrock-dashboard/core/pkg/har"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// harMaxContent HAR 中保存的请求体与响应体上限，Size 仍为完整大小
+const harMaxContent = 64 << 10
+
+// WithHAR 记录本次检测的 HAR，secrets 为需要在 HAR 中替换掉的密钥值
+// 认证头与 cookie 总是被替换
+func WithHAR(secrets ...string) CheckerOption {
+	return func(c *HTTPChecker) {
+		c.har = true
+		c.harSecrets = append(c.harSecrets, secrets...)
+	}
+}
+
+// harRecorder 记录经过的每个请求，放在最内层，digest 质询与跳转都会单独成为一条记录
+type harRecorder struct {
+	next    http.RoundTripper
+	entries []*harEntry
+}
+
+type harEntry struct {
+	entry     har.Entry
+	trace     *tracer
+	gotConn   time.Time
+	responded time.Time
+	body      *harBody
+}
+
+func (r *harRecorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	e := &harEntry{trace: &tracer{}}
+	e.entry.StartedDateTime = time.Now()
+	e.trace.setStart(e.entry.StartedDateTime)
+	e.entry.Request = harRequest(req)
+	r.entries = append(r.entries, e)
+
+	ctx := httptrace.WithClientTrace(req.Context(), e.trace.clientTrace())
+	ctx = httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			e.gotConn = time.Now()
+			if host, _, err := net.SplitHostPort(info.Conn.RemoteAddr().String()); err == nil {
+				e.entry.ServerIPAddress = host
+			}
+		},
+	})
+
+	resp, err := r.next.RoundTrip(req.WithContext(ctx))
+	e.responded = time.Now()
+	if err != nil {
+		e.entry.Error = err.Error()
+		e.entry.Response = har.Response{Cookies: []har.NameValue{}, Headers: []har.NameValue{}, HeadersSize: -1, BodySize: -1}
+		return resp, err
+	}
+	e.entry.Request.HTTPVersion = resp.Proto
+	e.entry.Response = harResponse(resp)
+	e.body = &harBody{ReadCloser: resp.Body}
+	resp.Body = e.body
+	return resp, nil
+}
+
+// build 生成脱敏后的 HAR，需要在响应体读完并关闭之后调用
+func (r *harRecorder) build(secrets []string) ([]byte, error) {
+	doc := har.HAR{Log: har.Log{
+		Version: "1.2",
+		Creator: har.Creator{Name: "redrock-dashboard", Version: "1.0"},
+		Entries: make([]har.Entry, 0, len(r.entries)),
+	}}
+	for _, e := range r.entries {
+		doc.Log.Entries = append(doc.Log.Entries, e.finish())
+	}
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	return har.Redact(data, secrets...)
+}
+
+// finish 填写响应体与各阶段耗时，缺少时间点的阶段按 HAR 规范记为 -1 或 0
+func (e *harEntry) finish() har.Entry {
+	t := e.trace.snapshot()
+	timings := har.Timings{
+		DNS: millis(t.dnsStart, t.dnsDone),
+		SSL: millis(t.tlsStart, t.tlsDone),
+	}
+	connectDone := t.connectDone
+	if !t.tlsDone.IsZero() {
+		connectDone = t.tlsDone
+	}
+	timings.Connect = millis(t.connectStart, connectDone)
+
+	// 没有 httptrace 事件时（如 HTTP/3）整个请求计入 wait
+	timings.Blocked = -1
+	if !e.gotConn.IsZero() {
+		timings.Blocked = millis(t.start, e.gotConn) - max(timings.DNS, 0) - max(timings.Connect, 0)
+		timings.Blocked = max(timings.Blocked, 0)
+	}
+	timings.Send = max(millis(e.gotConn, t.wroteRequest), 0)
+	firstByte := t.firstByte
+	if firstByte.IsZero() {
+		firstByte = e.responded
+	}
+	if t.wroteRequest.IsZero() {
+		timings.Wait = max(millis(t.start, firstByte), 0)
+	} else {
+		timings.Wait = max(millis(t.wroteRequest, firstByte), 0)
+	}
+	if e.body != nil {
+		timings.Receive = max(millis(firstByte, e.body.done), 0)
+		e.entry.Response.Content = harContent(e.entry.Response.Content.MimeType, e.body.data.Bytes(), e.body.n)
+		e.entry.Response.BodySize = e.body.n
+	}
+	e.entry.Timings = timings
+
+	e.entry.Time = 0
+	for _, d := range []float64{timings.Blocked, timings.DNS, timings.Connect, timings.Send, timings.Wait, timings.Receive} {
+		if d > 0 {
+			e.entry.Time += d
+		}
+	}
+	return e.entry
+}
+
+func harRequest(req *http.Request) har.Request {
+	r := har.Request{
+		Method:      req.Method,
+		URL:         req.URL.String(),
+		HTTPVersion: req.Proto,
+		Cookies:     []har.NameValue{},
+		Headers:     harHeaders(req.Header),
+		QueryString: []har.NameValue{},
+		HeadersSize: -1,
+		BodySize:    req.ContentLength,
+	}
+	for _, c := range req.Cookies() {
+		r.Cookies = append(r.Cookies, har.NameValue{Name: c.Name, Value: c.Value})
+	}
+	query := req.URL.Query()
+	for _, name := range sortedKeys(query) {
+		for _, v := range query[name] {
+			r.QueryString = append(r.QueryString, har.NameValue{Name: name, Value: v})
+		}
+	}
+	// GetBody 返回请求体的新副本，不影响真正发送的内容
+	if req.GetBody != nil && req.ContentLength != 0 {
+		if body, err := req.GetBody(); err == nil {
+			data, _ := io.ReadAll(io.LimitReader(body, harMaxContent))
+			body.Close()
+			r.PostData = &har.PostData{MimeType: req.Header.Get("Content-Type"), Text: string(data)}
+		}
+	}
+	return r
+}
+
+func harResponse(resp *http.Response) har.Response {
+	r := har.Response{
+		Status:      resp.StatusCode,
+		StatusText:  strings.TrimSpace(strings.TrimPrefix(resp.Status, strconv.Itoa(resp.StatusCode))),
+		HTTPVersion: resp.Proto,
+		Cookies:     []har.NameValue{},
+		Headers:     harHeaders(resp.Header),
+		Content:     har.Content{MimeType: resp.Header.Get("Content-Type")},
+		RedirectURL: resp.Header.Get("Location"),
+		HeadersSize: -1,
+	}
+	for _, c := range resp.Cookies() {
+		r.Cookies = append(r.Cookies, har.NameValue{Name: c.Name, Value: c.Value})
+	}
+	return r
+}
+
+func harHeaders(header http.Header) []har.NameValue {
+	headers := []har.NameValue{}
+	for _, name := range sortedKeys(header) {
+		for _, v := range header[name] {
+			headers = append(headers, har.NameValue{Name: name, Value: v})
+		}
+	}
+	return headers
+}
+
+// harContent 文本内容直接保存，其余按 base64 保存
+func harContent(mimeType string, data []byte, size int64) har.Content {
+	content := har.Content{Size: size, MimeType: mimeType}
+	if len(data) == 0 {
+		return content
+	}
+	if isText(mimeType) && utf8.Valid(data) {
+		content.Text = string(data)
+	} else {
+		content.Text = base64.StdEncoding.EncodeToString(data)
+		content.Encoding = "base64"
+	}
+	return content
+}
+
+func isText(mimeType string) bool {
+	mimeType = strings.ToLower(mimeType)
+	if mimeType == "" || strings.HasPrefix(mimeType, "text/") {
+		return true
+	}
+	for _, s := range []string{"json", "xml", "javascript", "x-www-form-urlencoded"} {
+		if strings.Contains(mimeType, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func millis(from, to time.Time) float64 {
+	if from.IsZero() || to.IsZero() || to.Before(from) {
+		return -1
+	}
+	return float64(to.Sub(from)) / float64(time.Millisecond)
+}
+
+// harBody 边读边保存响应体的前 harMaxContent 字节，并记录读完的时间
+type harBody struct {
+	io.ReadCloser
+	data bytes.Buffer
+	n    int64
+	done time.Time
+}
+
+func (b *harBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.n += int64(n)
+	if room := harMaxContent - b.data.Len(); room > 0 {
+		b.data.Write(p[:min(n, room)])
+	}
+	if err != nil && b.done.IsZero() {
+		b.done = time.Now()
+	}
+	return n, err
+}
+
+func (b *harBody) Close() error {
+	if b.done.IsZero() {
+		b.done = time.Now()
+	}
+	return b.ReadCloser.Close()
+}
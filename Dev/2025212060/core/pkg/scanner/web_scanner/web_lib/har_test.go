@@ -0,0 +1,193 @@
+package web_lib
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"redrock-dashboard/core/pkg/har"
+	"strings"
+	"testing"
+)
+
+func decodeHAR(t *testing.T, data []byte) har.HAR {
+	t.Helper()
+	if data == nil {
+		t.Fatal("no HAR recorded")
+	}
+	var doc har.HAR
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("invalid HAR: %v", err)
+	}
+	return doc
+}
+
+func headerValue(headers []har.NameValue, name string) string {
+	for _, h := range headers {
+		if strings.EqualFold(h.Name, name) {
+			return h.Value
+		}
+	}
+	return ""
+}
+
+func TestHARRedirectChain(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/data?token=hunter2&page=1", http.StatusFound)
+	})
+	mux.HandleFunc("/data", func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "sid", Value: "session-value"})
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status":"ok","echo":"hunter2"}`))
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	result := NewHTTPChecker(
+		WithMethod(http.MethodPost),
+		WithJSONBody(`{"password":"hunter2","user":"admin"}`),
+		WithBearerToken("bearer-value"),
+		WithHeader("Cookie", "pref=dark"),
+		WithHAR("hunter2"),
+	).Check(srv.URL + "/login")
+	if result.Error != nil || !result.Available {
+		t.Fatalf("error %v, assertions %+v", result.Error, result.Assertions)
+	}
+	for _, leaked := range []string{"hunter2", "bearer-value", "session-value", "pref=dark", "dark"} {
+		if bytes.Contains(result.HAR, []byte(leaked)) {
+			t.Errorf("HAR contains %q", leaked)
+		}
+	}
+
+	doc := decodeHAR(t, result.HAR)
+	if doc.Log.Version != "1.2" || len(doc.Log.Entries) != 2 {
+		t.Fatalf("log = %+v", doc.Log)
+	}
+	first, second := doc.Log.Entries[0], doc.Log.Entries[1]
+
+	if first.Request.Method != http.MethodPost || first.Request.PostData == nil ||
+		first.Request.PostData.MimeType != "application/json" ||
+		first.Request.PostData.Text != `{"password":"`+har.Redacted+`","user":"admin"}` {
+		t.Errorf("first request = %+v", first.Request)
+	}
+	if got := headerValue(first.Request.Headers, "Authorization"); got != har.Redacted {
+		t.Errorf("Authorization = %q", got)
+	}
+	if len(first.Request.Cookies) != 1 || first.Request.Cookies[0].Name != "pref" || first.Request.Cookies[0].Value != har.Redacted {
+		t.Errorf("request cookies = %+v", first.Request.Cookies)
+	}
+	if first.Response.Status != http.StatusFound || first.Response.StatusText != "Found" ||
+		first.Response.RedirectURL != "/data?token="+har.Redacted+"&page=1" {
+		t.Errorf("first response = %+v", first.Response)
+	}
+
+	// 302 之后改为不带请求体的 GET
+	if second.Request.Method != http.MethodGet || second.Request.PostData != nil {
+		t.Errorf("second request = %+v", second.Request)
+	}
+	wantQuery := []har.NameValue{{Name: "page", Value: "1"}, {Name: "token", Value: har.Redacted}}
+	if len(second.Request.QueryString) != 2 || second.Request.QueryString[0] != wantQuery[0] || second.Request.QueryString[1] != wantQuery[1] {
+		t.Errorf("query = %+v", second.Request.QueryString)
+	}
+	if second.Response.Status != http.StatusOK || second.Response.HTTPVersion != "HTTP/1.1" {
+		t.Errorf("second response = %+v", second.Response)
+	}
+	if content := second.Response.Content; content.Text != `{"status":"ok","echo":"`+har.Redacted+`"}` || content.Size != 32 || content.Encoding != "" {
+		t.Errorf("content = %+v", content)
+	}
+	if got := headerValue(second.Response.Headers, "Set-Cookie"); got != har.Redacted {
+		t.Errorf("Set-Cookie = %q", got)
+	}
+	if len(second.Response.Cookies) != 1 || second.Response.Cookies[0].Value != har.Redacted {
+		t.Errorf("response cookies = %+v", second.Response.Cookies)
+	}
+
+	host, _, _ := net.SplitHostPort(srv.Listener.Addr().String())
+	for i, e := range doc.Log.Entries {
+		if e.ServerIPAddress != host {
+			t.Errorf("entry %d server ip = %q", i, e.ServerIPAddress)
+		}
+		if e.Timings.Wait < 0 || e.Timings.Send < 0 || e.Timings.Receive < 0 || e.Timings.SSL != -1 || e.Time <= 0 {
+			t.Errorf("entry %d timings = %+v time %v", i, e.Timings, e.Time)
+		}
+	}
+}
+
+func TestHARContent(t *testing.T) {
+	binary := bytes.Repeat([]byte{0x89, 'P', 'N', 'G', 0x00, 0xff}, 10)
+	large := strings.Repeat("x", harMaxContent+100)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/image", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(binary)
+	})
+	mux.HandleFunc("/large", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte(large))
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	doc := decodeHAR(t, NewHTTPChecker(WithHAR()).Check(srv.URL+"/image").HAR)
+	content := doc.Log.Entries[0].Response.Content
+	if content.Encoding != "base64" || content.Size != int64(len(binary)) || content.Text != base64.StdEncoding.EncodeToString(binary) {
+		t.Errorf("binary content = %+v", content)
+	}
+
+	doc = decodeHAR(t, NewHTTPChecker(WithHAR()).Check(srv.URL+"/large").HAR)
+	entry := doc.Log.Entries[0]
+	if len(entry.Response.Content.Text) != harMaxContent || entry.Response.Content.Size != int64(len(large)) || entry.Response.BodySize != int64(len(large)) {
+		t.Errorf("large content: text %d size %d body %d", len(entry.Response.Content.Text), entry.Response.Content.Size, entry.Response.BodySize)
+	}
+}
+
+func TestHARFailedRequest(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	result := NewHTTPChecker(WithHAR()).Check("http://" + addr + "/")
+	if result.Error == nil {
+		t.Fatal("expected connection error")
+	}
+	doc := decodeHAR(t, result.HAR)
+	if len(doc.Log.Entries) != 1 {
+		t.Fatalf("entries = %+v", doc.Log.Entries)
+	}
+	e := doc.Log.Entries[0]
+	if e.Error == "" || e.Response.Status != 0 || e.Response.BodySize != -1 || e.Response.Headers == nil {
+		t.Errorf("failed entry = %+v", e)
+	}
+}
+
+func TestHARDisabled(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	t.Cleanup(srv.Close)
+	if result := NewHTTPChecker().Check(srv.URL); result.HAR != nil {
+		t.Fatalf("HAR recorded without WithHAR: %s", result.HAR)
+	}
+}
+
+func TestIsText(t *testing.T) {
+	for mime, want := range map[string]bool{
+		"":                                  true,
+		"text/html; charset=utf-8":          true,
+		"Application/JSON":                  true,
+		"application/problem+json":          true,
+		"application/xml":                   true,
+		"application/javascript":            true,
+		"application/x-www-form-urlencoded": true,
+		"image/png":                         false,
+		"application/octet-stream":          false,
+	} {
+		if got := isText(mime); got != want {
+			t.Errorf("isText(%q) = %v, want %v", mime, got, want)
+		}
+	}
+}
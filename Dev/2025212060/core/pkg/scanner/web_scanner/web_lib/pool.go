@@ -0,0 +1,145 @@
+package web_lib
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// 连接模式
+const (
+	ConnKeepAlive = "keep-alive" // 同一监控复用连接，测到的是热连接耗时
+	ConnFresh     = "fresh"      // 每次检测新建连接，测到完整的 DNS、建连与 TLS 耗时
+)
+
+// idleConnTimeout 空闲连接最长保留时间，防止调度间隔很长时占着套接字
+const idleConnTimeout = 90 * time.Second
+
+// cachedTransport 监控共用的 Transport，key 为配置指纹，配置变化时重建。
+// 移出缓存时可能还有检测在用，由最后一个使用者关闭，避免 HTTP/3 的进行中请求被取消
+type cachedTransport struct {
+	key       string
+	transport roundTripper
+	refs      int  // 正在使用的检测数，受 transportMu 保护
+	retired   bool // 已移出缓存
+}
+
+var (
+	transportMu sync.Mutex
+	transports  = make(map[string]*cachedTransport)
+)
+
+// retireLocked 标记已移出缓存，返回是否可以立即关闭，调用方需持有 transportMu
+func (t *cachedTransport) retireLocked() bool {
+	t.retired = true
+	return t.refs == 0
+}
+
+// release 结束一次使用，已移出缓存且没有其他使用者时关闭
+func (t *cachedTransport) release() {
+	transportMu.Lock()
+	t.refs--
+	closeNow := t.retired && t.refs == 0
+	transportMu.Unlock()
+	if closeNow {
+		closeTransport(t.transport)
+	}
+}
+
+// WithMonitorID 指定所属监控，keep-alive 模式下同一监控的检测共用 Transport
+func WithMonitorID(id string) CheckerOption {
+	return func(c *HTTPChecker) { c.monitorID = id }
+}
+
+func WithConnectionMode(mode string) CheckerOption {
+	return func(c *HTTPChecker) { c.connMode = mode }
+}
+
+// Release 关闭并移除监控的连接与 OAuth2 令牌，删除或停用监控时调用。
+// 正在进行的检测不受影响，其连接在检测结束后关闭
+func Release(id string) {
+	releaseToken(id)
+	transportMu.Lock()
+	cached, ok := transports[id]
+	delete(transports, id)
+	closeNow := ok && cached.retireLocked()
+	transportMu.Unlock()
+	if closeNow {
+		closeTransport(cached.transport)
+	}
+}
+
+// ReleaseAll 关闭所有缓存的连接，程序退出时调用
+func ReleaseAll() {
+	releaseAllTokens()
+	transportMu.Lock()
+	var idle []*cachedTransport
+	for _, cached := range transports {
+		if cached.retireLocked() {
+			idle = append(idle, cached)
+		}
+	}
+	transports = make(map[string]*cachedTransport)
+	transportMu.Unlock()
+	for _, cached := range idle {
+		closeTransport(cached.transport)
+	}
+}
+
+// acquireTransport 返回本次检测使用的 Transport，检测结束后必须调用 release
+func (c *HTTPChecker) acquireTransport() (roundTripper, func(), error) {
+	switch c.connMode {
+	case ConnKeepAlive, ConnFresh:
+	default:
+		return nil, nil, fmt.Errorf("unsupported connection mode: %s", c.connMode)
+	}
+
+	// 没有监控 ID 或要求新连接时不缓存，用完立即关闭
+	if c.monitorID == "" || c.connMode == ConnFresh {
+		transport, err := c.newTransport()
+		if err != nil {
+			return nil, nil, err
+		}
+		if t, ok := transport.(*http.Transport); ok {
+			t.DisableKeepAlives = c.connMode == ConnFresh
+		}
+		return transport, func() { closeTransport(transport) }, nil
+	}
+
+	key := c.transportKey()
+	transportMu.Lock()
+	defer transportMu.Unlock()
+	if cached, ok := transports[c.monitorID]; ok {
+		if cached.key == key {
+			cached.refs++
+			return cached.transport, cached.release, nil
+		}
+		// 配置变化：旧 Transport 移出缓存，仍有检测在用时等它们结束后再关闭
+		delete(transports, c.monitorID)
+		if cached.retireLocked() {
+			closeTransport(cached.transport)
+		}
+	}
+
+	transport, err := c.newTransport()
+	if err != nil {
+		return nil, nil, err
+	}
+	cached := &cachedTransport{key: key, transport: transport, refs: 1}
+	transports[c.monitorID] = cached
+	return transport, cached.release, nil
+}
+
+// transportKey 影响 Transport 的配置指纹
+func (c *HTTPChecker) transportKey() string {
+	sum := sha256.New()
+	fmt.Fprintf(sum, "%v\x00%v\x00%s\x00%s\x00%s\x00", c.timeout, c.skipTLSVerify, c.proxy, c.sourceAddr, c.protocol)
+	for _, b := range [][]byte{c.clientCertPEM, c.clientKeyPEM, c.caPEM} {
+		sum.Write(b)
+		sum.Write([]byte{0})
+	}
+	return hex.EncodeToString(sum.Sum(nil))
+}
@@ -0,0 +1,162 @@
+package web_lib
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newAddrServer 返回请求的源地址，用来判断是否复用了连接
+func newAddrServer(t *testing.T) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, r.RemoteAddr)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func checkAddrs(t *testing.T, url string, opts ...CheckerOption) (string, string) {
+	t.Helper()
+	var addrs [2]string
+	for i := range addrs {
+		result := NewHTTPChecker(opts...).Check(url)
+		if result.Error != nil {
+			t.Fatal(result.Error)
+		}
+		addrs[i] = result.Text
+	}
+	return addrs[0], addrs[1]
+}
+
+func cachedRefs(id string) (refs int, ok bool) {
+	transportMu.Lock()
+	defer transportMu.Unlock()
+	cached, ok := transports[id]
+	if !ok {
+		return 0, false
+	}
+	return cached.refs, true
+}
+
+func TestKeepAliveReuse(t *testing.T) {
+	srv := newAddrServer(t)
+	t.Cleanup(func() { Release("keep-alive") })
+
+	first, second := checkAddrs(t, srv.URL, WithMonitorID("keep-alive"))
+	if first != second {
+		t.Errorf("keep-alive used two connections: %s, %s", first, second)
+	}
+	if refs, ok := cachedRefs("keep-alive"); !ok || refs != 0 {
+		t.Errorf("cached = %v, refs = %d", ok, refs)
+	}
+
+	// 没有监控 ID 时不缓存
+	if first, second := checkAddrs(t, srv.URL); first == second {
+		t.Errorf("checks without monitor id shared connection %s", first)
+	}
+}
+
+func TestFreshMode(t *testing.T) {
+	srv := newAddrServer(t)
+
+	first, second := checkAddrs(t, srv.URL, WithMonitorID("fresh"), WithConnectionMode(ConnFresh))
+	if first == second {
+		t.Errorf("fresh mode reused connection %s", first)
+	}
+	if _, ok := cachedRefs("fresh"); ok {
+		t.Error("fresh mode cached a transport")
+	}
+
+	if result := NewHTTPChecker(WithConnectionMode("pooled")).Check(srv.URL); result.Error == nil {
+		t.Error("unknown connection mode accepted")
+	}
+}
+
+// slowHTTP3 启动 HTTP/3 服务，/slow 在 unblock 关闭前不返回
+func slowHTTP3(t *testing.T) (url string, started <-chan struct{}, unblock chan<- struct{}) {
+	startedCh := make(chan struct{}, 1)
+	unblockCh := make(chan struct{})
+	url = newHTTP3Server(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			startedCh <- struct{}{}
+			<-unblockCh
+		}
+		fmt.Fprint(w, r.URL.Path)
+	}))
+	return url, startedCh, unblockCh
+}
+
+// 在检测进行中的 HTTP/3 请求后台运行，返回其结果
+func checkInFlight(t *testing.T, url string, started <-chan struct{}, opts ...CheckerOption) <-chan *CheckResult {
+	results := make(chan *CheckResult, 1)
+	go func() { results <- NewHTTPChecker(opts...).Check(url + "/slow") }()
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("slow request never reached the server")
+	}
+	return results
+}
+
+// 配置变化时旧 Transport 上进行中的请求不被取消
+func TestConfigChangeKeepsInFlight(t *testing.T) {
+	url, started, unblock := slowHTTP3(t)
+	t.Cleanup(func() { Release("config") })
+
+	opts := []CheckerOption{WithMonitorID("config"), WithProtocol(ProtoHTTP3), SkipTLSVerify()}
+	results := checkInFlight(t, url, started, append(opts, WithTimeout(5*time.Second))...)
+
+	changed := NewHTTPChecker(append(opts, WithTimeout(4*time.Second))...)
+	if result := changed.Check(url + "/fast"); result.Error != nil || result.Text != "/fast" {
+		t.Fatalf("check after config change: %v, %q", result.Error, result.Text)
+	}
+
+	close(unblock)
+	if result := <-results; result.Error != nil || result.Text != "/slow" {
+		t.Fatalf("in-flight check: %v, %q", result.Error, result.Text)
+	}
+
+	transportMu.Lock()
+	cached := transports["config"]
+	transportMu.Unlock()
+	if cached == nil || cached.key != changed.transportKey() || cached.refs != 0 {
+		t.Errorf("cached transport = %+v", cached)
+	}
+}
+
+func TestRelease(t *testing.T) {
+	url, started, unblock := slowHTTP3(t)
+	opts := []CheckerOption{WithMonitorID("release"), WithProtocol(ProtoHTTP3), SkipTLSVerify(), WithTimeout(5 * time.Second)}
+
+	results := checkInFlight(t, url, started, opts...)
+	transportMu.Lock()
+	cached := transports["release"]
+	transportMu.Unlock()
+
+	Release("release")
+	if _, ok := cachedRefs("release"); ok {
+		t.Error("transport still cached after Release")
+	}
+	if !cached.retired || cached.refs != 1 {
+		t.Errorf("released transport retired %v, refs %d", cached.retired, cached.refs)
+	}
+
+	close(unblock)
+	if result := <-results; result.Error != nil || result.Text != "/slow" {
+		t.Fatalf("in-flight check cancelled by Release: %v, %q", result.Error, result.Text)
+	}
+	if cached.refs != 0 {
+		t.Errorf("refs = %d after check finished", cached.refs)
+	}
+
+	// 释放后再次检测重新建立 Transport
+	if result := NewHTTPChecker(opts...).Check(url + "/again"); result.Error != nil {
+		t.Fatal(result.Error)
+	}
+	if refs, ok := cachedRefs("release"); !ok || refs != 0 {
+		t.Errorf("cached = %v, refs = %d", ok, refs)
+	}
+	Release("release")
+}
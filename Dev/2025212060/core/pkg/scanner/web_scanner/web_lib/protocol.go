@@ -0,0 +1,118 @@
+package web_lib
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/http3"
+)
+
+// 强制使用的 HTTP 协议
+const (
+	ProtoAuto  = ""         // 按 ALPN 协商 h2 或 http/1.1
+	ProtoHTTP1 = "http/1.1" // 只用 HTTP/1.1
+	ProtoHTTP2 = "h2"       // 必须通过 TLS 协商到 HTTP/2
+	ProtoH2C   = "h2c"      // 明文 HTTP/2，使用 prior knowledge 直接发送 HTTP/2 帧
+	ProtoHTTP3 = "h3"       // HTTP/3 over QUIC
+)
+
+// WithProtocol 强制使用指定协议，服务端不支持时检测失败
+func WithProtocol(proto string) CheckerOption {
+	return func(c *HTTPChecker) { c.protocol = strings.ToLower(proto) }
+}
+
+// roundTripper 可以释放连接的 RoundTripper
+type roundTripper interface {
+	http.RoundTripper
+	CloseIdleConnections()
+}
+
+// closeTransport 释放 Transport，HTTP/3 需要关闭底层的 UDP 套接字
+func closeTransport(rt roundTripper) {
+	if closer, ok := rt.(io.Closer); ok {
+		_ = closer.Close()
+		return
+	}
+	rt.CloseIdleConnections()
+}
+
+// httpProtocols 返回 http.Transport 允许的协议
+func (c *HTTPChecker) httpProtocols() (*http.Protocols, error) {
+	protocols := new(http.Protocols)
+	switch c.protocol {
+	case ProtoAuto:
+		protocols.SetHTTP1(true)
+		protocols.SetHTTP2(true)
+	case ProtoHTTP1:
+		protocols.SetHTTP1(true)
+	case ProtoHTTP2:
+		protocols.SetHTTP2(true)
+	case ProtoH2C:
+		protocols.SetUnencryptedHTTP2(true)
+	default:
+		return nil, fmt.Errorf("unsupported protocol: %s", c.protocol)
+	}
+	return protocols, nil
+}
+
+// http3Transport 包装 http3.Transport，绑定源地址时自建的 UDP 套接字随之关闭
+type http3Transport struct {
+	*http3.Transport
+	quic *quic.Transport
+}
+
+func (t *http3Transport) Close() error {
+	err := t.Transport.Close()
+	if t.quic != nil {
+		t.quic.Close()
+		t.quic.Conn.Close()
+	}
+	return err
+}
+
+// newHTTP3Transport 创建 HTTP/3 Transport，QUIC 不支持代理
+func (c *HTTPChecker) newHTTP3Transport(tlsConfig *tls.Config) (roundTripper, error) {
+	if c.proxy != "" {
+		return nil, fmt.Errorf("proxy is not supported for %s", ProtoHTTP3)
+	}
+
+	transport := &http3Transport{Transport: &http3.Transport{TLSClientConfig: tlsConfig}}
+	if c.sourceAddr != "" {
+		ip := net.ParseIP(c.sourceAddr)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid source address: %s", c.sourceAddr)
+		}
+		conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: ip})
+		if err != nil {
+			return nil, fmt.Errorf("bind source address failed: %w", err)
+		}
+		transport.quic = &quic.Transport{Conn: conn}
+		transport.Dial = func(ctx context.Context, addr string, tlsCfg *tls.Config, cfg *quic.Config) (*quic.Conn, error) {
+			remote, err := net.ResolveUDPAddr("udp", addr)
+			if err != nil {
+				return nil, err
+			}
+			return transport.quic.DialEarly(ctx, remote, tlsCfg, cfg)
+		}
+	}
+	return transport, nil
+}
+
+// negotiatedProtocol 返回实际使用的协议，名称与 WithProtocol 一致
+func negotiatedProtocol(resp *http.Response) string {
+	switch {
+	case resp.ProtoMajor == 3:
+		return ProtoHTTP3
+	case resp.ProtoMajor == 2 && resp.TLS == nil:
+		return ProtoH2C
+	case resp.ProtoMajor == 2:
+		return ProtoHTTP2
+	}
+	return strings.ToLower(resp.Proto)
+}
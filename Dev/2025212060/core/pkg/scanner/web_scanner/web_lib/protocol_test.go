@@ -0,0 +1,145 @@
+package web_lib
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/quic-go/quic-go/http3"
+)
+
+var echoProto = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	fmt.Fprint(w, r.Proto)
+})
+
+// newTLSServer 启动 TLS 测试服务，h2 为 false 时只提供 HTTP/1.1
+func newTLSServer(t *testing.T, h2 bool) *httptest.Server {
+	srv := httptest.NewUnstartedServer(echoProto)
+	srv.EnableHTTP2 = h2
+	// 强制 h2 失败时的握手错误是预期内的
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0)
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// newHTTP3Server 在本机 UDP 端口上启动 HTTP/3 服务，证书复用 httptest 的测试证书
+func newHTTP3Server(t *testing.T, handler http.Handler) string {
+	certSrv := httptest.NewTLSServer(echoProto)
+	certs := certSrv.TLS.Certificates
+	certSrv.Close()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := &http3.Server{
+		Handler:   handler,
+		TLSConfig: http3.ConfigureTLSConfig(&tls.Config{Certificates: certs}),
+	}
+	go srv.Serve(conn)
+	t.Cleanup(func() {
+		srv.Close()
+		conn.Close()
+	})
+	return "https://" + conn.LocalAddr().String()
+}
+
+func TestProtocolNegotiation(t *testing.T) {
+	h2 := newTLSServer(t, true)
+	h1 := newTLSServer(t, false)
+	plain := httptest.NewServer(echoProto)
+	t.Cleanup(plain.Close)
+	h2c := newH2CServer(t)
+
+	tests := []struct {
+		name     string
+		protocol string
+		url      string
+		want     string // 为空表示检测应失败
+	}{
+		{"auto negotiates h2", ProtoAuto, h2.URL, ProtoHTTP2},
+		{"auto falls back to http/1.1", ProtoAuto, h1.URL, ProtoHTTP1},
+		{"auto over plain http", ProtoAuto, plain.URL, ProtoHTTP1},
+		{"forced http/1.1 on h2 server", ProtoHTTP1, h2.URL, ProtoHTTP1},
+		{"forced h2", ProtoHTTP2, h2.URL, ProtoHTTP2},
+		{"forced h2 without h2 support", ProtoHTTP2, h1.URL, ""},
+		{"forced h2c", ProtoH2C, h2c.URL, ProtoH2C},
+		{"forced h2c on http/1.1 only server", ProtoH2C, plain.URL, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := NewHTTPChecker(WithProtocol(tt.protocol), SkipTLSVerify()).Check(tt.url)
+			if tt.want == "" {
+				if result.Error == nil {
+					t.Fatalf("expected failure, got protocol %s", result.Protocol)
+				}
+				return
+			}
+			if result.Error != nil {
+				t.Fatal(result.Error)
+			}
+			if result.Protocol != tt.want {
+				t.Errorf("protocol = %s, want %s", result.Protocol, tt.want)
+			}
+		})
+	}
+}
+
+func TestHTTP3(t *testing.T) {
+	url := newHTTP3Server(t, echoProto)
+
+	result := NewHTTPChecker(WithProtocol(ProtoHTTP3), SkipTLSVerify()).Check(url)
+	if result.Error != nil {
+		t.Fatal(result.Error)
+	}
+	if result.Protocol != ProtoHTTP3 || result.Text != "HTTP/3.0" {
+		t.Errorf("protocol = %s, body = %q", result.Protocol, result.Text)
+	}
+}
+
+// 强制 h3 时不回退到 TCP：只有 h2 服务的地址检测失败
+func TestHTTP3NoFallback(t *testing.T) {
+	h2 := newTLSServer(t, true)
+
+	result := NewHTTPChecker(WithProtocol(ProtoHTTP3), SkipTLSVerify(), WithTimeout(time.Second)).Check(h2.URL)
+	if result.Error == nil {
+		t.Fatalf("forced h3 against a TCP-only server succeeded with %s", result.Protocol)
+	}
+}
+
+func TestHTTP3RejectsProxy(t *testing.T) {
+	result := NewHTTPChecker(WithProtocol(ProtoHTTP3), WithProxy("http://127.0.0.1:3128")).Check("https://example.com")
+	if result.Error == nil {
+		t.Error("expected proxy to be rejected for h3")
+	}
+}
+
+// 期望 h2 但服务端降级为 HTTP/1.1 时协议断言失败
+func TestProtocolAssertion(t *testing.T) {
+	h1 := newTLSServer(t, false)
+
+	result := NewHTTPChecker(
+		SkipTLSVerify(),
+		WithAssertion(Assertion{Type: AssertProtocol, Operator: OpEqual, Value: ProtoHTTP2}),
+	).Check(h1.URL)
+	if result.Available {
+		t.Fatal("check should fail when the expected protocol is not served")
+	}
+	for _, a := range result.Assertions {
+		if a.Type != AssertProtocol {
+			continue
+		}
+		if a.Passed || a.Actual != ProtoHTTP1 {
+			t.Errorf("protocol assertion = %+v", a)
+		}
+		return
+	}
+	t.Fatalf("no protocol assertion in %+v", result.Assertions)
+}
@@ -0,0 +1,86 @@
+package web_lib
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultMaxRedirects 默认最多跟随的跳转次数，与 net/http 一致
+const DefaultMaxRedirects = 10
+
+// RedirectHop 跳转链上的一跳
+type RedirectHop struct {
+	URL        string
+	StatusCode int
+	Location   string // 跳转目标，最后一跳为空
+	Latency    time.Duration
+}
+
+func WithMaxRedirects(n int) CheckerOption {
+	return func(c *HTTPChecker) { c.maxRedirects = n }
+}
+
+// hopRecorder 记录每一跳的地址、状态码与耗时
+type hopRecorder struct {
+	next http.RoundTripper
+	hops []RedirectHop
+}
+
+func (t *hopRecorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	hop := RedirectHop{URL: req.URL.String(), Latency: time.Since(start)}
+	if resp != nil {
+		hop.StatusCode = resp.StatusCode
+		if location, err := resp.Location(); err == nil {
+			hop.Location = location.String()
+		}
+	}
+	t.hops = append(t.hops, hop)
+	return resp, err
+}
+
+// redirectPolicy 返回 CheckRedirect，超过次数或出现循环时停在当前响应，并把原因写入 stop
+func (c *HTTPChecker) redirectPolicy(stop *string) func(*http.Request, []*http.Request) error {
+	return func(req *http.Request, via []*http.Request) error {
+		if !c.followRedirect {
+			return http.ErrUseLastResponse
+		}
+		for _, prev := range via {
+			if prev.URL.String() == req.URL.String() {
+				*stop = fmt.Sprintf("redirect loop detected at %s", req.URL)
+				return http.ErrUseLastResponse
+			}
+		}
+		if len(via) > c.maxRedirects {
+			*stop = fmt.Sprintf("too many redirects (limit %d)", c.maxRedirects)
+			return http.ErrUseLastResponse
+		}
+		return nil
+	}
+}
+
+// checkHTTPSRedirect 以 http 开始的请求必须跳转到 https 并停在 https 上
+func checkHTTPSRedirect(start string, hops []RedirectHop) (bool, string) {
+	u, err := url.Parse(start)
+	if err != nil || u.Scheme != "http" {
+		return true, ""
+	}
+	if len(hops) < 2 {
+		return false, "no redirect from http"
+	}
+	final, err := url.Parse(hops[len(hops)-1].URL)
+	if err != nil || final.Scheme != "https" {
+		return false, "final url is not https"
+	}
+	return true, ""
+}
+
+// inDomain 判断 host 是否为 domain 或其子域名
+func inDomain(host, domain string) bool {
+	host, domain = strings.ToLower(host), strings.ToLower(strings.TrimPrefix(domain, "."))
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
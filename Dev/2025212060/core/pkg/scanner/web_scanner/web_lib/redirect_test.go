@@ -0,0 +1,191 @@
+package web_lib
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// newRedirectServer /start -> /middle -> /end，/loop 与 /loop2 互相跳转，/chain/N 跳 N 次
+func newRedirectServer(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/start", http.RedirectHandler("/middle", http.StatusFound))
+	mux.Handle("/middle", http.RedirectHandler("/end", http.StatusMovedPermanently))
+	mux.HandleFunc("/end", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "ok")
+	})
+	mux.Handle("/loop", http.RedirectHandler("/loop2", http.StatusFound))
+	mux.Handle("/loop2", http.RedirectHandler("/loop", http.StatusFound))
+	mux.HandleFunc("/chain/", func(w http.ResponseWriter, r *http.Request) {
+		n, _ := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/chain/"))
+		if n <= 0 {
+			fmt.Fprint(w, "done")
+			return
+		}
+		http.Redirect(w, r, "/chain/"+strconv.Itoa(n-1), http.StatusFound)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestRedirectChain(t *testing.T) {
+	srv := newRedirectServer(t)
+	result := NewHTTPChecker(WithAssertion(
+		Assertion{Type: AssertRedirects, Operator: OpEqual, Value: "2"},
+		Assertion{Type: AssertFinalURL, Value: srv.URL + "/end"},
+	)).Check(srv.URL + "/start")
+	if result.Error != nil || !result.Available {
+		t.Fatalf("error %v, assertions %+v", result.Error, result.Assertions)
+	}
+
+	want := []struct {
+		path     string
+		status   int
+		location string
+	}{
+		{"/start", http.StatusFound, srv.URL + "/middle"},
+		{"/middle", http.StatusMovedPermanently, srv.URL + "/end"},
+		{"/end", http.StatusOK, ""},
+	}
+	if len(result.Redirects) != len(want) {
+		t.Fatalf("hops = %+v", result.Redirects)
+	}
+	for i, w := range want {
+		hop := result.Redirects[i]
+		if hop.URL != srv.URL+w.path || hop.StatusCode != w.status || hop.Location != w.location {
+			t.Errorf("hop %d = %+v, want %s %d %s", i, hop, w.path, w.status, w.location)
+		}
+		if hop.Latency <= 0 {
+			t.Errorf("hop %d has no latency", i)
+		}
+	}
+	if result.FinalURL != srv.URL+"/end" {
+		t.Errorf("FinalURL = %s", result.FinalURL)
+	}
+}
+
+func TestRedirectPolicy(t *testing.T) {
+	srv := newRedirectServer(t)
+	tests := []struct {
+		name       string
+		path       string
+		opts       []CheckerOption
+		hops       int
+		status     int
+		stopReason string // 为空表示没有因跳转策略失败
+	}{
+		{"loop", "/loop", nil, 2, http.StatusFound, "redirect loop detected"},
+		{"within limit", "/chain/3", []CheckerOption{WithMaxRedirects(3)}, 4, http.StatusOK, ""},
+		{"over limit", "/chain/5", []CheckerOption{WithMaxRedirects(2)}, 3, http.StatusFound, "too many redirects (limit 2)"},
+		{"no redirect", "/start", []CheckerOption{NoRedirect()}, 1, http.StatusFound, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := NewHTTPChecker(tt.opts...).Check(srv.URL + tt.path)
+			if result.Error != nil {
+				t.Fatal(result.Error)
+			}
+			if len(result.Redirects) != tt.hops || result.StatusCode != tt.status {
+				t.Fatalf("status %d hops %+v", result.StatusCode, result.Redirects)
+			}
+			last := result.Assertions[len(result.Assertions)-1]
+			if tt.stopReason == "" {
+				if !result.Available {
+					t.Fatalf("unexpected failure: %+v", result.Assertions)
+				}
+				return
+			}
+			if result.Available || last.Type != AssertRedirects || !strings.Contains(last.Message, tt.stopReason) {
+				t.Fatalf("available %v, last assertion %+v", result.Available, last)
+			}
+		})
+	}
+}
+
+func TestHTTPSRedirectAssertion(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "ok")
+	})
+	secure := httptest.NewTLSServer(ok)
+	t.Cleanup(secure.Close)
+	upgrade := httptest.NewServer(http.RedirectHandler(secure.URL+"/", http.StatusMovedPermanently))
+	t.Cleanup(upgrade.Close)
+	plain := httptest.NewServer(ok)
+	t.Cleanup(plain.Close)
+
+	check := func(url string) AssertionResult {
+		result := NewHTTPChecker(SkipTLSVerify(), WithAssertion(Assertion{Type: AssertHTTPSRedirect})).Check(url)
+		if result.Error != nil {
+			t.Fatal(result.Error)
+		}
+		for _, a := range result.Assertions {
+			if a.Type == AssertHTTPSRedirect {
+				return a
+			}
+		}
+		t.Fatal("https_redirect assertion missing")
+		return AssertionResult{}
+	}
+	if a := check(upgrade.URL); !a.Passed || a.Actual != secure.URL+"/" {
+		t.Errorf("redirect to https: %+v", a)
+	}
+	if a := check(plain.URL); a.Passed || a.Message != "no redirect from http" {
+		t.Errorf("plain http: %+v", a)
+	}
+}
+
+func TestCheckHTTPSRedirect(t *testing.T) {
+	hops := func(urls ...string) []RedirectHop {
+		out := make([]RedirectHop, len(urls))
+		for i, u := range urls {
+			out[i] = RedirectHop{URL: u}
+		}
+		return out
+	}
+	tests := []struct {
+		name   string
+		start  string
+		hops   []RedirectHop
+		ok     bool
+		reason string
+	}{
+		{"https start is exempt", "https://example.com", hops("https://example.com"), true, ""},
+		{"upgraded", "http://example.com", hops("http://example.com", "https://example.com/"), true, ""},
+		{"upgraded via http hop", "http://a.com", hops("http://a.com", "http://www.a.com", "https://www.a.com"), true, ""},
+		{"no redirect", "http://example.com", hops("http://example.com"), false, "no redirect from http"},
+		{"stays on http", "http://example.com", hops("http://example.com", "http://www.example.com"), false, "final url is not https"},
+		{"downgraded again", "http://a.com", hops("http://a.com", "https://a.com", "http://b.com"), false, "final url is not https"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, reason := checkHTTPSRedirect(tt.start, tt.hops)
+			if ok != tt.ok || reason != tt.reason {
+				t.Fatalf("got %v %q, want %v %q", ok, reason, tt.ok, tt.reason)
+			}
+		})
+	}
+}
+
+func TestInDomain(t *testing.T) {
+	tests := []struct {
+		host, domain string
+		want         bool
+	}{
+		{"example.com", "example.com", true},
+		{"www.example.com", "example.com", true},
+		{"a.b.Example.COM", "example.com", true},
+		{"www.example.com", ".example.com", true},
+		{"badexample.com", "example.com", false},
+		{"example.com.evil.net", "example.com", false},
+		{"example.com", "www.example.com", false},
+	}
+	for _, tt := range tests {
+		if got := inDomain(tt.host, tt.domain); got != tt.want {
+			t.Errorf("inDomain(%q, %q) = %v, want %v", tt.host, tt.domain, got, tt.want)
+		}
+	}
+}
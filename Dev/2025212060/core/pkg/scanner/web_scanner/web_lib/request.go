@@ -0,0 +1,148 @@
+package web_lib
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// 请求体类型
+const (
+	BodyRaw  = "raw"
+	BodyJSON = "json"
+	BodyForm = "form"
+)
+
+// WithBody 设置原始请求体，contentType 为空时不设置 Content-Type
+func WithBody(contentType string, body []byte) CheckerOption {
+	return func(c *HTTPChecker) {
+		c.body = body
+		c.contentType = contentType
+	}
+}
+
+// WithJSONBody 设置 JSON 请求体，发送前会校验格式
+func WithJSONBody(body string) CheckerOption {
+	return func(c *HTTPChecker) {
+		c.body = []byte(body)
+		c.contentType = "application/json"
+	}
+}
+
+// WithFormBody 设置 application/x-www-form-urlencoded 请求体
+func WithFormBody(values url.Values) CheckerOption {
+	return func(c *HTTPChecker) {
+		c.body = []byte(values.Encode())
+		c.contentType = "application/x-www-form-urlencoded"
+	}
+}
+
+// WithClientCertificate 设置 mTLS 客户端证书（PEM）
+func WithClientCertificate(certPEM, keyPEM []byte) CheckerOption {
+	return func(c *HTTPChecker) {
+		c.clientCertPEM = certPEM
+		c.clientKeyPEM = keyPEM
+	}
+}
+
+// WithCACert 设置自定义 CA 证书包（PEM），替代系统根证书
+func WithCACert(pem []byte) CheckerOption {
+	return func(c *HTTPChecker) { c.caPEM = pem }
+}
+
+// WithProxy 设置代理，支持 http://、https:// 与 socks5://
+func WithProxy(proxyURL string) CheckerOption {
+	return func(c *HTTPChecker) { c.proxy = proxyURL }
+}
+
+func WithSourceAddr(addr string) CheckerOption {
+	return func(c *HTTPChecker) { c.sourceAddr = addr }
+}
+
+// newBody 校验并返回请求体
+func (c *HTTPChecker) newBody() (io.Reader, error) {
+	if c.body == nil {
+		return nil, nil
+	}
+	if c.contentType == "application/json" && !json.Valid(c.body) {
+		return nil, fmt.Errorf("invalid json body")
+	}
+	return bytes.NewReader(c.body), nil
+}
+
+// tlsConfig 组装证书校验、客户端证书与自定义 CA
+func (c *HTTPChecker) tlsConfig() (*tls.Config, error) {
+	config := &tls.Config{
+		InsecureSkipVerify: c.skipTLSVerify,
+	}
+	if c.clientCertPEM != nil || c.clientKeyPEM != nil {
+		cert, err := tls.X509KeyPair(c.clientCertPEM, c.clientKeyPEM)
+		if err != nil {
+			return nil, fmt.Errorf("load client certificate failed: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	if c.caPEM != nil {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(c.caPEM) {
+			return nil, fmt.Errorf("no certificate found in ca bundle")
+		}
+		config.RootCAs = pool
+	}
+	return config, nil
+}
+
+// newTransport 根据协议、代理、源地址与 TLS 配置创建 Transport
+func (c *HTTPChecker) newTransport() (roundTripper, error) {
+	tlsConfig, err := c.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+	if c.protocol == ProtoHTTP3 {
+		return c.newHTTP3Transport(tlsConfig)
+	}
+	protocols, err := c.httpProtocols()
+	if err != nil {
+		return nil, err
+	}
+	return c.newHTTPTransport(tlsConfig, protocols)
+}
+
+// newHTTPTransport 创建带源地址与代理设置的 Transport，protocols 为 nil 时使用默认的 HTTP/1.1 与 h2
+func (c *HTTPChecker) newHTTPTransport(tlsConfig *tls.Config, protocols *http.Protocols) (*http.Transport, error) {
+	dialer := &net.Dialer{Timeout: c.timeout}
+	if c.sourceAddr != "" {
+		ip := net.ParseIP(c.sourceAddr)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid source address: %s", c.sourceAddr)
+		}
+		dialer.LocalAddr = &net.TCPAddr{IP: ip}
+	}
+
+	transport := &http.Transport{
+		DialContext:     dialer.DialContext,
+		TLSClientConfig: tlsConfig,
+		IdleConnTimeout: idleConnTimeout,
+		Protocols:       protocols,
+	}
+	if c.proxy != "" {
+		proxyURL, err := url.Parse(c.proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy: %w", err)
+		}
+		switch strings.ToLower(proxyURL.Scheme) {
+		case "http", "https", "socks5", "socks5h":
+		default:
+			return nil, fmt.Errorf("unsupported proxy scheme: %s", proxyURL.Scheme)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+	return transport, nil
+}
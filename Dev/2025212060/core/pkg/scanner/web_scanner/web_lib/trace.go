@@ -0,0 +1,164 @@
+package web_lib
+
+import (
+	"crypto/tls"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// 耗时阶段名称，按请求先后顺序
+const (
+	PhaseRedirect         = "redirect"
+	PhaseDNSLookup        = "dns"
+	PhaseTCPConnect       = "connect"
+	PhaseTLSHandshake     = "tls"
+	PhaseServerProcessing = "server"
+	PhaseContentTransfer  = "transfer"
+)
+
+// Timing 请求各阶段耗时，只统计最后一跳，之前的跳转耗时计入 Redirect
+type Timing struct {
+	Redirect         time.Duration
+	DNSLookup        time.Duration
+	TCPConnect       time.Duration
+	TLSHandshake     time.Duration
+	ServerProcessing time.Duration // 请求发送完到收到首字节
+	ContentTransfer  time.Duration // 首字节到响应体读完
+	TimeToFirstByte  time.Duration // 从开始请求到收到首字节
+	ConnReused       bool          // 复用连接时没有 DNS、连接与 TLS 耗时
+}
+
+// TimingPhase 单个阶段耗时
+type TimingPhase struct {
+	Name     string
+	Duration time.Duration
+}
+
+// Phases 按顺序返回各阶段耗时，累加约等于总耗时，可直接画成堆叠柱状图
+func (t Timing) Phases() []TimingPhase {
+	return []TimingPhase{
+		{PhaseRedirect, t.Redirect},
+		{PhaseDNSLookup, t.DNSLookup},
+		{PhaseTCPConnect, t.TCPConnect},
+		{PhaseTLSHandshake, t.TLSHandshake},
+		{PhaseServerProcessing, t.ServerProcessing},
+		{PhaseContentTransfer, t.ContentTransfer},
+	}
+}
+
+// tracePoints 一次请求各阶段的时间点
+type tracePoints struct {
+	start        time.Time
+	getConn      time.Time
+	dnsStart     time.Time
+	dnsDone      time.Time
+	connectStart time.Time
+	connectDone  time.Time
+	tlsStart     time.Time
+	tlsDone      time.Time
+	wroteRequest time.Time
+	firstByte    time.Time
+	reused       bool
+}
+
+// tracer 通过 httptrace 记录各阶段时间点
+// 回调可能来自不同 goroutine（并发建连、HTTP/2 读循环），读写都需持锁
+type tracer struct {
+	mu     sync.Mutex
+	points tracePoints
+}
+
+// record 持锁修改时间点
+func (t *tracer) record(update func(p *tracePoints)) {
+	t.mu.Lock()
+	update(&t.points)
+	t.mu.Unlock()
+}
+
+// setStart 设置请求开始时间
+func (t *tracer) setStart(start time.Time) {
+	t.record(func(p *tracePoints) { p.start = start })
+}
+
+// snapshot 返回当前时间点的副本
+func (t *tracer) snapshot() tracePoints {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.points
+}
+
+func (t *tracer) clientTrace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		// 每一跳都会重新取连接，只保留最后一跳的时间点
+		GetConn: func(string) {
+			now := time.Now()
+			t.record(func(p *tracePoints) { *p = tracePoints{start: p.start, getConn: now} })
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.record(func(p *tracePoints) { p.reused = info.Reused })
+		},
+		DNSStart: func(httptrace.DNSStartInfo) {
+			now := time.Now()
+			t.record(func(p *tracePoints) { p.dnsStart = now })
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			now := time.Now()
+			t.record(func(p *tracePoints) { p.dnsDone = now })
+		},
+		// 多地址时会并发建连，取第一次开始与成功的那次结束
+		ConnectStart: func(string, string) {
+			now := time.Now()
+			t.record(func(p *tracePoints) {
+				if p.connectStart.IsZero() {
+					p.connectStart = now
+				}
+			})
+		},
+		ConnectDone: func(_, _ string, err error) {
+			if err != nil {
+				return
+			}
+			now := time.Now()
+			t.record(func(p *tracePoints) { p.connectDone = now })
+		},
+		TLSHandshakeStart: func() {
+			now := time.Now()
+			t.record(func(p *tracePoints) { p.tlsStart = now })
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			now := time.Now()
+			t.record(func(p *tracePoints) { p.tlsDone = now })
+		},
+		WroteRequest: func(httptrace.WroteRequestInfo) {
+			now := time.Now()
+			t.record(func(p *tracePoints) { p.wroteRequest = now })
+		},
+		GotFirstResponseByte: func() {
+			now := time.Now()
+			t.record(func(p *tracePoints) { p.firstByte = now })
+		},
+	}
+}
+
+// timing 根据记录的时间点计算各阶段耗时，done 为响应体读完的时间
+func (t *tracer) timing(done time.Time) Timing {
+	p := t.snapshot()
+	return Timing{
+		Redirect:         between(p.start, p.getConn),
+		DNSLookup:        between(p.dnsStart, p.dnsDone),
+		TCPConnect:       between(p.connectStart, p.connectDone),
+		TLSHandshake:     between(p.tlsStart, p.tlsDone),
+		ServerProcessing: between(p.wroteRequest, p.firstByte),
+		ContentTransfer:  between(p.firstByte, done),
+		TimeToFirstByte:  between(p.start, p.firstByte),
+		ConnReused:       p.reused,
+	}
+}
+
+func between(from, to time.Time) time.Duration {
+	if from.IsZero() || to.IsZero() || to.Before(from) {
+		return 0
+	}
+	return to.Sub(from)
+}
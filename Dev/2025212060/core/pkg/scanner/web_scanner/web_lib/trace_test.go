@@ -0,0 +1,75 @@
+package web_lib
+
+import (
+	"crypto/tls"
+	"net/http/httptrace"
+	"sync"
+	"testing"
+	"time"
+)
+
+// 回调与读取并发进行，配合 -race 检查
+func TestTracerConcurrentCallbacks(t *testing.T) {
+	trace := &tracer{}
+	trace.setStart(time.Now())
+	ct := trace.clientTrace()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				ct.GetConn("example.com:443")
+				ct.DNSStart(httptrace.DNSStartInfo{})
+				ct.DNSDone(httptrace.DNSDoneInfo{})
+				ct.ConnectStart("tcp", "192.0.2.1:443")
+				ct.ConnectDone("tcp", "192.0.2.1:443", nil)
+				ct.TLSHandshakeStart()
+				ct.TLSHandshakeDone(tls.ConnectionState{}, nil)
+				ct.GotConn(httptrace.GotConnInfo{})
+				ct.WroteRequest(httptrace.WroteRequestInfo{})
+				ct.GotFirstResponseByte()
+				_ = trace.timing(time.Now())
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestTracerTiming(t *testing.T) {
+	base := time.Now()
+	at := func(ms int) time.Time { return base.Add(time.Duration(ms) * time.Millisecond) }
+
+	trace := &tracer{}
+	trace.setStart(base)
+	trace.record(func(p *tracePoints) {
+		p.getConn = at(10)
+		p.dnsStart, p.dnsDone = at(10), at(15)
+		p.connectStart, p.connectDone = at(15), at(25)
+		p.tlsStart, p.tlsDone = at(25), at(45)
+		p.wroteRequest = at(46)
+		p.firstByte = at(96)
+	})
+
+	got := trace.timing(at(100))
+	want := Timing{
+		Redirect:         10 * time.Millisecond,
+		DNSLookup:        5 * time.Millisecond,
+		TCPConnect:       10 * time.Millisecond,
+		TLSHandshake:     20 * time.Millisecond,
+		ServerProcessing: 50 * time.Millisecond,
+		ContentTransfer:  4 * time.Millisecond,
+		TimeToFirstByte:  96 * time.Millisecond,
+	}
+	if got != want {
+		t.Errorf("timing = %+v, want %+v", got, want)
+	}
+
+	// 新的一跳清空之前的时间点，只保留开始时间
+	trace.clientTrace().GetConn("example.com:443")
+	p := trace.snapshot()
+	if !p.start.Equal(base) || !p.dnsStart.IsZero() || !p.firstByte.IsZero() || p.getConn.IsZero() {
+		t.Errorf("GetConn did not reset the hop: %+v", p)
+	}
+}
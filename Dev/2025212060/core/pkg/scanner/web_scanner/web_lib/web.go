@@ -2,10 +2,11 @@ package web_lib
 
 import (
 	"context"
-	"crypto/tls"
 	"fmt"
 	"io"
 	"net/http"
+	"net/http/httptrace"
+	"redrock-dashboard/core/pkg/charset"
 	"regexp"
 	"strings"
 	"time"
@@ -18,6 +19,23 @@ type HTTPChecker struct {
 	followRedirect bool
 	skipTLSVerify  bool
 	headers        map[string]string
+	assertions     []Assertion
+	maxBodySize    int64 // 读入内存用于断言的最大字节数，超出部分只计数不检查
+
+	body          []byte
+	contentType   string
+	auth          *auth
+	clientCertPEM []byte
+	clientKeyPEM  []byte
+	caPEM         []byte
+	proxy         string // http://、https:// 或 socks5://
+	sourceAddr    string // 绑定的源地址，为空则由系统选择
+	monitorID     string
+	connMode      string
+	maxRedirects  int
+	protocol      string
+	har           bool
+	harSecrets    []string
 }
 
 // CheckResult 检测结果
@@ -25,8 +43,17 @@ type CheckResult struct {
 	URL        string
 	Available  bool
 	StatusCode int
+	Protocol   string // 实际使用的协议：http/1.1、h2、h2c、h3
 	TotalTime  time.Duration
-	Title      string // 网页标题
+	Timing     Timing        // DNS、连接、TLS、首字节与传输耗时
+	Title      string        // 网页标题
+	BodySize   int64         // 响应体完整大小
+	Text       string        // 按字符集解码为 UTF-8 的响应体，最多 maxBodySize 字节；非文本类型为原始内容
+	Charset    string        // 识别出的字符集，非文本类型为空
+	Redirects  []RedirectHop // 完整跳转链，包含最后一跳
+	FinalURL   string
+	Assertions []AssertionResult
+	HAR        []byte // 开启 HAR 记录时的 HAR 文件内容，已脱敏；请求失败时也会生成
 	Error      error
 }
 
@@ -40,6 +67,9 @@ func NewHTTPChecker(opts ...CheckerOption) *HTTPChecker {
 		method:         "GET",
 		followRedirect: true,
 		skipTLSVerify:  false,
+		maxBodySize:    1 << 20,
+		connMode:       ConnKeepAlive,
+		maxRedirects:   DefaultMaxRedirects,
 		headers: map[string]string{
 			"User-Agent": "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36",
 		},
@@ -70,6 +100,15 @@ func WithHeader(key, value string) CheckerOption {
 	return func(c *HTTPChecker) { c.headers[key] = value }
 }
 
+// WithAssertion 添加响应断言，全部通过才算可用
+func WithAssertion(assertions ...Assertion) CheckerOption {
+	return func(c *HTTPChecker) { c.assertions = append(c.assertions, assertions...) }
+}
+
+func WithMaxBodySize(n int64) CheckerOption {
+	return func(c *HTTPChecker) { c.maxBodySize = n }
+}
+
 // Check 检测单个 URL（唯一对外接口）
 func (c *HTTPChecker) Check(url string) *CheckResult {
 	result := &CheckResult{
@@ -80,60 +119,127 @@ func (c *HTTPChecker) Check(url string) *CheckResult {
 	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
 	defer cancel()
 
-	req, err := http.NewRequestWithContext(ctx, c.method, url, nil)
+	trace := &tracer{}
+	ctx = httptrace.WithClientTrace(ctx, trace.clientTrace())
+
+	body, err := c.newBody()
+	if err != nil {
+		result.Error = err
+		return result
+	}
+
+	req, err := http.NewRequestWithContext(ctx, c.method, url, body)
 	if err != nil {
 		result.Error = fmt.Errorf("create request failed: %w", err)
 		return result
 	}
 
+	if c.contentType != "" {
+		req.Header.Set("Content-Type", c.contentType)
+	}
 	for k, v := range c.headers {
 		req.Header.Set(k, v)
 	}
 
-	transport := &http.Transport{
-		TLSClientConfig: &tls.Config{
-			InsecureSkipVerify: c.skipTLSVerify,
-		},
+	transport, release, err := c.acquireTransport()
+	if err != nil {
+		result.Error = err
+		return result
+	}
+	defer release()
+	var roundTripper http.RoundTripper = transport
+	if c.har {
+		recorder := &harRecorder{next: transport}
+		roundTripper = recorder
+		// 在关闭响应体之后生成，需要先于 resp.Body.Close 注册
+		defer func() {
+			if data, err := recorder.build(c.harSecrets); err == nil {
+				result.HAR = data
+			}
+		}()
+	}
+	if c.auth != nil && c.auth.kind == AuthDigest {
+		roundTripper = &digestTransport{username: c.auth.username, password: c.auth.password, next: roundTripper}
 	}
 
+	// 跳转链记录放在最外层，digest 的质询重发只算一跳
+	recorder := &hopRecorder{next: roundTripper}
+	var stopReason string
 	client := &http.Client{
-		Transport: transport,
-		Timeout:   c.timeout,
+		Transport:     recorder,
+		Timeout:       c.timeout,
+		CheckRedirect: c.redirectPolicy(&stopReason),
 	}
-	if !c.followRedirect {
-		client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
-			return http.ErrUseLastResponse
-		}
+	if err := c.applyAuth(req); err != nil {
+		result.Error = err
+		return result
 	}
-
 	start := time.Now()
+	trace.setStart(start)
 	resp, err := client.Do(req)
-	result.TotalTime = time.Since(start)
-
+	result.Redirects = recorder.hops
 	if err != nil {
+		result.TotalTime = time.Since(start)
+		result.Timing = trace.timing(time.Time{})
 		result.Error = fmt.Errorf("request failed: %w", err)
 		return result
 	}
 	defer resp.Body.Close()
 
 	result.StatusCode = resp.StatusCode
-	result.Available = resp.StatusCode >= 200 && resp.StatusCode < 400
+	result.FinalURL = resp.Request.URL.String()
+	result.Protocol = negotiatedProtocol(resp)
+
+	// 读完响应体才能得到传输耗时，超过上限的部分只计数
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, c.maxBodySize))
+	rest, _ := io.Copy(io.Discard, resp.Body)
+	done := time.Now()
+	result.TotalTime = done.Sub(start)
+	result.Timing = trace.timing(done)
+	if err != nil {
+		result.Error = fmt.Errorf("read body failed: %w", err)
+		return result
+	}
+	result.BodySize = int64(len(respBody)) + rest
+
+	// 文本内容统一转为 UTF-8，断言与标题才能正确处理 GBK 等编码的页面
+	result.Text = string(respBody)
+	if contentType := resp.Header.Get("Content-Type"); isText(contentType) {
+		result.Text, result.Charset = charset.Decode(respBody, contentType)
+	}
+	text := []byte(result.Text)
+
+	result.Assertions = evaluate(c.assertions, &response{
+		statusCode: resp.StatusCode,
+		header:     resp.Header,
+		body:       text,
+		truncated:  rest > 0,
+		size:       result.BodySize,
+		startURL:   url,
+		hops:       result.Redirects,
+		stopReason: stopReason,
+		protocol:   result.Protocol,
+	})
+	result.Available = true
+	for _, a := range result.Assertions {
+		if !a.Passed {
+			result.Available = false
+		}
+	}
 
 	// 获取 Title
 	if result.Available {
-		result.Title = c.extractTitle(resp)
+		result.Title = c.extractTitle(text)
 	}
 
 	return result
 }
 
 // extractTitle 从 HTML 中提取 title
-func (c *HTTPChecker) extractTitle(resp *http.Response) string {
-	// 只读取前 8KB，避免大页面
-	limit := int64(8192)
-	body, err := io.ReadAll(io.LimitReader(resp.Body, limit))
-	if err != nil {
-		return "unknown"
+func (c *HTTPChecker) extractTitle(body []byte) string {
+	// 只看前 8KB，避免大页面
+	if len(body) > 8192 {
+		body = body[:8192]
 	}
 
 	// 正则匹配 title
@@ -143,9 +249,7 @@ func (c *HTTPChecker) extractTitle(resp *http.Response) string {
 		title := strings.TrimSpace(string(matches[1]))
 		// 清理空白字符
 		title = strings.Join(strings.Fields(title), " ")
-		if len(title) > 100 {
-			title = title[:100] + "..."
-		}
+		title = charset.Truncate(title, 100)
 		if title != "" {
 			return title
 		}
@@ -0,0 +1,54 @@
+package secret
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// 引用前缀，监控配置里只保存引用，扫描时才解析出明文
+const (
+	PrefixEnv  = "env:"  // env:API_TOKEN 读取环境变量
+	PrefixFile = "file:" // file:/run/secrets/token 读取文件内容（去掉末尾换行）
+)
+
+// IsRef 判断是否为密钥引用
+func IsRef(value string) bool {
+	return strings.HasPrefix(value, PrefixEnv) || strings.HasPrefix(value, PrefixFile)
+}
+
+// Resolve 解析密钥引用，不带前缀的值原样返回
+func Resolve(value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, PrefixEnv):
+		name := strings.TrimPrefix(value, PrefixEnv)
+		v, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("secret env %s not set", name)
+		}
+		return v, nil
+	case strings.HasPrefix(value, PrefixFile):
+		path := strings.TrimPrefix(value, PrefixFile)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("read secret file failed: %w", err)
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	}
+	return value, nil
+}
+
+// ResolveAll 依次解析多个引用，遇到错误立即返回
+func ResolveAll(values ...*string) error {
+	for _, v := range values {
+		if v == nil || *v == "" {
+			continue
+		}
+		resolved, err := Resolve(*v)
+		if err != nil {
+			return err
+		}
+		*v = resolved
+	}
+	return nil
+}
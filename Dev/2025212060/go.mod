@@ -4,10 +4,17 @@ go 1.25.0
 
 require (
 	gitee.com/liumou_site/logger v1.3.0
-	github.com/axgle/mahonia v0.0.0-20180208002826-3358181d7394
+	github.com/andybalholm/cascadia v1.3.3
 	github.com/miekg/dns v1.1.72
 	github.com/playwright-community/playwright-go v0.5200.1
+	github.com/pmezard/go-difflib v1.0.0
+	github.com/quic-go/quic-go v0.59.1
+	github.com/tidwall/gjson v1.18.0
+	golang.org/x/crypto v0.48.0
+	golang.org/x/image v0.25.0
 	golang.org/x/net v0.50.0
+	golang.org/x/oauth2 v0.36.0
+	golang.org/x/text v0.34.0
 )
 
 require (
@@ -16,9 +23,12 @@ require (
 	github.com/go-stack/stack v1.8.1 // indirect
 	github.com/mattn/go-colorable v0.1.13 // indirect
 	github.com/mattn/go-isatty v0.0.16 // indirect
+	github.com/quic-go/qpack v0.6.0 // indirect
 	github.com/spf13/cast v1.5.0 // indirect
-	golang.org/x/mod v0.31.0 // indirect
+	github.com/tidwall/match v1.1.1 // indirect
+	github.com/tidwall/pretty v1.2.1 // indirect
+	golang.org/x/mod v0.32.0 // indirect
 	golang.org/x/sync v0.19.0 // indirect
 	golang.org/x/sys v0.41.0 // indirect
-	golang.org/x/tools v0.40.0 // indirect
+	golang.org/x/tools v0.41.0 // indirect
 )